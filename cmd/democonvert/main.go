@@ -0,0 +1,72 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/divVerent/aaaaxy/internal/demo"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+var (
+	in          = flag.String("in", "", "demo file to read (any format)")
+	out         = flag.String("out", "", "demo file to write")
+	format      = flag.String("format", "binary", "format of the output demo; can be 'json' or 'binary'")
+	compression = flag.String("compression", "zstd", "compression of the output demo if binary; can be 'none', 'gzip' or 'zstd'")
+)
+
+func main() {
+	log.Debugf("initializing VFS...")
+	err := vfs.Init()
+	if err != nil {
+		log.Fatalf("could not initialize VFS: %v", err)
+	}
+	log.Debugf("parsing flags...")
+	flag.Parse(flag.NoConfig)
+	if *in == "" || *out == "" {
+		log.Fatalf("usage: democonvert -in=input.dem -out=output.dem [-format=json|binary] [-compression=none|gzip|zstd]")
+	}
+	f, err := demo.ParseFormat(*format)
+	if err != nil {
+		log.Fatalf("invalid -format: %v", err)
+	}
+	c := demo.NoCompression
+	if f == demo.BinaryFormat {
+		c, err = demo.ParseCompression(*compression)
+		if err != nil {
+			log.Fatalf("invalid -compression: %v", err)
+		}
+	}
+	log.Debugf("converting...")
+	r, err := vfs.OSOpen(vfs.WorkDir, *in)
+	if err != nil {
+		log.Fatalf("could not open input demo: %v", err)
+	}
+	defer r.Close()
+	w, err := vfs.OSCreate(vfs.WorkDir, *out)
+	if err != nil {
+		log.Fatalf("could not create output demo: %v", err)
+	}
+	err = demo.Convert(r, w, f, c)
+	if err != nil {
+		log.Fatalf("could not convert demo: %v", err)
+	}
+	err = w.Close()
+	if err != nil {
+		log.Fatalf("could not close output demo: %v", err)
+	}
+	log.Debugf("done.")
+}
@@ -0,0 +1,294 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// demodiff compares two demos of the same route.
+//
+// The demos are aligned on their checkpoint save events; for demos recorded
+// before checkpoint names were stored, the n-th saves are aligned. The save
+// game hashes are not used, as they include the frame counter and thus never
+// match between different runs.
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/divVerent/aaaaxy/internal/demo"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/log"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+var (
+	demoA            = flag.String("a", "", "first demo file to compare")
+	demoB            = flag.String("b", "", "second demo file to compare")
+	segmentsCSV      = flag.String("segments_csv", "", "if set, also write the per-segment frame deltas as CSV to this file")
+	positionsCSV     = flag.String("positions_csv", "", "if set, also write the position divergence over time as CSV to this file")
+	positionInterval = flag.Int("position_interval", 60, "number of frames between position divergence samples")
+)
+
+type saveEvent struct {
+	Frame      int
+	Checkpoint string
+}
+
+// key returns what to align a save event on.
+func (s saveEvent) key(i int) string {
+	if s.Checkpoint == "" {
+		// No checkpoint name recorded; align by index.
+		return "#" + strconv.Itoa(i)
+	}
+	return s.Checkpoint
+}
+
+type run struct {
+	Inputs    []*input.DemoState
+	Positions []*m.Pos
+	Saves     []saveEvent
+}
+
+func loadRun(name string) (*run, error) {
+	f, err := vfs.OSOpen(vfs.WorkDir, name)
+	if err != nil {
+		return nil, fmt.Errorf("could not open demo %v: %w", name, err)
+	}
+	defer f.Close()
+	r, err := demo.NewFrameReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not read demo %v: %w", name, err)
+	}
+	defer r.Close()
+	out := &run{}
+	var fr demo.Frame
+	for r.More() {
+		err := r.Read(&fr)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode frame %d of demo %v: %w", len(out.Inputs), name, err)
+		}
+		if fr.FinalSaveGame != nil {
+			continue
+		}
+		idx := len(out.Inputs)
+		for i := range fr.SaveGames {
+			s := saveEvent{Frame: idx}
+			if i < len(fr.SaveCheckpoints) {
+				s.Checkpoint = fr.SaveCheckpoints[i]
+			}
+			out.Saves = append(out.Saves, s)
+		}
+		out.Inputs = append(out.Inputs, fr.Input)
+		out.Positions = append(out.Positions, fr.PlayerPos)
+	}
+	return out, nil
+}
+
+type savePair struct {
+	A, B int
+}
+
+// align matches save events of both runs using a longest common subsequence
+// of their keys, so checkpoints only hit in one of the runs are skipped.
+func align(a, b []saveEvent) []savePair {
+	// best[i][j] is the most save events a[i:] and b[j:] can be aligned on.
+	best := make([][]int, len(a)+1)
+	for i := range best {
+		best[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			best[i][j] = max(best[i+1][j], best[i][j+1])
+			if a[i].key(i) == b[j].key(j) {
+				best[i][j] = max(best[i][j], best[i+1][j+1]+1)
+			}
+		}
+	}
+	var pairs []savePair
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i].key(i) == b[j].key(j) && best[i][j] == best[i+1][j+1]+1:
+			pairs = append(pairs, savePair{A: i, B: j})
+			i++
+			j++
+		case best[i+1][j] >= best[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
+
+type segment struct {
+	Checkpoint     string
+	FrameA, FrameB int
+	LenA, LenB     int
+}
+
+func segments(a, b *run) []segment {
+	var out []segment
+	prevA, prevB := 0, 0
+	for _, p := range align(a.Saves, b.Saves) {
+		sa, sb := a.Saves[p.A], b.Saves[p.B]
+		cp := sa.Checkpoint
+		if cp == "" {
+			cp = fmt.Sprintf("save %d", p.A)
+		}
+		out = append(out, segment{
+			Checkpoint: cp,
+			FrameA:     sa.Frame,
+			FrameB:     sb.Frame,
+			LenA:       sa.Frame - prevA,
+			LenB:       sb.Frame - prevB,
+		})
+		prevA, prevB = sa.Frame, sb.Frame
+	}
+	return out
+}
+
+func firstInputDivergence(a, b *run) int {
+	n := min(len(a.Inputs), len(b.Inputs))
+	for i := 0; i < n; i++ {
+		if !reflect.DeepEqual(a.Inputs[i], b.Inputs[i]) {
+			return i
+		}
+	}
+	if len(a.Inputs) != len(b.Inputs) {
+		return n
+	}
+	return -1
+}
+
+func formatPos(p *m.Pos) string {
+	if p == nil {
+		return ""
+	}
+	return p.String()
+}
+
+func divergence(a, b *m.Pos) string {
+	if a == nil || b == nil {
+		return ""
+	}
+	return strconv.FormatFloat(a.Delta(*b).Length(), 'f', 1, 64)
+}
+
+func writeCSV(name string, header []string, rows [][]string) error {
+	f, err := vfs.OSCreate(vfs.WorkDir, name)
+	if err != nil {
+		return fmt.Errorf("could not create %v: %w", name, err)
+	}
+	w := csv.NewWriter(f)
+	w.Write(header)
+	w.WriteAll(rows)
+	if err := w.Error(); err != nil {
+		f.Close()
+		return fmt.Errorf("could not write %v: %w", name, err)
+	}
+	return f.Close()
+}
+
+func main() {
+	log.Debugf("initializing VFS...")
+	err := vfs.Init()
+	if err != nil {
+		log.Fatalf("could not initialize VFS: %v", err)
+	}
+	log.Debugf("parsing flags...")
+	flag.Parse(flag.NoConfig)
+	if *demoA == "" || *demoB == "" {
+		log.Fatalf("usage: demodiff -a=first.dem -b=second.dem [-segments_csv=segments.csv] [-positions_csv=positions.csv]")
+	}
+	if *positionInterval <= 0 {
+		log.Fatalf("-position_interval must be positive")
+	}
+	log.Debugf("loading demos...")
+	a, err := loadRun(*demoA)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	b, err := loadRun(*demoB)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	log.Debugf("comparing...")
+	fmt.Printf("frames: a=%d b=%d delta=%+d\n", len(a.Inputs), len(b.Inputs), len(b.Inputs)-len(a.Inputs))
+	fmt.Printf("saves: a=%d b=%d\n", len(a.Saves), len(b.Saves))
+
+	segs := segments(a, b)
+	fmt.Printf("\nsegments:\n")
+	fmt.Printf("%-24s %8s %8s %8s %8s %8s\n", "checkpoint", "frame_a", "frame_b", "len_a", "len_b", "delta")
+	var segRows [][]string
+	for _, s := range segs {
+		fmt.Printf("%-24s %8d %8d %8d %8d %+8d\n", s.Checkpoint, s.FrameA, s.FrameB, s.LenA, s.LenB, s.LenB-s.LenA)
+		segRows = append(segRows, []string{
+			s.Checkpoint,
+			strconv.Itoa(s.FrameA), strconv.Itoa(s.FrameB),
+			strconv.Itoa(s.LenA), strconv.Itoa(s.LenB),
+			strconv.Itoa(s.LenB - s.LenA),
+		})
+	}
+
+	fmt.Printf("\nfirst diverging input: ")
+	if i := firstInputDivergence(a, b); i < 0 {
+		fmt.Printf("none\n")
+	} else {
+		var ia, ib *input.DemoState
+		if i < len(a.Inputs) {
+			ia = a.Inputs[i]
+		}
+		if i < len(b.Inputs) {
+			ib = b.Inputs[i]
+		}
+		ja, _ := json.Marshal(ia)
+		jb, _ := json.Marshal(ib)
+		fmt.Printf("frame %d\n  a: %s\n  b: %s\n", i, ja, jb)
+	}
+
+	fmt.Printf("\nposition divergence:\n")
+	fmt.Printf("%8s %-16s %-16s %10s\n", "frame", "pos_a", "pos_b", "distance")
+	var posRows [][]string
+	n := max(len(a.Positions), len(b.Positions))
+	for i := 0; i < n; i += *positionInterval {
+		var pa, pb *m.Pos
+		if i < len(a.Positions) {
+			pa = a.Positions[i]
+		}
+		if i < len(b.Positions) {
+			pb = b.Positions[i]
+		}
+		d := divergence(pa, pb)
+		fmt.Printf("%8d %-16s %-16s %10s\n", i, formatPos(pa), formatPos(pb), d)
+		posRows = append(posRows, []string{strconv.Itoa(i), formatPos(pa), formatPos(pb), d})
+	}
+
+	if *segmentsCSV != "" {
+		err := writeCSV(*segmentsCSV, []string{"checkpoint", "frame_a", "frame_b", "len_a", "len_b", "delta"}, segRows)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+	if *positionsCSV != "" {
+		err := writeCSV(*positionsCSV, []string{"frame", "pos_a", "pos_b", "distance"}, posRows)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+	log.Debugf("done.")
+}
@@ -0,0 +1,107 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// saves returns save events for the given checkpoint names, one every 100 frames.
+func saves(checkpoints ...string) []saveEvent {
+	var out []saveEvent
+	for i, cp := range checkpoints {
+		out = append(out, saveEvent{Frame: 100 * (i + 1), Checkpoint: cp})
+	}
+	return out
+}
+
+func TestAlign(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		a, b []saveEvent
+		want []savePair
+	}{
+		{
+			name: "empty",
+			a:    nil,
+			b:    saves("x"),
+			want: nil,
+		},
+		{
+			name: "same",
+			a:    saves("x", "y", "z"),
+			b:    saves("x", "y", "z"),
+			want: []savePair{{0, 0}, {1, 1}, {2, 2}},
+		},
+		{
+			name: "skipped",
+			a:    saves("x", "y", "z"),
+			b:    saves("x", "z"),
+			want: []savePair{{0, 0}, {2, 1}},
+		},
+		{
+			name: "extra",
+			a:    saves("x", "z"),
+			b:    saves("x", "y", "z"),
+			want: []savePair{{0, 0}, {1, 2}},
+		},
+		{
+			name: "skipped and extra",
+			a:    saves("x", "y", "z", "w"),
+			b:    saves("x", "z", "v", "w"),
+			want: []savePair{{0, 0}, {2, 1}, {3, 3}},
+		},
+		{
+			name: "revisited",
+			a:    saves("x", "y", "x"),
+			b:    saves("x", "x"),
+			want: []savePair{{0, 0}, {2, 1}},
+		},
+		{
+			name: "unnamed",
+			a:    saves("", "", ""),
+			b:    saves("", ""),
+			want: []savePair{{0, 0}, {1, 1}},
+		},
+		{
+			name: "unnamed against named",
+			a:    saves("", ""),
+			b:    saves("x", "y"),
+			want: nil,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			got := align(c.a, c.b)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("align: got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestSegments(t *testing.T) {
+	a := &run{Saves: saves("x", "y", "z")}
+	b := &run{Saves: saves("x", "z")}
+	b.Saves[1].Frame = 150
+	got := segments(a, b)
+	want := []segment{
+		{Checkpoint: "x", FrameA: 100, FrameB: 100, LenA: 100, LenB: 100},
+		{Checkpoint: "z", FrameA: 300, FrameB: 150, LenA: 200, LenB: 50},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("segments: got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// demorun plays back a demo headless, i.e. without window, GPU or audio.
+// It accepts the same flags as the game and must be run from the source directory,
+// after go generate has created the generated assets.
+package main
+
+import (
+	"errors"
+
+	"github.com/divVerent/aaaaxy/internal/aaaaxy"
+	"github.com/divVerent/aaaaxy/internal/atexit"
+	"github.com/divVerent/aaaaxy/internal/exitstatus"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/log"
+)
+
+func main() {
+	defer atexit.Finish()
+
+	// Turn all panics into Fatalf for uniform exception handling.
+	ok := false
+	defer func() {
+		if !ok {
+			log.Fatalf("got panic: %v", recover())
+		}
+	}()
+
+	flag.Parse(flag.NoConfig)
+
+	game := aaaaxy.NewGame()
+	err := game.RunHeadless()
+	errbe := game.BeforeExit()
+	// From here on, nothing can panic.
+	ok = true
+	if err != nil && !errors.Is(err, exitstatus.ErrRegularTermination) {
+		log.Fatalf("headless demo playback exited abnormally: %v", err)
+	}
+	if errbe != nil {
+		log.Fatalf("BeforeExit exited abnormally: %v", errbe)
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/divVerent/aaaaxy/internal/demo"
+)
+
+// As the game uses global state, each demo runs in a subprocess of the test binary.
+// The arguments for it are passed newline separated in this environment variable.
+const runDemoEnv = "AAAAXY_DEMORUN_TEST_ARGS"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runDemoEnv) != "" {
+		os.Args = append(os.Args[:1], strings.Split(os.Getenv(runDemoEnv), "\n")...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// playDemo plays back a demo in a subprocess.
+func playDemo(root string, args ...string) ([]byte, error) {
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	cmd.Dir = root
+	cmd.Env = append(os.Environ(), runDemoEnv+"="+strings.Join(args, "\n"))
+	return cmd.CombinedOutput()
+}
+
+// runDemo plays back a demo in a subprocess and fails the test if that fails.
+func runDemo(t *testing.T, root string, args ...string) {
+	t.Helper()
+	out, err := playDemo(root, args...)
+	if err != nil {
+		t.Fatalf("demo playback failed: %v\n%s", err, out)
+	}
+}
+
+// listDemos returns the source directory and the demos to play, i.e. the
+// shipped ones and the test fixtures.
+func listDemos(t *testing.T) (string, []string) {
+	t.Helper()
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatalf("could not find source directory: %v", err)
+	}
+	// The game cannot start without the generated assets.
+	_, err = os.Stat(filepath.Join(root, "assets", "generated", "version.txt"))
+	if err != nil {
+		t.Skipf("generated assets are missing, run go generate in %v first: %v", root, err)
+	}
+	demos, err := filepath.Glob(filepath.Join(root, "assets", "demos", "*.dem"))
+	if err != nil {
+		t.Fatalf("could not list demos: %v", err)
+	}
+	if len(demos) == 0 {
+		t.Fatalf("no demos found in %v", root)
+	}
+	// respawn.dem respawns the player on spikes, so it has keyframes.
+	fixtures, err := filepath.Glob(filepath.Join(root, "cmd", "demorun", "testdata", "*.dem"))
+	if err != nil {
+		t.Fatalf("could not list test demos: %v", err)
+	}
+	return root, append(demos, fixtures...)
+}
+
+func TestDemos(t *testing.T) {
+	if testing.Short() {
+		t.Skip("demo playback is slow")
+	}
+	root, demos := listDemos(t)
+	for _, d := range demos {
+		t.Run(filepath.Base(d), func(t *testing.T) {
+			runDemo(t, root,
+				"-demo_play="+d,
+				"-debug_check_entity_overlaps",
+				"-debug_check_tile_window_size",
+			)
+		})
+	}
+}
+
+// keyframes returns the number of frames and the indices of the keyframes of a demo.
+func keyframes(t *testing.T, name string) (int, []int) {
+	t.Helper()
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("could not open demo: %v", err)
+	}
+	defer f.Close()
+	r, err := demo.NewFrameReader(f)
+	if err != nil {
+		t.Fatalf("could not read demo: %v", err)
+	}
+	defer r.Close()
+	n := 0
+	var kfs []int
+	var fr demo.Frame
+	for r.More() {
+		err := r.Read(&fr)
+		if err != nil {
+			t.Fatalf("could not decode frame %d: %v", n, err)
+		}
+		if fr.FinalSaveGame != nil {
+			continue
+		}
+		if n > 0 && fr.Keyframe != nil {
+			kfs = append(kfs, n)
+		}
+		n++
+	}
+	return n, kfs
+}
+
+// seekReport is the part of the regression report that TestSeek checks.
+type seekReport struct {
+	Frames      int
+	Regressions []struct {
+		Frame   int
+		Message string
+	}
+}
+
+// readReport reads the JSON regression report written by demo playback.
+func readReport(t *testing.T, name string) *seekReport {
+	t.Helper()
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("could not read report: %v", err)
+	}
+	var r seekReport
+	err = json.Unmarshal(data, &r)
+	if err != nil {
+		t.Fatalf("could not parse report: %v", err)
+	}
+	return &r
+}
+
+// TestSeek checks that starting playback in the middle of a demo yields the
+// same save games, player positions and final state as playing all of it.
+func TestSeek(t *testing.T) {
+	if testing.Short() {
+		t.Skip("demo playback is slow")
+	}
+	root, demos := listDemos(t)
+	restored := 0
+	for _, d := range demos {
+		t.Run(filepath.Base(d), func(t *testing.T) {
+			// Re-record the demo with as many keyframes as possible.
+			recorded := filepath.Join(t.TempDir(), "keyframes.dem")
+			runDemo(t, root,
+				"-demo_play="+d,
+				"-demo_record="+recorded,
+				"-demo_record_keyframe_interval=1",
+			)
+			n, kfs := keyframes(t, recorded)
+			// Start right after a keyframe, at a keyframe, and in the middle.
+			starts := []int{n / 2}
+			for _, kf := range kfs {
+				starts = append(starts, kf, kf+1)
+			}
+			if len(kfs) == 0 {
+				t.Logf("demo has no in-game respawns, so seeking can only fast forward")
+			}
+			restored += len(kfs)
+			for _, start := range starts {
+				if start <= 0 || start >= n {
+					continue
+				}
+				t.Run(strconv.Itoa(start), func(t *testing.T) {
+					report := filepath.Join(t.TempDir(), "report")
+					out, err := playDemo(root,
+						"-demo_play="+recorded,
+						"-demo_play_start_frame="+strconv.Itoa(start),
+						"-demo_play_report="+report,
+					)
+					_, statErr := os.Stat(report + ".json")
+					if statErr != nil {
+						t.Fatalf("demo playback failed without report: %v\n%s", err, out)
+					}
+					r := readReport(t, report+".json")
+					// Every frame from the keyframe on is checked against
+					// the player position and save games of the full playback.
+					for _, e := range r.Regressions {
+						t.Errorf("frame %d: %s", e.Frame, e.Message)
+					}
+					if r.Frames != n {
+						t.Errorf("played until frame %d, want %d", r.Frames, n)
+					}
+					if err != nil && !t.Failed() {
+						t.Fatalf("demo playback failed: %v\n%s", err, out)
+					}
+				})
+			}
+		})
+	}
+	if restored == 0 {
+		t.Errorf("no demo has in-game respawns, so no keyframe was restored")
+	}
+}
@@ -19,6 +19,8 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/mitchellh/hashstructure/v2"
+
 	"github.com/divVerent/aaaaxy/internal/flag"
 	"github.com/divVerent/aaaaxy/internal/level"
 	"github.com/divVerent/aaaaxy/internal/log"
@@ -29,6 +31,7 @@ import (
 
 var (
 	levelName = flag.String("level", "level", "name of the level file to load")
+	generate  = flag.Bool("generate", false, "instead of writing a graph for graphviz, lay out the checkpoints natively and print the checkpoint_locations_hash to store in the level")
 )
 
 type (
@@ -73,6 +76,28 @@ func CalcPos(v *Vertex) {
 	v.CalcingPos = false
 }
 
+// printGeneratedHash lays out the checkpoint graph natively and prints the hash of the result.
+func printGeneratedHash(lvl *level.Level) {
+	log.Debugf("laying out checkpoints...")
+	var err error
+	lvl.CheckpointLocations, err = lvl.GenerateCheckpointLocations(*levelName)
+	if err != nil {
+		log.Fatalf("could not lay out checkpoints: %v", err)
+	}
+	mismatches, err := lvl.CheckpointLayoutMismatches()
+	if err != nil {
+		log.Fatalf("failed to parse: %v", err)
+	}
+	for _, mm := range mismatches {
+		log.Warningf("checkpoint edge %v points in a different direction on the map", mm)
+	}
+	hash, err := hashstructure.Hash(lvl.CheckpointLocations, hashstructure.FormatV2, nil)
+	if err != nil {
+		log.Fatalf("could not hash checkpoint locations: %v", err)
+	}
+	fmt.Println(hash)
+}
+
 func main() {
 	log.Debugf("initializing VFS...")
 	err := vfs.Init()
@@ -86,6 +111,10 @@ func main() {
 	if err != nil {
 		log.Fatalf("could not load level: %v", err)
 	}
+	if *generate {
+		printGeneratedHash(lvl)
+		return
+	}
 	log.Debugf("generating checkpoint ID to name map...")
 	cpMap := map[level.EntityID]*level.Spawnable{}
 	for name, sp := range lvl.Checkpoints {
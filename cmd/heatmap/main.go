@@ -0,0 +1,344 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// heatmap replays demos headless and shows where players spend their time,
+// die, teleport and escape to the menu.
+//
+// Usage: heatmap [game flags] demo1.dem demo2.dem ...
+//
+// Each demo is replayed in a child process, as the game cannot be reset
+// within one process. It must be run from the source directory, like demorun.
+// The results are a PNG of the level with the statistics per tile drawn on top,
+// and a CSV with the statistics per checkpoint segment, i.e. the time since
+// reaching each checkpoint.
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+
+	"github.com/divVerent/aaaaxy/internal/aaaaxy"
+	"github.com/divVerent/aaaaxy/internal/atexit"
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/exitstatus"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/maprender"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+var (
+	outPNG   = flag.String("out_png", "heatmap.png", "file to write the heatmap image to")
+	outCSV   = flag.String("out_csv", "heatmap.csv", "file to write the statistics per checkpoint segment to")
+	statsOut = flag.String("stats_out", "", "internal: replay the demo given by -demo_play and write its statistics to this file")
+)
+
+// counts are the statistics of a place.
+type counts struct {
+	Frames    int
+	Respawns  int
+	Teleports int
+	Escapes   int
+}
+
+func (c *counts) add(other *counts) {
+	c.Frames += other.Frames
+	c.Respawns += other.Respawns
+	c.Teleports += other.Teleports
+	c.Escapes += other.Escapes
+}
+
+// stats are the statistics of one or more demos.
+type stats struct {
+	// Tiles are the counts by level tile position.
+	Tiles map[m.Pos]*counts
+	// Segments are the counts by the last checkpoint reached.
+	Segments map[string]*counts
+}
+
+func newStats() *stats {
+	return &stats{
+		Tiles:    map[m.Pos]*counts{},
+		Segments: map[string]*counts{},
+	}
+}
+
+func (s *stats) tile(pos m.Pos) *counts {
+	c := s.Tiles[pos]
+	if c == nil {
+		c = &counts{}
+		s.Tiles[pos] = c
+	}
+	return c
+}
+
+func (s *stats) segment(cp string) *counts {
+	c := s.Segments[cp]
+	if c == nil {
+		c = &counts{}
+		s.Segments[cp] = c
+	}
+	return c
+}
+
+func (s *stats) merge(other *stats) {
+	for pos, c := range other.Tiles {
+		s.tile(pos).add(c)
+	}
+	for cp, c := range other.Segments {
+		s.segment(cp).add(c)
+	}
+}
+
+// collector gathers statistics frame by frame.
+type collector struct {
+	stats *stats
+
+	// Events are attributed to where the player was at the end of the previous frame.
+	havePrev       bool
+	prevTile       m.Pos
+	prevCheckpoint string
+	prevTeleports  int
+	prevEscapes    int
+}
+
+func (c *collector) frame(w *engine.World) {
+	teleports, escapes := w.PlayerState.Teleports(), w.PlayerState.Escapes()
+	if c.havePrev {
+		tile, segment := c.stats.tile(c.prevTile), c.stats.segment(c.prevCheckpoint)
+		if w.Respawned() {
+			tile.Respawns++
+			segment.Respawns++
+		}
+		if teleports > c.prevTeleports {
+			tile.Teleports += teleports - c.prevTeleports
+			segment.Teleports += teleports - c.prevTeleports
+		}
+		if escapes > c.prevEscapes {
+			tile.Escapes += escapes - c.prevEscapes
+			segment.Escapes += escapes - c.prevEscapes
+		}
+	}
+	c.prevTeleports, c.prevEscapes = teleports, escapes
+	t := w.Tile(w.Player.Rect.Center().Div(level.TileSize))
+	if t == nil {
+		return
+	}
+	c.havePrev = true
+	c.prevTile = t.LevelPos
+	c.prevCheckpoint = w.PlayerState.LastCheckpoint()
+	c.stats.tile(c.prevTile).Frames++
+	c.stats.segment(c.prevCheckpoint).Frames++
+}
+
+// collect replays the demo given by -demo_play and writes its statistics to -stats_out.
+func collect() error {
+	c := &collector{stats: newStats()}
+	engine.ObserveFrames(c.frame)
+	game := aaaaxy.NewGame()
+	err := game.RunHeadless()
+	errbe := game.BeforeExit()
+	if err != nil && !errors.Is(err, exitstatus.ErrRegularTermination) {
+		return fmt.Errorf("headless demo playback exited abnormally: %w", err)
+	}
+	if errbe != nil {
+		return fmt.Errorf("BeforeExit exited abnormally: %w", errbe)
+	}
+	data, err := json.Marshal(c.stats)
+	if err != nil {
+		return fmt.Errorf("could not marshal statistics: %w", err)
+	}
+	return os.WriteFile(*statsOut, data, 0o644)
+}
+
+// replay runs a child process to replay the given demo and returns its statistics.
+func replay(gameArgs []string, demoName string) (*stats, error) {
+	f, err := os.CreateTemp("", "heatmap-*.json")
+	if err != nil {
+		return nil, fmt.Errorf("could not create temporary statistics file: %w", err)
+	}
+	statsName := f.Name()
+	f.Close()
+	defer os.Remove(statsName)
+	args := append(append([]string{}, gameArgs...), "-demo_play="+demoName, "-stats_out="+statsName)
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("could not replay: %w", err)
+	}
+	data, err := os.ReadFile(statsName)
+	if err != nil {
+		return nil, fmt.Errorf("could not read statistics: %w", err)
+	}
+	s := newStats()
+	err = json.Unmarshal(data, s)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse statistics: %w", err)
+	}
+	return s, nil
+}
+
+// heatColor maps 0..1 to a color from transparent via blue and red to yellow.
+func heatColor(f float64) color.NRGBA {
+	switch {
+	case f <= 0:
+		return color.NRGBA{}
+	case f < 0.5:
+		return color.NRGBA{R: uint8(510 * f), G: 0, B: uint8(255 - 510*f), A: uint8(96 + 192*f)}
+	default:
+		return color.NRGBA{R: 255, G: uint8(510 * (f - 0.5)), B: 0, A: 192}
+	}
+}
+
+func fillRect(img draw.Image, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r, image.NewUniform(c), image.Point{}, draw.Over)
+}
+
+// marker returns a square of the given size in the middle of a tile.
+func marker(pos m.Pos, size int) image.Rectangle {
+	center := pos.Mul(level.TileSize).Add(m.Delta{DX: level.TileSize / 2, DY: level.TileSize / 2})
+	return image.Rect(center.X-size/2, center.Y-size/2, center.X-size/2+size, center.Y-size/2+size)
+}
+
+func writePNG(lvl *level.Level, s *stats) error {
+	img, err := maprender.New().Render(maprender.RawLayout(lvl))
+	if err != nil {
+		return fmt.Errorf("could not render level: %w", err)
+	}
+	// Darken the level so the statistics stand out.
+	fillRect(img, img.Bounds(), color.NRGBA{A: 160})
+	maxFrames := 0
+	for _, c := range s.Tiles {
+		maxFrames = max(maxFrames, c.Frames)
+	}
+	for pos, c := range s.Tiles {
+		if c.Frames == 0 {
+			continue
+		}
+		// Logarithmic, as time spent varies wildly.
+		f := math.Log1p(float64(c.Frames)) / math.Log1p(float64(maxFrames))
+		r := image.Rect(pos.X*level.TileSize, pos.Y*level.TileSize, (pos.X+1)*level.TileSize, (pos.Y+1)*level.TileSize)
+		fillRect(img, r, heatColor(f))
+	}
+	for pos, c := range s.Tiles {
+		if n := c.Teleports + c.Escapes; n > 0 {
+			fillRect(img, marker(pos, 4+2*min(n, 5)), color.NRGBA{G: 255, B: 255, A: 255})
+		}
+		if c.Respawns > 0 {
+			fillRect(img, marker(pos, 2+2*min(c.Respawns, 5)), color.NRGBA{R: 255, B: 255, A: 255})
+		}
+	}
+	f, err := vfs.OSCreate(vfs.WorkDir, *outPNG)
+	if err != nil {
+		return err
+	}
+	err = png.Encode(f, img)
+	if errC := f.Close(); err == nil {
+		err = errC
+	}
+	return err
+}
+
+func writeCSV(s *stats) error {
+	f, err := vfs.OSCreate(vfs.WorkDir, *outCSV)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.Write([]string{"checkpoint", "frames", "respawns", "teleports", "escapes"})
+	cps := make([]string, 0, len(s.Segments))
+	for cp := range s.Segments {
+		cps = append(cps, cp)
+	}
+	sort.Strings(cps)
+	for _, cp := range cps {
+		c := s.Segments[cp]
+		name := cp
+		if name == "" {
+			name = "(start)"
+		}
+		w.Write([]string{name, strconv.Itoa(c.Frames), strconv.Itoa(c.Respawns), strconv.Itoa(c.Teleports), strconv.Itoa(c.Escapes)})
+	}
+	w.Flush()
+	err = w.Error()
+	if errC := f.Close(); err == nil {
+		err = errC
+	}
+	return err
+}
+
+func main() {
+	defer atexit.Finish()
+
+	flag.Parse(flag.NoConfig)
+	if *statsOut != "" {
+		err := collect()
+		if err != nil {
+			log.Fatalf("could not collect statistics: %v", err)
+		}
+		return
+	}
+
+	demos := flag.Args()
+	if len(demos) == 0 {
+		log.Fatalf("usage: heatmap [flags] demo.dem...")
+	}
+	// Flags given to us are passed on to the replays, e.g. to select the level.
+	gameArgs := os.Args[1 : len(os.Args)-len(demos)]
+
+	log.Debugf("initializing VFS...")
+	err := vfs.Init()
+	if err != nil {
+		log.Fatalf("could not initialize VFS: %v", err)
+	}
+	total := newStats()
+	for _, d := range demos {
+		log.Infof("replaying %v...", d)
+		s, err := replay(gameArgs, d)
+		if err != nil {
+			log.Fatalf("could not replay %v: %v", d, err)
+		}
+		total.merge(s)
+	}
+	log.Debugf("loading level...")
+	lvl, err := level.NewLoader(engine.LevelName()).SkipCheckpointLocations(true).Load()
+	if err != nil {
+		log.Fatalf("could not load level: %v", err)
+	}
+	log.Debugf("writing output...")
+	err = writePNG(lvl, total)
+	if err != nil {
+		log.Fatalf("could not write %v: %v", *outPNG, err)
+	}
+	err = writeCSV(total)
+	if err != nil {
+		log.Fatalf("could not write %v: %v", *outCSV, err)
+	}
+	log.Infof("wrote %v and %v", *outPNG, *outCSV)
+}
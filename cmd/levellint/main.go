@@ -0,0 +1,416 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// levellint loads a level without starting the game and checks it for problems.
+//
+// Besides everything the level loader verifies, it runs the checks otherwise
+// only done at runtime behind -debug_check_* flags, and some more that need a
+// view of the whole level. As no entities are actually spawned, overlap
+// checks only consider sprites, and spawn checks are limited to entity types
+// and the sprites and sounds their properties refer to.
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	_ "github.com/divVerent/aaaaxy/internal/game" // Load entities.
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/log"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+	"github.com/divVerent/aaaaxy/internal/script"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+var (
+	levelName = flag.String("level", "level", "name of the level file to check")
+)
+
+type linter struct {
+	lvl      *level.Level
+	file     string
+	problems int
+}
+
+func (l *linter) reportf(where string, format string, args ...interface{}) {
+	fmt.Printf("%s: %s%s\n", l.file, where, fmt.Sprintf(format, args...))
+	l.problems++
+}
+
+func (l *linter) mapProblemf(format string, args ...interface{}) {
+	l.reportf("", format, args...)
+}
+
+func (l *linter) objectProblemf(sp *level.Spawnable, format string, args ...interface{}) {
+	l.reportf(fmt.Sprintf("object %v (%s): ", sp.ID, sp.EntityType), format, args...)
+}
+
+func (l *linter) tileProblemf(pos m.Pos, format string, args ...interface{}) {
+	l.reportf(fmt.Sprintf("tile %v: ", pos), format, args...)
+}
+
+// spawnables returns all entities of the level, sorted by ID.
+func (l *linter) spawnables() []*level.Spawnable {
+	seen := map[level.EntityID]*level.Spawnable{}
+	if l.lvl.Player != nil {
+		seen[l.lvl.Player.ID] = l.lvl.Player
+	}
+	l.lvl.ForEachTile(func(_ m.Pos, t *level.LevelTile) {
+		for _, sp := range t.Tile.Spawnables {
+			seen[sp.ID] = sp
+		}
+	})
+	out := make([]*level.Spawnable, 0, len(seen))
+	for _, sp := range seen {
+		out = append(out, sp)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// rect returns the location of an entity in level coordinates.
+func rect(sp *level.Spawnable) m.Rect {
+	return m.Rect{
+		Origin: sp.LevelPos.Mul(level.TileSize).Add(sp.RectInTile.Origin.Delta(m.Pos{})),
+		Size:   sp.RectInTile.Size,
+	}
+}
+
+func assetExists(purpose, name string) error {
+	if name == "" {
+		return fmt.Errorf("no %s file name given", purpose)
+	}
+	if strings.ContainsRune(name, '/') {
+		return fmt.Errorf("%s file name %q must not contain a directory", purpose, name)
+	}
+	f, err := vfs.Load(purpose, name)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// checkScript verifies that a script exists and parses.
+func checkScript(name string) error {
+	if err := assetExists("scripts", name); err != nil {
+		return err
+	}
+	f, err := vfs.Load("scripts", name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	src, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	_, err = script.Parse(string(src))
+	return err
+}
+
+func (l *linter) checkTnihSigns() {
+	names := make([]string, 0, len(l.lvl.Checkpoints))
+	for name := range l.lvl.Checkpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		err := l.lvl.CheckTnihSign(name)
+		if err != nil {
+			l.objectProblemf(l.lvl.Checkpoints[name], "%v", err)
+		}
+	}
+}
+
+func (l *linter) checkCheckpointLocations() {
+	if l.lvl.CheckpointLocations == nil {
+		return
+	}
+	err := l.lvl.VerifyCheckpointLocationsHash()
+	if err != nil {
+		l.mapProblemf("%v", err)
+	}
+	// All checkpoints must be connected to the main checkpoint graph.
+	// As the player start is not part of the graph, the largest component is taken as the main one.
+	component := map[string]int{}
+	var sizes []int
+	locNames := make([]string, 0, len(l.lvl.CheckpointLocations.Locs))
+	for name := range l.lvl.CheckpointLocations.Locs {
+		locNames = append(locNames, name)
+	}
+	sort.Strings(locNames)
+	for _, root := range locNames {
+		if _, found := component[root]; found {
+			continue
+		}
+		c := len(sizes)
+		sizes = append(sizes, 0)
+		component[root] = c
+		queue := []string{root}
+		for len(queue) > 0 {
+			name := queue[0]
+			queue = queue[1:]
+			sizes[c]++
+			loc := l.lvl.CheckpointLocations.Locs[name]
+			edges := append([]level.CheckpointEdge{}, loc.NextDeadEnds...)
+			for _, edge := range loc.NextByDir {
+				edges = append(edges, edge)
+			}
+			for _, edge := range edges {
+				if _, found := l.lvl.CheckpointLocations.Locs[edge.Other]; !found {
+					continue
+				}
+				if _, found := component[edge.Other]; found {
+					continue
+				}
+				component[edge.Other] = c
+				queue = append(queue, edge.Other)
+			}
+		}
+	}
+	mainComponent := 0
+	for c, size := range sizes {
+		if size > sizes[mainComponent] {
+			mainComponent = c
+		}
+	}
+	names := make([]string, 0, len(l.lvl.Checkpoints))
+	for name := range l.lvl.Checkpoints {
+		if name == "" {
+			// The player start is not in the graph.
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cpSp := l.lvl.Checkpoints[name]
+		c, found := component[name]
+		if !found {
+			l.objectProblemf(cpSp, "checkpoint %q has no location in the checkpoint graph", name)
+			continue
+		}
+		if c != mainComponent {
+			l.objectProblemf(cpSp, "checkpoint %q cannot be reached from the main checkpoint graph", name)
+		}
+	}
+}
+
+// knownDanglingTargets are target names the shipped levels refer to on purpose without defining them.
+// Fixing them in the map would change the level hash and invalidate all recorded demos and saves.
+var knownDanglingTargets = map[string]bool{
+	// Object 8251 in level.tmx is a decoy switch next to the ending exit; flipping it must do nothing.
+	"ViewTheEndingOrNotOrElse": true,
+}
+
+// isTargetProperty returns whether a property is a target list for mixins.ParseTarget.
+// SpawnCounter uses target, target2, target3 and so on.
+func isTargetProperty(key string) bool {
+	suffix, found := strings.CutPrefix(key, "target")
+	return found && strings.Trim(suffix, "0123456789") == ""
+}
+
+func (l *linter) checkTargets(sps []*level.Spawnable) {
+	names := map[string]bool{}
+	for _, sp := range sps {
+		if name := propmap.StringOr(sp.Properties, "name", ""); name != "" {
+			names[name] = true
+		}
+	}
+	l.lvl.ForEachTile(func(_ m.Pos, t *level.LevelTile) {
+		for _, wz := range t.WarpZones {
+			names[wz.Name] = true
+		}
+	})
+	for _, sp := range sps {
+		propmap.ForEach(sp.Properties, func(k, v string) error {
+			if !isTargetProperty(k) {
+				return nil
+			}
+			// Same syntax as mixins.ParseTarget and SetStateOfTarget.
+			for _, target := range strings.Split(v, " ") {
+				target = strings.TrimPrefix(target, "!")
+				target = strings.TrimPrefix(target, "=")
+				if target == "" {
+					continue
+				}
+				if !names[target] && !knownDanglingTargets[target] {
+					l.objectProblemf(sp, "%s refers to unknown entity name %q", k, target)
+				}
+			}
+			return nil
+		})
+	}
+}
+
+func (l *linter) checkEntityTypes(sps []*level.Spawnable) {
+	for _, sp := range sps {
+		if !engine.IsEntityType(sp.EntityType) {
+			l.objectProblemf(sp, "unknown entity type %q", sp.EntityType)
+		}
+	}
+}
+
+func (l *linter) checkAssets(sps []*level.Spawnable) {
+	for _, sp := range sps {
+		// Other entity types interpret the image property themselves.
+		// Sprites without an image are editor placeholders; they never render.
+		if sp.EntityType == "Sprite" && hasImage(sp) {
+			directory := propmap.StringOr(sp.Properties, "image_dir", "sprites")
+			imgSrc := propmap.StringOr(sp.Properties, "image", "")
+			if err := assetExists(directory, imgSrc); err != nil {
+				l.objectProblemf(sp, "missing sprite: %v", err)
+			}
+			imgSrcByOrientation, err := level.ParseImageSrcByOrientation(imgSrc, sp.Properties)
+			if err != nil {
+				l.objectProblemf(sp, "%v", err)
+			}
+			for _, thisSrc := range imgSrcByOrientation {
+				if thisSrc == "" {
+					continue
+				}
+				if err := assetExists(directory, thisSrc); err != nil {
+					l.objectProblemf(sp, "missing sprite: %v", err)
+				}
+			}
+		}
+		if sp.EntityType == "ScriptEntity" {
+			if err := checkScript(propmap.StringOr(sp.Properties, "script", "")); err != nil {
+				l.objectProblemf(sp, "bad script: %v", err)
+			}
+		}
+		if propmap.Has(sp.Properties, "sound") {
+			if err := assetExists("sounds", propmap.StringOr(sp.Properties, "sound", "")); err != nil {
+				l.objectProblemf(sp, "missing sound: %v", err)
+			}
+		}
+	}
+	checked := map[string]bool{}
+	checkTile := func(pos m.Pos, imgSrc string) {
+		if imgSrc == "" || checked[imgSrc] {
+			return
+		}
+		checked[imgSrc] = true
+		if err := assetExists("tiles", imgSrc); err != nil {
+			l.tileProblemf(pos, "missing tile image: %v", err)
+		}
+	}
+	l.lvl.ForEachTile(func(pos m.Pos, t *level.LevelTile) {
+		if !t.Valid {
+			return
+		}
+		checkTile(pos, t.Tile.ImageSrc)
+		for _, o := range t.Tile.Overlays {
+			checkTile(pos, o.ImageSrc)
+		}
+	})
+	for _, il := range l.lvl.ImageLayers {
+		if err := assetExists(il.Purpose, il.ImageSrc); err != nil {
+			l.mapProblemf("image layer %q: missing image: %v", il.Name, err)
+		}
+	}
+}
+
+// hasImage returns whether a Sprite names an image to show.
+func hasImage(sp *level.Spawnable) bool {
+	return propmap.StringOr(sp.Properties, "image", "") != ""
+}
+
+// alwaysVisible returns whether a Sprite is visible no matter how it is spawned.
+// Mirrors the conditions under which SpriteBase.Spawn and World.Spawn hide an entity.
+func alwaysVisible(sp *level.Spawnable) bool {
+	if !hasImage(sp) {
+		return false
+	}
+	if alpha, err := propmap.ValueOr(sp.Properties, "alpha", 1.0); err != nil || alpha == 0 {
+		return false
+	}
+	for _, key := range []string{"unless_abilities", "required_orientation", "required_x_divisible_by", "only_if_can_switch_level"} {
+		if propmap.Has(sp.Properties, key) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkEntityOverlaps is the static counterpart of -debug_check_entity_overlaps.
+// Only plain sprites are checked, as other entities may move or pick their Z index at runtime.
+// Like at runtime, invisible sprites are skipped; so are conditionally visible ones,
+// as the conditions are rarely all met at once.
+func (l *linter) checkEntityOverlaps(sps []*level.Spawnable) {
+	byZ := map[string][]*level.Spawnable{}
+	for _, sp := range sps {
+		if sp.EntityType != "Sprite" || !alwaysVisible(sp) {
+			continue
+		}
+		z := propmap.StringOr(sp.Properties, "z_index", "")
+		for _, other := range byZ[z] {
+			if !rect(other).Delta(rect(sp)).IsZero() {
+				continue
+			}
+			if propmap.StringOr(other.Properties, "image", "") == propmap.StringOr(sp.Properties, "image", "") {
+				// Same exception as at runtime: same image overlaps are likely intentional.
+				continue
+			}
+			l.objectProblemf(sp, "overlaps object %v at the same Z index", other.ID)
+		}
+		byZ[z] = append(byZ[z], sp)
+	}
+}
+
+// lint checks the named level and returns the number of problems found.
+func lint(name string) int {
+	l := &linter{
+		file: "maps/" + name + ".tmx",
+	}
+	log.Debugf("loading level...")
+	// Comparing checkpoint locations is done below, so it is reported like all other problems.
+	var err error
+	l.lvl, err = level.NewLoader(name).SkipComparingCheckpointLocations(true).Load()
+	if err != nil {
+		l.mapProblemf("could not load level: %v", err)
+		return l.problems
+	}
+	sps := l.spawnables()
+	log.Debugf("checking...")
+	l.checkTnihSigns()
+	l.checkCheckpointLocations()
+	l.checkTargets(sps)
+	l.checkEntityTypes(sps)
+	l.checkAssets(sps)
+	l.checkEntityOverlaps(sps)
+	return l.problems
+}
+
+func main() {
+	log.Debugf("initializing VFS...")
+	err := vfs.Init()
+	if err != nil {
+		log.Fatalf("could not initialize VFS: %v", err)
+	}
+	log.Debugf("parsing flags...")
+	flag.Parse(flag.NoConfig)
+	problems := lint(*levelName)
+	if problems != 0 {
+		log.Fatalf("%d problem(s) found", problems)
+	}
+	log.Debugf("done.")
+}
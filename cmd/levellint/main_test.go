@@ -0,0 +1,50 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+func TestMain(m *testing.M) {
+	// The VFS finds the assets relative to the source root.
+	err := os.Chdir("../..")
+	if err != nil {
+		panic(err)
+	}
+	err = vfs.Init()
+	if err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+func TestShippedLevels(t *testing.T) {
+	// The level loader needs the generated checkpoint locations.
+	_, err := os.Stat("assets/generated/version.txt")
+	if err != nil {
+		t.Skipf("generated assets are missing, run go generate first: %v", err)
+	}
+	for _, name := range []string{"level", "level2"} {
+		t.Run(name, func(t *testing.T) {
+			if problems := lint(name); problems != 0 {
+				t.Errorf("got %d problem(s), want none", problems)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// rendermap renders a whole level to a PNG file, without needing a GPU.
+//
+// In raw mode, the level is shown as in the editor. In unfolded mode, the
+// level is shown as the player sees it around a checkpoint, i.e. with
+// warpzones applied.
+package main
+
+import (
+	"image/png"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/maprender"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+var (
+	levelName        = flag.String("level", "level", "name of the level file to render")
+	out              = flag.String("out", "map.png", "PNG file to write")
+	mode             = flag.String("mode", "raw", "what to render; can be 'raw' for the tile grid as in the editor, or 'unfolded' for the view around a checkpoint")
+	checkpoint       = flag.String("checkpoint", "", "name of the checkpoint to unfold from; empty means the player start")
+	radius           = flag.Int("radius", 64, "maximum distance in tiles from the checkpoint to unfold to")
+	sprites          = flag.Bool("sprites", true, "render static sprites")
+	checkpointLabels = flag.Bool("checkpoint_labels", false, "write the names of checkpoints onto the map")
+)
+
+func main() {
+	log.Debugf("initializing VFS...")
+	err := vfs.Init()
+	if err != nil {
+		log.Fatalf("could not initialize VFS: %v", err)
+	}
+	log.Debugf("parsing flags...")
+	flag.Parse(flag.NoConfig)
+	log.Debugf("loading level...")
+	lvl, err := level.NewLoader(*levelName).SkipCheckpointLocations(true).Load()
+	if err != nil {
+		log.Fatalf("could not load level: %v", err)
+	}
+	var layout maprender.Layout
+	switch *mode {
+	case "raw":
+		layout = maprender.RawLayout(lvl)
+	case "unfolded":
+		layout, err = maprender.UnfoldedLayout(lvl, *checkpoint, *radius)
+		if err != nil {
+			log.Fatalf("could not unfold level: %v", err)
+		}
+	default:
+		log.Fatalf("invalid -mode: got %q, want raw or unfolded", *mode)
+	}
+	log.Debugf("rendering...")
+	r := maprender.New()
+	img, err := r.Render(layout)
+	if err != nil {
+		log.Fatalf("could not render tiles: %v", err)
+	}
+	if *sprites {
+		err = r.Sprites(img, layout)
+		if err != nil {
+			log.Fatalf("could not render sprites: %v", err)
+		}
+	}
+	if *checkpointLabels {
+		maprender.CheckpointLabels(img, layout)
+	}
+	log.Debugf("writing %v...", *out)
+	f, err := vfs.OSCreate(vfs.WorkDir, *out)
+	if err != nil {
+		log.Fatalf("could not create %v: %v", *out, err)
+	}
+	err = png.Encode(f, img)
+	if err != nil {
+		log.Fatalf("could not encode %v: %v", *out, err)
+	}
+	err = f.Close()
+	if err != nil {
+		log.Fatalf("could not close %v: %v", *out, err)
+	}
+}
@@ -0,0 +1,364 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// savetool inspects, validates, diffs and migrates save games.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/fun"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/playerstate"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+	"github.com/divVerent/aaaaxy/internal/version"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+var (
+	mode      = flag.String("mode", "inspect", "what to do; can be 'inspect', 'print', 'validate', 'diff', 'migrate', 'rehash' or 'summary'; 'migrate' updates game version and level hash, converts legacy v0 save games to separate info and state hashes, and fails if the level version changed")
+	in        = flag.String("in", "", "save game file to read")
+	other     = flag.String("other", "", "second save game file to compare with in diff mode")
+	out       = flag.String("out", "", "file to write the result of print, migrate and rehash to; defaults to stdout for print")
+	levelName = flag.String("level", "level", "name of the level file to load")
+)
+
+const usage = "usage: savetool -mode=inspect|print|validate|diff|migrate|rehash|summary -in=save-0.json [-other=save-1.json] [-out=new.json] [-level=level]\n" +
+	"migrate carries save games over to a new game version or level hash and converts legacy v0 save games; save games from a different level version cannot be migrated"
+
+func readSave(name string) (*level.SaveGame, error) {
+	f, err := vfs.OSOpen(vfs.WorkDir, name)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %v: %w", name, err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %v: %w", name, err)
+	}
+	save := &level.SaveGame{}
+	err = json.Unmarshal(data, save)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %v: %w", name, err)
+	}
+	return save, nil
+}
+
+func writeSave(name string, save *level.SaveGame) error {
+	data, err := json.MarshalIndent(save, "", "\t")
+	if err != nil {
+		return fmt.Errorf("could not encode save game: %w", err)
+	}
+	if name == "" {
+		_, err = os.Stdout.Write(append(data, '\n'))
+		return err
+	}
+	f, err := vfs.OSCreate(vfs.WorkDir, name)
+	if err != nil {
+		return fmt.Errorf("could not create %v: %w", name, err)
+	}
+	_, err = f.Write(data)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("could not write %v: %w", name, err)
+	}
+	return f.Close()
+}
+
+func loadLevel() (*level.Level, error) {
+	log.Debugf("loading level...")
+	lvl, err := level.NewLoader(*levelName).SkipComparingCheckpointLocations(true).Load()
+	if err != nil {
+		return nil, fmt.Errorf("could not load level: %w", err)
+	}
+	return lvl, nil
+}
+
+// playerState loads the save game into a copy of the level.
+func playerState(lvl *level.Level, save *level.SaveGame) (*playerstate.PlayerState, error) {
+	lvl = lvl.Clone()
+	err := lvl.LoadGame(save)
+	if err != nil {
+		return nil, fmt.Errorf("could not load save game: %w", err)
+	}
+	ps := &playerstate.PlayerState{Level: lvl}
+	ps.Init()
+	return ps, nil
+}
+
+func formatVersion(save *level.SaveGame) string {
+	if save.IsV0() {
+		return "v0 (legacy hash)"
+	}
+	return "v1"
+}
+
+func inspect(lvl *level.Level, save *level.SaveGame) {
+	fmt.Printf("format: %s\n", formatVersion(save))
+	if err := save.Validate(); err != nil {
+		fmt.Printf("hashes: INVALID (%v)\n", err)
+	} else {
+		fmt.Printf("hashes: ok\n")
+	}
+	fmt.Printf("game version: %v (current: %v)\n", save.GameVersion, version.Revision())
+	fmt.Printf("level version: %v (current: %v)\n", save.LevelVersion, lvl.SaveGameVersion)
+	fmt.Printf("level hash: %v (current: %v)\n", save.LevelHash, lvl.Hash)
+	fmt.Printf("entities with state: %d\n", len(save.State))
+	if unknown := save.UnknownEntities(lvl); len(unknown) != 0 {
+		fmt.Printf("entities not in level: %v\n", unknown)
+	}
+	ps, err := playerState(lvl, save)
+	if err != nil {
+		fmt.Printf("cannot load: %v\n", err)
+		return
+	}
+	if ps.Edited() {
+		fmt.Printf("edited: yes (counts as cheating)\n")
+	}
+	fmt.Printf("%s\n", fun.FormatText(ps, "Score: {{Score}}{{SpeedrunCategoriesShort}} | Time: {{GameTime}}"))
+}
+
+func validate(lvl *level.Level, save *level.SaveGame) error {
+	err := save.Validate()
+	if err != nil {
+		return err
+	}
+	if save.LevelVersion != lvl.SaveGameVersion {
+		return fmt.Errorf("save game does not match level version: got %v, want %v", save.LevelVersion, lvl.SaveGameVersion)
+	}
+	if save.IsV0() {
+		log.Warningf("save game uses the legacy v0 hash; consider -mode=migrate")
+	}
+	if save.LevelHash != lvl.Hash {
+		log.Warningf("save game does not match level hash: got %v, want %v; consider -mode=migrate", save.LevelHash, lvl.Hash)
+	}
+	if save.GameVersion != version.Revision() {
+		log.Warningf("save game does not match game version: got %v, want %v", save.GameVersion, version.Revision())
+	}
+	if unknown := save.UnknownEntities(lvl); len(unknown) != 0 {
+		log.Warningf("save game refers to entities not in the level: %v", unknown)
+	}
+	_, err = playerState(lvl, save)
+	return err
+}
+
+func sortedKeys(pm propmap.Map) []string {
+	var keys []string
+	propmap.ForEach(pm, func(k, _ string) error {
+		keys = append(keys, k)
+		return nil
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+func diff(a, b *level.SaveGame) {
+	header := func(name string, va, vb interface{}) {
+		if va != vb {
+			fmt.Printf("~ %s: %v -> %v\n", name, va, vb)
+		}
+	}
+	header("format", formatVersion(a), formatVersion(b))
+	header("game version", a.GameVersion, b.GameVersion)
+	header("level version", a.LevelVersion, b.LevelVersion)
+	header("level hash", a.LevelHash, b.LevelHash)
+
+	ids := map[level.EntityID]bool{}
+	for id := range a.State {
+		ids[id] = true
+	}
+	for id := range b.State {
+		ids[id] = true
+	}
+	sortedIDs := make([]level.EntityID, 0, len(ids))
+	for id := range ids {
+		sortedIDs = append(sortedIDs, id)
+	}
+	sort.Slice(sortedIDs, func(i, j int) bool {
+		return sortedIDs[i] < sortedIDs[j]
+	})
+	for _, id := range sortedIDs {
+		sa, sb := a.State[id], b.State[id]
+		for _, k := range sortedKeys(sa) {
+			va := propmap.StringOr(sa, k, "")
+			if !propmap.Has(sb, k) {
+				fmt.Printf("- %v %s=%s\n", id, k, va)
+			} else if vb := propmap.StringOr(sb, k, ""); va != vb {
+				fmt.Printf("~ %v %s=%s -> %s\n", id, k, va, vb)
+			}
+		}
+		for _, k := range sortedKeys(sb) {
+			if !propmap.Has(sa, k) {
+				fmt.Printf("+ %v %s=%s\n", id, k, propmap.StringOr(sb, k, ""))
+			}
+		}
+	}
+}
+
+// rehash makes a hand-edited save game loadable again, marking it as edited.
+func rehash(lvl *level.Level, save *level.SaveGame) error {
+	if save.State == nil {
+		save.State = map[level.EntityID]level.PersistentState{}
+	}
+	ps, found := save.State[lvl.Player.ID]
+	if !found {
+		ps = propmap.New()
+		save.State[lvl.Player.ID] = ps
+	}
+	propmap.Set(ps, "edited", true)
+	return save.Rehash()
+}
+
+func summary(lvl *level.Level, save *level.SaveGame) error {
+	ps, err := playerState(lvl, save)
+	if err != nil {
+		return err
+	}
+	categories, _ := ps.SpeedrunCategories().Describe()
+	fmt.Printf("score: %s\n", ps.Score())
+	fmt.Printf("time: %s\n", playerstate.FormatFrames(ps.Frames()))
+	fmt.Printf("categories: %s\n", categories)
+	fmt.Printf("won: %v\n", ps.Won())
+	fmt.Printf("escapes: %d\n", ps.Escapes())
+	fmt.Printf("teleports: %d\n", ps.Teleports())
+	fmt.Printf("last checkpoint: %s\n", ps.LastCheckpoint())
+	if ps.Edited() {
+		fmt.Printf("edited: yes (counts as cheating)\n")
+	}
+
+	abilities := make([]string, 0, len(lvl.Abilities))
+	for a := range lvl.Abilities {
+		abilities = append(abilities, a)
+	}
+	sort.Strings(abilities)
+	fmt.Printf("\nabilities:\n")
+	for _, a := range abilities {
+		have := "no"
+		if ps.HasAbility(a) {
+			have = "yes"
+		}
+		fmt.Printf("  %-24s %s\n", a, have)
+	}
+
+	cps := make([]string, 0, len(lvl.Checkpoints))
+	for cp := range lvl.Checkpoints {
+		if cp == "" {
+			// Start is not a real CP.
+			continue
+		}
+		cps = append(cps, cp)
+	}
+	sort.Strings(cps)
+	cpsSeen, signsSeen, signsTotal := 0, 0, 0
+	var lines []string
+	for _, cp := range cps {
+		seen := "-"
+		switch ps.CheckpointSeen(cp) {
+		case playerstate.SeenNormal:
+			seen = "seen"
+			cpsSeen++
+		case playerstate.SeenFlipped:
+			seen = "seen flipped"
+			cpsSeen++
+		}
+		s, t := ps.TnihSignsSeen(cp)
+		signsSeen += s
+		signsTotal += t
+		lines = append(lines, fmt.Sprintf("  %-32s %-12s signs %d/%d", cp, seen, s, t))
+	}
+	fmt.Printf("\ncheckpoints (%d/%d seen, %d/%d signs read):\n", cpsSeen, len(cps), signsSeen, signsTotal)
+	fmt.Printf("%s\n", strings.Join(lines, "\n"))
+	return nil
+}
+
+func main() {
+	log.Debugf("initializing VFS...")
+	err := vfs.Init()
+	if err != nil {
+		log.Fatalf("could not initialize VFS: %v", err)
+	}
+	log.Debugf("parsing flags...")
+	flag.Parse(flag.NoConfig)
+	if *in == "" {
+		log.Fatalf("%s", usage)
+	}
+	save, err := readSave(*in)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	switch *mode {
+	case "print":
+		err = writeSave(*out, save)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		return
+	case "diff":
+		if *other == "" {
+			log.Fatalf("%s", usage)
+		}
+		otherSave, err := readSave(*other)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		diff(save, otherSave)
+		return
+	}
+	lvl, err := loadLevel()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	switch *mode {
+	case "inspect":
+		inspect(lvl, save)
+	case "validate":
+		err = validate(lvl, save)
+		if err != nil {
+			log.Fatalf("save game is invalid: %v", err)
+		}
+		fmt.Printf("ok\n")
+	case "migrate", "rehash":
+		if *out == "" {
+			log.Fatalf("%s", usage)
+		}
+		if *mode == "migrate" {
+			err = save.Migrate(lvl)
+		} else {
+			log.Warningf("the save game will be flagged as edited and count as cheating")
+			err = rehash(lvl, save)
+		}
+		if err != nil {
+			log.Fatalf("could not %s save game: %v", *mode, err)
+		}
+		err = writeSave(*out, save)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+	case "summary":
+		err = summary(lvl, save)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+	default:
+		log.Fatalf("%s", usage)
+	}
+	log.Debugf("done.")
+}
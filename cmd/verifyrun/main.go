@@ -0,0 +1,181 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// verifyrun checks a run certificate by replaying its demo headless.
+// It must be run from the source directory of the same game version that recorded the run.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/divVerent/aaaaxy/internal/aaaaxy"
+	"github.com/divVerent/aaaaxy/internal/atexit"
+	"github.com/divVerent/aaaaxy/internal/demo"
+	"github.com/divVerent/aaaaxy/internal/exitstatus"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/playerstate"
+	"github.com/divVerent/aaaaxy/internal/runcert"
+	"github.com/divVerent/aaaaxy/internal/version"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+var (
+	certificate = flag.String("certificate", "", "run certificate to verify")
+)
+
+func readCertificate(name string) (*runcert.Certificate, error) {
+	f, err := vfs.OSOpen(vfs.WorkDir, name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return runcert.Read(f)
+}
+
+// demoFinalSaveGame returns the final save game stored in the demo itself.
+func demoFinalSaveGame(name string) (*level.SaveGame, error) {
+	f, err := vfs.OSOpen(vfs.WorkDir, name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r, err := demo.NewFrameReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	var final *level.SaveGame
+	var fr demo.Frame
+	for r.More() {
+		err := r.Read(&fr)
+		if err != nil {
+			return nil, err
+		}
+		if fr.FinalSaveGame != nil {
+			final = fr.FinalSaveGame
+		}
+	}
+	if final == nil {
+		return nil, errors.New("demo has no final save game")
+	}
+	return final, nil
+}
+
+func sameSaveGame(a, b *level.SaveGame) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+func verify(game *aaaaxy.Game, cert *runcert.Certificate, demoName string) error {
+	err := cert.Verify()
+	if err != nil {
+		return err
+	}
+	final, err := demoFinalSaveGame(demoName)
+	if err != nil {
+		return fmt.Errorf("could not read final save game from demo: %w", err)
+	}
+	if !sameSaveGame(final, cert.FinalSaveGame) {
+		return errors.New("final save game of certificate does not match the demo")
+	}
+
+	// Replay. This fails on any regression, including the final save state.
+	err = game.RunHeadless()
+	errbe := game.BeforeExit()
+	if cert.GameVersion != version.Revision() {
+		log.Warningf("run was recorded with game version %v, but this is %v", cert.GameVersion, version.Revision())
+	}
+	if err != nil && !errors.Is(err, exitstatus.ErrRegularTermination) {
+		return fmt.Errorf("demo playback exited abnormally: %w", err)
+	}
+	if errbe != nil {
+		return fmt.Errorf("demo playback failed: %w", errbe)
+	}
+
+	// Only now we know the final save game is legit; check the claims.
+	claims, err := runcert.Evaluate(game.Menu.World.Level, cert.FinalSaveGame)
+	if err != nil {
+		return err
+	}
+	if claims.LevelHash != cert.LevelHash {
+		return fmt.Errorf("level hash: got %v, want %v", claims.LevelHash, cert.LevelHash)
+	}
+	if claims.Frames != cert.Frames {
+		return fmt.Errorf("time: got %v, claimed %v", playerstate.FormatFrames(claims.Frames), playerstate.FormatFrames(cert.Frames))
+	}
+	if claims.Categories != cert.Categories {
+		return fmt.Errorf("speedrun categories: got %v, claimed %v", claims.CategoryNames, cert.CategoryNames)
+	}
+	return nil
+}
+
+// verifyCertificate reads the named run certificate and verifies it.
+func verifyCertificate(name string) (*runcert.Certificate, error) {
+	cert, err := readCertificate(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not read certificate: %w", err)
+	}
+	// Playback needs the demo as a file.
+	f, err := os.CreateTemp("", "verifyrun-*.dem")
+	if err != nil {
+		return nil, fmt.Errorf("could not create temporary demo file: %w", err)
+	}
+	demoName := f.Name()
+	defer os.Remove(demoName)
+	_, err = f.Write(cert.Demo)
+	if errC := f.Close(); err == nil {
+		err = errC
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not write temporary demo file: %w", err)
+	}
+	err = flag.Set("demo_play", demoName)
+	if err != nil {
+		return nil, fmt.Errorf("could not set demo to play: %w", err)
+	}
+	err = verify(aaaaxy.NewGame(), cert, demoName)
+	if err != nil {
+		return nil, fmt.Errorf("run certificate %v is INVALID: %w", name, err)
+	}
+	return cert, nil
+}
+
+func main() {
+	defer atexit.Finish()
+
+	// Turn all panics into Fatalf for uniform exception handling.
+	ok := false
+	defer func() {
+		if !ok {
+			log.Fatalf("got panic: %v", recover())
+		}
+	}()
+
+	flag.Parse(flag.NoConfig)
+	if *certificate == "" {
+		log.Fatalf("usage: verifyrun -certificate=run.cert")
+	}
+	cert, err := verifyCertificate(*certificate)
+	ok = true
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	log.Infof("run certificate %v is valid: %v in %v", *certificate, cert.CategoryNames, playerstate.FormatFrames(cert.Frames))
+}
@@ -14,6 +14,7 @@ require (
 	github.com/hajimehoshi/bitmapfont/v3 v3.2.1
 	github.com/hajimehoshi/ebiten/v2 v2.8.6
 	github.com/jeandeaual/go-locale v0.0.0-20241217141322-fcc2cadd6f08
+	github.com/klauspost/compress v1.18.0
 	github.com/leonelquinteros/gotext v1.7.1
 	github.com/lestrrat-go/strftime v1.1.0
 	github.com/lucasb-eyer/go-colorful v1.2.0
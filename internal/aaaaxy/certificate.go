@@ -0,0 +1,73 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aaaaxy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/divVerent/aaaaxy/internal/demo"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/runcert"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+var (
+	demoRecordCertificate = flag.String("demo_record_certificate", "", "local file path to write a run certificate for leaderboard submission to; requires recording a demo")
+)
+
+// writeCertificate writes a run certificate for the just finished demo recording.
+func (g *Game) writeCertificate() error {
+	if *demoRecordCertificate == "" {
+		return nil
+	}
+	name, final := demo.Recorded()
+	if name == "" {
+		return errors.New("cannot write a run certificate without recording a demo")
+	}
+	if final == nil {
+		return errors.New("cannot write a run certificate for a demo that never saved")
+	}
+	f, err := vfs.OSOpen(vfs.WorkDir, name)
+	if err != nil {
+		return fmt.Errorf("could not reopen demo %v: %w", name, err)
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("could not read demo %v: %w", name, err)
+	}
+	cert, err := runcert.New(g.Menu.World.Level, data, final)
+	if err != nil {
+		return err
+	}
+	out, err := vfs.OSCreate(vfs.WorkDir, *demoRecordCertificate)
+	if err != nil {
+		return err
+	}
+	err = cert.Write(out)
+	if err != nil {
+		out.Close()
+		return err
+	}
+	err = out.Close()
+	if err != nil {
+		return err
+	}
+	log.Infof("wrote run certificate to %v: %v in %d frames", *demoRecordCertificate, cert.CategoryNames, cert.Frames)
+	return nil
+}
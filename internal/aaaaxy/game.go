@@ -37,6 +37,7 @@ import (
 	"github.com/divVerent/aaaaxy/internal/fun"
 	"github.com/divVerent/aaaaxy/internal/game/constants"
 	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/livesplit"
 	"github.com/divVerent/aaaaxy/internal/locale"
 	"github.com/divVerent/aaaaxy/internal/log"
 	m "github.com/divVerent/aaaaxy/internal/math"
@@ -99,6 +100,9 @@ const (
 	square2Dither
 )
 
+// fastForwardFrames is the maximum number of frames to run per update while fast forwarding a demo.
+const fastForwardFrames = 60
+
 type Game struct {
 	Menu menu.Controller
 
@@ -132,6 +136,12 @@ type Game struct {
 
 	framesToDump int
 
+	// inputs is the state shown by -show_inputs.
+	inputs inputsState
+
+	// headless is set when running without window, rendering or audio.
+	headless bool
+
 	debugLoadingScreenCpuprofileF io.WriteCloser
 }
 
@@ -144,8 +154,10 @@ func NewGame() *Game {
 }
 
 func (g *Game) updateFrame() error {
-	timing.Section("input")
-	input.Update(g.screenWidth, g.screenHeight, engine.GameWidth, engine.GameHeight, crtK1(), crtK2(), borderStretchPower())
+	if !g.headless {
+		timing.Section("input")
+		input.Update(g.screenWidth, g.screenHeight, engine.GameWidth, engine.GameHeight, crtK1(), crtK2(), borderStretchPower())
+	}
 
 	timing.Section("demo_pre")
 	if demo.Update() {
@@ -153,6 +165,14 @@ func (g *Game) updateFrame() error {
 		return exitstatus.ErrRegularTermination
 	}
 
+	if demo.Paused() {
+		return nil
+	}
+
+	if *showInputs {
+		g.inputs.update()
+	}
+
 	defer func() {
 		timing.Section("demo_post")
 		if g.Menu.World.Player != nil {
@@ -160,16 +180,45 @@ func (g *Game) updateFrame() error {
 		}
 	}()
 
-	timing.Section("menu")
-	err := g.Menu.Update()
-	if err != nil {
-		return err
+	if demo.RestartRequested() {
+		timing.Section("demo_restart")
+		g.Menu.RestartGame()
 	}
 
-	timing.Section("world")
-	err = g.Menu.UpdateWorld()
-	if err != nil {
-		return err
+	if kf := demo.KeyframeToRestore(); kf != nil {
+		// The keyframe replaces the menu and world update of this frame;
+		// everything after them still runs as in the recorded frame.
+		timing.Section("demo_seek")
+		err := g.Menu.RestoreKeyframe(kf)
+		if err != nil {
+			return err
+		}
+	} else {
+		timing.Section("menu")
+		err := g.Menu.Update()
+		if err != nil {
+			return err
+		}
+
+		timing.Section("world")
+		err = g.Menu.UpdateWorld()
+		if err != nil {
+			return err
+		}
+	}
+
+	if livesplit.Active() {
+		timing.Section("livesplit")
+		livesplit.Update(livesplit.State{
+			TimerStarted: g.Menu.World.TimerStarted,
+			TimerStopped: g.Menu.World.TimerStopped,
+			Frames:       g.Menu.World.PlayerState.Frames(),
+			Splits:       len(g.Menu.World.PlayerState.Splits()),
+		})
+	}
+
+	if g.headless {
+		return nil
 	}
 
 	// As the world's Update method may change the sound system info,
@@ -226,7 +275,9 @@ func (g *Game) Update() error {
 
 	defer timing.Group()()
 
-	for frame := 0; frame < *fpsDivisor; frame++ {
+	demo.UpdateTAS()
+
+	for frame := 0; frame < *fpsDivisor || (demo.FastForwarding() && frame < fastForwardFrames); frame++ {
 		if err := g.updateFrame(); err != nil {
 			if errors.Is(err, exitstatus.ErrRegularTermination) {
 				log.Infof("exiting normally")
@@ -490,6 +541,14 @@ func (g *Game) drawAtGameSizeThenReturnTo(maybeScreen *ebiten.Image, to chan *eb
 			m.Pos{X: engine.GameWidth / 2, Y: engine.GameHeight - 4}, font.Center,
 			palette.EGA(palette.White, 255), palette.EGA(palette.Black, 255))
 	}
+	if *showSplits {
+		timing.Section("splits")
+		g.drawSplits(drawDest)
+	}
+	if *showInputs {
+		timing.Section("inputs")
+		g.drawInputs(drawDest)
+	}
 	if *showPos {
 		timing.Section("pos")
 		xi, yi, vxi, vyi := g.Menu.World.Player.Impl.(engine.PlayerEntityImpl).DebugPos64()
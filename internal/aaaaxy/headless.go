@@ -0,0 +1,75 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aaaaxy
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/divVerent/aaaaxy/internal/demo"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/timing"
+)
+
+// RunHeadless plays back a demo without window, rendering or audio.
+// It replaces InitEbitengine and running the Ebitengine main loop;
+// BeforeExit still has to be called afterwards.
+func (g *Game) RunHeadless() error {
+	g.headless = true
+
+	// Nothing that needs a display or audio device may run.
+	for name, value := range map[string]interface{}{
+		"audio":                false,
+		"auto_adjust_quality":  false,
+		"debug_enable_drawing": false,
+		"demo_timedemo":        true,
+		"fullscreen":           false,
+	} {
+		err := flag.Set(name, value)
+		if err != nil {
+			return fmt.Errorf("could not set -%s for headless mode: %w", name, err)
+		}
+	}
+
+	err := g.InitEarly()
+	if err != nil {
+		return err
+	}
+	if !demo.Playing() {
+		return errors.New("headless mode requires a demo to play; use -demo_play")
+	}
+	err = g.InitFull()
+	if err != nil {
+		return fmt.Errorf("could not initialize game: %w", err)
+	}
+
+	for {
+		err := g.updateHeadless()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (g *Game) updateHeadless() error {
+	timing.Update()
+
+	defer timing.Group()()
+	timing.Section("update")
+
+	defer timing.Group()()
+
+	return g.updateFrame()
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/divVerent/aaaaxy/internal/font"
 	"github.com/divVerent/aaaaxy/internal/image"
 	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/livesplit"
 	"github.com/divVerent/aaaaxy/internal/locale"
 	"github.com/divVerent/aaaaxy/internal/locale/initlocale"
 	"github.com/divVerent/aaaaxy/internal/log"
@@ -142,6 +143,10 @@ func (g *Game) InitEarly() error {
 	if err != nil {
 		return fmt.Errorf("could not initialize demo: %w", err)
 	}
+	err = livesplit.Init()
+	if err != nil {
+		return fmt.Errorf("could not initialize LiveSplit Server connection: %w", err)
+	}
 	err = dump.InitEarly(dump.Params{
 		FPSDivisor:            *fpsDivisor,
 		ScreenFilter:          *screenFilter,
@@ -286,5 +291,10 @@ func (g *Game) BeforeExit() error {
 	if err != nil {
 		return fmt.Errorf("could not finalize demo: %w", err)
 	}
+	err = g.writeCertificate()
+	if err != nil {
+		return fmt.Errorf("could not write run certificate: %w", err)
+	}
+	livesplit.Close()
 	return nil
 }
@@ -0,0 +1,145 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aaaaxy
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/log"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/palette"
+)
+
+var (
+	showInputs         = flag.Bool("show_inputs", false, "show the state of the game inputs, e.g. for videos")
+	showInputsPosition = flag.String("show_inputs_position", "top_right", "where to show the inputs; can be 'top_left', 'top_right', 'bottom_left' or 'bottom_right'")
+	showInputsStyle    = flag.String("show_inputs_style", "pad", "how to show the inputs; can be 'pad' for a gamepad like layout or 'text' for a row of letters")
+)
+
+const (
+	// inputsCell is the size of a button in the pad style.
+	inputsCell = 11
+	// inputsBottomMargin keeps the inputs above the bottom row of overlays.
+	inputsBottomMargin = 12
+)
+
+// inputsButtons are the shown impulses, in DemoState order.
+var inputsButtons = [...]struct {
+	text  string
+	label string
+	cell  m.Pos
+}{
+	{"L", "<", m.Pos{X: 0, Y: 1}},
+	{"R", ">", m.Pos{X: 2, Y: 1}},
+	{"U", "^", m.Pos{X: 1, Y: 0}},
+	{"D", "v", m.Pos{X: 1, Y: 1}},
+	{"J", "J", m.Pos{X: 4, Y: 1}},
+	{"A", "A", m.Pos{X: 5, Y: 1}},
+	{"E", "E", m.Pos{X: 5, Y: 0}},
+}
+
+// inputsState is the input state to show.
+//
+// Presses are remembered until drawn, so none are missed when more than
+// one frame is simulated per drawn frame.
+type inputsState struct {
+	held    [len(inputsButtons)]bool
+	justHit [len(inputsButtons)]bool
+}
+
+// update takes the input state of the current frame.
+// When playing a demo, this is the replayed state.
+func (s *inputsState) update() {
+	d := input.SaveToDemo()
+	for i, imp := range [...]*input.ImpulseState{d.Left, d.Right, d.Up, d.Down, d.Jump, d.Action, d.Exit} {
+		state := imp.OrEmpty()
+		s.held[i] = state.Held
+		s.justHit[i] = s.justHit[i] || state.JustHit
+	}
+}
+
+// colors returns the label and button color of a shown impulse, and whether the button is filled.
+// Impulses that were just hit are yellow, held ones white.
+func (s *inputsState) colors(i int) (label, button palette.EGAIndex, filled bool) {
+	switch {
+	case s.justHit[i]:
+		return palette.Black, palette.Yellow, true
+	case s.held[i]:
+		return palette.Black, palette.White, true
+	default:
+		return palette.LightGrey, palette.DarkGrey, false
+	}
+}
+
+// drawInputs draws the current input state.
+func (g *Game) drawInputs(dst *ebiten.Image) {
+	s := &g.inputs
+	defer func() {
+		s.justHit = [len(inputsButtons)]bool{}
+	}()
+	f := font.ByName["Small"]
+	b := f.BoundString("M")
+	var size m.Delta
+	switch *showInputsStyle {
+	case "pad":
+		size = m.Delta{DX: 6*inputsCell + 5, DY: 2*inputsCell + 1}
+	case "text":
+		size = m.Delta{DX: len(inputsButtons)*(b.Size.DX+2) - 2, DY: b.Size.DY}
+	default:
+		log.Errorf("invalid -show_inputs_style: got %q, want pad or text", *showInputsStyle)
+		*showInputs = false
+		return
+	}
+	var pos m.Pos
+	switch *showInputsPosition {
+	case "top_left":
+		pos = m.Pos{X: 1, Y: 1}
+	case "top_right":
+		pos = m.Pos{X: engine.GameWidth - 1 - size.DX, Y: 1}
+	case "bottom_left":
+		pos = m.Pos{X: 1, Y: engine.GameHeight - inputsBottomMargin - size.DY}
+	case "bottom_right":
+		pos = m.Pos{X: engine.GameWidth - 1 - size.DX, Y: engine.GameHeight - inputsBottomMargin - size.DY}
+	default:
+		log.Errorf("invalid -show_inputs_position: got %q, want top_left, top_right, bottom_left or bottom_right", *showInputsPosition)
+		*showInputs = false
+		return
+	}
+	for i, button := range inputsButtons {
+		label, color, filled := s.colors(i)
+		if *showInputsStyle == "text" {
+			f.Draw(dst, button.text, m.Pos{X: pos.X + i*(b.Size.DX+2), Y: pos.Y - b.Origin.Y}, font.Left,
+				palette.EGA(color, 255), palette.EGA(palette.Black, 255))
+			continue
+		}
+		x := float32(pos.X + button.cell.X*(inputsCell+1))
+		y := float32(pos.Y + button.cell.Y*(inputsCell+1))
+		if filled {
+			vector.DrawFilledRect(dst, x, y, inputsCell, inputsCell, palette.EGA(color, 255), false)
+		} else {
+			vector.DrawFilledRect(dst, x, y, inputsCell, inputsCell, palette.EGA(palette.Black, 255), false)
+			vector.StrokeRect(dst, x+0.5, y+0.5, inputsCell-1, inputsCell-1, 1, palette.EGA(color, 255), false)
+		}
+		f.Draw(dst, button.label, m.Pos{
+			X: int(x) + inputsCell/2,
+			Y: int(y) + (inputsCell-b.Size.DY)/2 - b.Origin.Y,
+		}, font.Center, palette.EGA(label, 255), palette.EGA(palette.Black, 0))
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aaaaxy
+
+import (
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/font"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/palette"
+	"github.com/divVerent/aaaaxy/internal/playerstate"
+)
+
+var (
+	showSplits     = flag.Bool("show_splits", false, "show split times against the personal best")
+	showSplitsRows = flag.Int("show_splits_rows", 3, "number of most recent splits to show")
+)
+
+// drawSplits draws the most recent splits with deltas against the personal best.
+// Gold means a best segment, green is ahead of and red behind the personal best run.
+func (g *Game) drawSplits(dst *ebiten.Image) {
+	splits := g.Menu.World.PlayerState.Splits()
+	if len(splits) > *showSplitsRows {
+		splits = splits[len(splits)-*showSplitsRows:]
+	}
+	pb := engine.PersonalBestBaseline()
+	f := font.ByName["Small"]
+	y := -f.BoundString("0").Origin.Y
+	for i, split := range splits {
+		text := split.Checkpoint + " " + playerstate.FormatFrames(split.Frames)
+		fg := palette.EGA(palette.White, 255)
+		if pbFrames, found := pb.Splits[split.Checkpoint]; found {
+			delta := split.Frames - pbFrames
+			text += " " + playerstate.FormatDelta(delta)
+			if delta > 0 {
+				fg = palette.EGA(palette.LightRed, 255)
+			} else {
+				fg = palette.EGA(palette.LightGreen, 255)
+			}
+		}
+		if best, found := pb.Segments[split.Checkpoint]; found && split.Segment < best {
+			fg = palette.EGA(palette.Yellow, 255)
+		}
+		// Prefixing newlines keeps the font's own line spacing.
+		f.Draw(dst, strings.Repeat("\n", i)+text, m.Pos{X: 1, Y: y}, font.Left,
+			fg, palette.EGA(palette.Black, 255))
+	}
+}
@@ -0,0 +1,512 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package demo
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+// Binary demo layout:
+//
+//	header: binaryMagic, version byte, compression byte
+//	then, possibly compressed, for each frame:
+//	  uvarint frame flags
+//	  input (if frameHasInput): uvarint input flags, uvarint InputMap, varint hover/click positions
+//	  uvarint length + JSON of SaveGame (if frameHasSaveGame)
+//	  uvarint count + little endian uint64 of SaveGames (if frameHasSaveGames)
+//	  uvarint length + JSON of FinalSaveGame (if frameHasFinalSaveGame)
+//	  varint DX, DY of PlayerPos relative to the previous PlayerPos (if frameHasPlayerPos)
+//	  uvarint length + JSON of Keyframe (if frameHasKeyframe; since version 2)
+//	  uvarint count + uvarint length and bytes of each of SaveCheckpoints (if frameHasSaveCheckpoints; since version 2)
+//
+// Save games and keyframes are rare and are stored as JSON so they can never get out of sync with their struct.
+const (
+	binaryMagic   = "AAAAXY demo\n"
+	binaryVersion = 2
+)
+
+const (
+	frameHasSaveGame = 1 << iota
+	frameHasInput
+	frameRepeatsInput
+	frameHasSaveGames
+	frameHasFinalSaveGame
+	frameHasPlayerPos
+	frameHasKeyframe
+	frameHasSaveCheckpoints
+	frameFlagsMask = 1<<iota - 1
+)
+
+// binaryVersionFrameFlags are the frame flags each readable version may use.
+var binaryVersionFrameFlags = map[byte]uint64{
+	1: frameFlagsMask &^ (frameHasKeyframe | frameHasSaveCheckpoints),
+	2: frameFlagsMask,
+}
+
+const (
+	inputHasHoverPos = 1 << iota
+	inputHasClickPos
+	inputEasterEggJustHit
+	inputKonamiCodeJustHit
+	inputImpulsesShift = iota
+)
+
+// Each impulse takes three bits: present, held, just hit.
+const (
+	impulsePresent = 1 << iota
+	impulseHeld
+	impulseJustHit
+	impulseBits = iota
+)
+
+func demoStateImpulses(s *input.DemoState) []**input.ImpulseState {
+	return []**input.ImpulseState{&s.Left, &s.Right, &s.Up, &s.Down, &s.Jump, &s.Action, &s.Exit}
+}
+
+func appendInput(buf []byte, s *input.DemoState) []byte {
+	var flags uint64
+	if s.HoverPos != nil {
+		flags |= inputHasHoverPos
+	}
+	if s.ClickPos != nil {
+		flags |= inputHasClickPos
+	}
+	if s.EasterEggJustHit {
+		flags |= inputEasterEggJustHit
+	}
+	if s.KonamiCodeJustHit {
+		flags |= inputKonamiCodeJustHit
+	}
+	for i, imp := range demoStateImpulses(s) {
+		if *imp == nil {
+			continue
+		}
+		bits := uint64(impulsePresent)
+		if (*imp).Held {
+			bits |= impulseHeld
+		}
+		if (*imp).JustHit {
+			bits |= impulseJustHit
+		}
+		flags |= bits << (inputImpulsesShift + i*impulseBits)
+	}
+	buf = binary.AppendUvarint(buf, flags)
+	buf = binary.AppendUvarint(buf, uint64(s.InputMap))
+	if s.HoverPos != nil {
+		buf = binary.AppendVarint(buf, int64(s.HoverPos.X))
+		buf = binary.AppendVarint(buf, int64(s.HoverPos.Y))
+	}
+	if s.ClickPos != nil {
+		buf = binary.AppendVarint(buf, int64(s.ClickPos.X))
+		buf = binary.AppendVarint(buf, int64(s.ClickPos.Y))
+	}
+	return buf
+}
+
+func readInput(r *bufio.Reader) (*input.DemoState, error) {
+	flags, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	inputMap, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	s := &input.DemoState{
+		InputMap:          input.InputMap(inputMap),
+		EasterEggJustHit:  flags&inputEasterEggJustHit != 0,
+		KonamiCodeJustHit: flags&inputKonamiCodeJustHit != 0,
+	}
+	for i, imp := range demoStateImpulses(s) {
+		bits := flags >> (inputImpulsesShift + i*impulseBits)
+		if bits&impulsePresent == 0 {
+			continue
+		}
+		*imp = &input.ImpulseState{
+			Held:    bits&impulseHeld != 0,
+			JustHit: bits&impulseJustHit != 0,
+		}
+	}
+	if flags>>(inputImpulsesShift+len(demoStateImpulses(s))*impulseBits) != 0 {
+		return nil, fmt.Errorf("unknown input flags %#x", flags)
+	}
+	if flags&inputHasHoverPos != 0 {
+		s.HoverPos, err = readPos(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if flags&inputHasClickPos != 0 {
+		s.ClickPos, err = readPos(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func readPos(r *bufio.Reader) (*m.Pos, error) {
+	x, err := binary.ReadVarint(r)
+	if err != nil {
+		return nil, err
+	}
+	y, err := binary.ReadVarint(r)
+	if err != nil {
+		return nil, err
+	}
+	return &m.Pos{X: int(x), Y: int(y)}, nil
+}
+
+func appendJSON(buf []byte, v interface{}) ([]byte, error) {
+	j, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(j)))
+	return append(buf, j...), nil
+}
+
+// maxSaveGameSize protects against allocating absurd amounts of memory for corrupt demos.
+const maxSaveGameSize = 64 << 20
+
+func readJSON(r *bufio.Reader, v interface{}) error {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return err
+	}
+	if n > maxSaveGameSize {
+		return fmt.Errorf("JSON data too large: got %d bytes, want at most %d", n, maxSaveGameSize)
+	}
+	j := make([]byte, n)
+	_, err = io.ReadFull(r, j)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(j, v)
+}
+
+func readSaveGame(r *bufio.Reader) (*level.SaveGame, error) {
+	save := &level.SaveGame{}
+	err := readJSON(r, save)
+	if err != nil {
+		return nil, err
+	}
+	return save, nil
+}
+
+type binaryFrameWriter struct {
+	w         *bufio.Writer
+	compress  io.WriteCloser
+	buf       []byte
+	prevInput []byte
+	prevPos   m.Pos
+}
+
+func newBinaryFrameWriter(w io.Writer, compression Compression) (*binaryFrameWriter, error) {
+	header := append([]byte(binaryMagic), binaryVersion, byte(compression))
+	_, err := w.Write(header)
+	if err != nil {
+		return nil, err
+	}
+	bw := &binaryFrameWriter{}
+	switch compression {
+	case NoCompression:
+		bw.w = bufio.NewWriter(w)
+	case GzipCompression:
+		bw.compress = gzip.NewWriter(w)
+		bw.w = bufio.NewWriter(bw.compress)
+	case ZstdCompression:
+		bw.compress, err = zstd.NewWriter(w)
+		if err != nil {
+			return nil, err
+		}
+		bw.w = bufio.NewWriter(bw.compress)
+	default:
+		return nil, fmt.Errorf("unknown demo compression %v", compression)
+	}
+	return bw, nil
+}
+
+func (w *binaryFrameWriter) Write(f *Frame) error {
+	var flags uint64
+	var inputBuf []byte
+	if f.Input != nil {
+		inputBuf = appendInput(nil, f.Input)
+		if w.prevInput != nil && bytes.Equal(inputBuf, w.prevInput) {
+			flags |= frameRepeatsInput
+		} else {
+			flags |= frameHasInput
+		}
+		w.prevInput = inputBuf
+	}
+	if f.SaveGame != nil {
+		flags |= frameHasSaveGame
+	}
+	if len(f.SaveGames) != 0 {
+		flags |= frameHasSaveGames
+	}
+	if f.FinalSaveGame != nil {
+		flags |= frameHasFinalSaveGame
+	}
+	if f.PlayerPos != nil {
+		flags |= frameHasPlayerPos
+	}
+	if f.Keyframe != nil {
+		flags |= frameHasKeyframe
+	}
+	if len(f.SaveCheckpoints) != 0 {
+		flags |= frameHasSaveCheckpoints
+	}
+	buf := binary.AppendUvarint(w.buf[:0], flags)
+	if flags&frameHasInput != 0 {
+		buf = append(buf, inputBuf...)
+	}
+	var err error
+	if f.SaveGame != nil {
+		buf, err = appendJSON(buf, f.SaveGame)
+		if err != nil {
+			return fmt.Errorf("could not encode save game: %w", err)
+		}
+	}
+	if len(f.SaveGames) != 0 {
+		buf = binary.AppendUvarint(buf, uint64(len(f.SaveGames)))
+		for _, h := range f.SaveGames {
+			buf = binary.LittleEndian.AppendUint64(buf, h)
+		}
+	}
+	if f.FinalSaveGame != nil {
+		buf, err = appendJSON(buf, f.FinalSaveGame)
+		if err != nil {
+			return fmt.Errorf("could not encode final save game: %w", err)
+		}
+	}
+	if f.PlayerPos != nil {
+		d := f.PlayerPos.Delta(w.prevPos)
+		buf = binary.AppendVarint(buf, int64(d.DX))
+		buf = binary.AppendVarint(buf, int64(d.DY))
+		w.prevPos = *f.PlayerPos
+	}
+	if f.Keyframe != nil {
+		buf, err = appendJSON(buf, f.Keyframe)
+		if err != nil {
+			return fmt.Errorf("could not encode keyframe: %w", err)
+		}
+	}
+	if len(f.SaveCheckpoints) != 0 {
+		buf = binary.AppendUvarint(buf, uint64(len(f.SaveCheckpoints)))
+		for _, cp := range f.SaveCheckpoints {
+			buf = binary.AppendUvarint(buf, uint64(len(cp)))
+			buf = append(buf, cp...)
+		}
+	}
+	w.buf = buf
+	_, err = w.w.Write(buf)
+	return err
+}
+
+func (w *binaryFrameWriter) Close() error {
+	err := w.w.Flush()
+	if err != nil {
+		return err
+	}
+	if w.compress != nil {
+		return w.compress.Close()
+	}
+	return nil
+}
+
+type binaryFrameReader struct {
+	r         *bufio.Reader
+	zstd      *zstd.Decoder
+	flagsMask uint64
+	prevInput *input.DemoState
+	prevPos   m.Pos
+}
+
+func newBinaryFrameReader(r *bufio.Reader) (*binaryFrameReader, error) {
+	header := make([]byte, len(binaryMagic)+2)
+	_, err := io.ReadFull(r, header)
+	if err != nil {
+		return nil, fmt.Errorf("could not read demo header: %w", err)
+	}
+	version := header[len(binaryMagic)]
+	flagsMask, found := binaryVersionFrameFlags[version]
+	if !found {
+		return nil, fmt.Errorf("unsupported demo version: got %d, want at most %d", version, binaryVersion)
+	}
+	br := &binaryFrameReader{flagsMask: flagsMask}
+	switch compression := Compression(header[len(binaryMagic)+1]); compression {
+	case NoCompression:
+		br.r = r
+	case GzipCompression:
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("could not decompress demo: %w", err)
+		}
+		br.r = bufio.NewReader(gz)
+	case ZstdCompression:
+		zs, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("could not decompress demo: %w", err)
+		}
+		br.zstd = zs
+		br.r = bufio.NewReader(zs)
+	default:
+		return nil, fmt.Errorf("unsupported demo compression %d", compression)
+	}
+	return br, nil
+}
+
+func (r *binaryFrameReader) Close() error {
+	if r.zstd != nil {
+		// Stops the decoder goroutines.
+		r.zstd.Close()
+		r.zstd = nil
+	}
+	return nil
+}
+
+func (r *binaryFrameReader) More() bool {
+	_, err := r.r.Peek(1)
+	return err == nil
+}
+
+func (r *binaryFrameReader) Read(f *Frame) error {
+	*f = Frame{}
+	flags, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return err
+	}
+	if flags&^r.flagsMask != 0 {
+		return fmt.Errorf("unknown frame flags %#x", flags)
+	}
+	if flags&frameHasInput != 0 {
+		r.prevInput, err = readInput(r.r)
+		if err != nil {
+			return fmt.Errorf("could not decode input: %w", err)
+		}
+	}
+	if flags&(frameHasInput|frameRepeatsInput) != 0 {
+		if r.prevInput == nil {
+			return errors.New("repeated input without previous input")
+		}
+		f.Input = cloneDemoState(r.prevInput)
+	}
+	if flags&frameHasSaveGame != 0 {
+		f.SaveGame, err = readSaveGame(r.r)
+		if err != nil {
+			return fmt.Errorf("could not decode save game: %w", err)
+		}
+	}
+	if flags&frameHasSaveGames != 0 {
+		n, err := binary.ReadUvarint(r.r)
+		if err != nil {
+			return err
+		}
+		if n == 0 || n > maxSaveGameSize/8 {
+			return fmt.Errorf("invalid save game count %d", n)
+		}
+		f.SaveGames = make([]uint64, n)
+		err = binary.Read(r.r, binary.LittleEndian, f.SaveGames)
+		if err != nil {
+			return err
+		}
+	}
+	if flags&frameHasFinalSaveGame != 0 {
+		f.FinalSaveGame, err = readSaveGame(r.r)
+		if err != nil {
+			return fmt.Errorf("could not decode final save game: %w", err)
+		}
+	}
+	if flags&frameHasPlayerPos != 0 {
+		dx, err := binary.ReadVarint(r.r)
+		if err != nil {
+			return err
+		}
+		dy, err := binary.ReadVarint(r.r)
+		if err != nil {
+			return err
+		}
+		r.prevPos = r.prevPos.Add(m.Delta{DX: int(dx), DY: int(dy)})
+		pos := r.prevPos
+		f.PlayerPos = &pos
+	}
+	if flags&frameHasKeyframe != 0 {
+		f.Keyframe = &Keyframe{}
+		err = readJSON(r.r, f.Keyframe)
+		if err != nil {
+			return fmt.Errorf("could not decode keyframe: %w", err)
+		}
+	}
+	if flags&frameHasSaveCheckpoints != 0 {
+		n, err := binary.ReadUvarint(r.r)
+		if err != nil {
+			return err
+		}
+		if n == 0 || n > maxSaveGameSize/8 {
+			return fmt.Errorf("invalid save checkpoint count %d", n)
+		}
+		f.SaveCheckpoints = make([]string, n)
+		for i := range f.SaveCheckpoints {
+			l, err := binary.ReadUvarint(r.r)
+			if err != nil {
+				return err
+			}
+			if l > maxSaveGameSize {
+				return fmt.Errorf("save checkpoint name too long: %d bytes", l)
+			}
+			cp := make([]byte, l)
+			_, err = io.ReadFull(r.r, cp)
+			if err != nil {
+				return err
+			}
+			f.SaveCheckpoints[i] = string(cp)
+		}
+	}
+	return nil
+}
+
+// cloneDemoState returns a deep copy, so that callers may modify frames freely.
+func cloneDemoState(s *input.DemoState) *input.DemoState {
+	out := *s
+	for _, imp := range demoStateImpulses(&out) {
+		if *imp != nil {
+			c := **imp
+			*imp = &c
+		}
+	}
+	if out.HoverPos != nil {
+		p := *out.HoverPos
+		out.HoverPos = &p
+	}
+	if out.ClickPos != nil {
+		p := *out.ClickPos
+		out.ClickPos = &p
+	}
+	return &out
+}
@@ -0,0 +1,120 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package demo
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readFrames(t *testing.T, data []byte) [][]byte {
+	t.Helper()
+	r, err := NewFrameReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("could not open demo: %v", err)
+	}
+	defer r.Close()
+	var frames [][]byte
+	var f Frame
+	for r.More() {
+		err := r.Read(&f)
+		if err != nil {
+			t.Fatalf("could not read frame %d: %v", len(frames), err)
+		}
+		j, err := json.Marshal(&f)
+		if err != nil {
+			t.Fatalf("could not marshal frame %d: %v", len(frames), err)
+		}
+		frames = append(frames, j)
+	}
+	return frames
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	original, err := os.ReadFile(filepath.Join("..", "..", "assets", "demos", "benchmark.dem"))
+	if err != nil {
+		t.Fatalf("could not read demo: %v", err)
+	}
+	want := readFrames(t, original)
+	if len(want) == 0 {
+		t.Fatalf("demo has no frames")
+	}
+	for _, c := range []struct {
+		name        string
+		compression Compression
+	}{
+		{"none", NoCompression},
+		{"gzip", GzipCompression},
+		{"zstd", ZstdCompression},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			var bin bytes.Buffer
+			err := Convert(bytes.NewReader(original), &bin, BinaryFormat, c.compression)
+			if err != nil {
+				t.Fatalf("could not convert to binary: %v", err)
+			}
+			if !bytes.HasPrefix(bin.Bytes(), []byte(binaryMagic)) {
+				t.Fatalf("converted demo is not binary")
+			}
+			var back bytes.Buffer
+			err = Convert(bytes.NewReader(bin.Bytes()), &back, JSONFormat, NoCompression)
+			if err != nil {
+				t.Fatalf("could not convert back to JSON: %v", err)
+			}
+			for name, data := range map[string][]byte{"binary": bin.Bytes(), "json": back.Bytes()} {
+				got := readFrames(t, data)
+				if len(got) != len(want) {
+					t.Fatalf("%s: got %d frames, want %d", name, len(got), len(want))
+				}
+				for i := range want {
+					if !bytes.Equal(got[i], want[i]) {
+						t.Fatalf("%s: frame %d differs: got %s, want %s", name, i, got[i], want[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestBinaryRejectsNewFlagsInOldVersion(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewFrameWriter(&buf, BinaryFormat, NoCompression)
+	if err != nil {
+		t.Fatalf("could not create writer: %v", err)
+	}
+	err = w.Write(&Frame{SaveCheckpoints: []string{"start"}})
+	if err != nil {
+		t.Fatalf("could not write frame: %v", err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("could not close writer: %v", err)
+	}
+	data := buf.Bytes()
+	data[len(binaryMagic)] = 1
+	r, err := NewFrameReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("could not open demo: %v", err)
+	}
+	defer r.Close()
+	var f Frame
+	err = r.Read(&f)
+	if err == nil {
+		t.Errorf("version 1 demo with save checkpoints was accepted")
+	}
+}
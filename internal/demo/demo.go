@@ -15,7 +15,6 @@
 package demo
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -38,31 +37,42 @@ var (
 	alwaysDemoRecordWithTimestamp = flag.String("always_demo_record_with_timestamp", "", "local file path for demo to record to; in the filename, strftime parameters or %s can be used to encode a timestamp; this option persists")
 	demoPlay                      = flag.String("demo_play", "", "local file path for demo to play back")
 	demoTimedemo                  = flag.Bool("demo_timedemo", false, "run demos as fast as possible, only limited by rendering; normally you'd want to pass -vsync=false too when using this")
+	demoRecordFormat              = flag.String("demo_record_format", "json", "file format of recorded demos; can be 'json' or 'binary' (playback detects the format automatically)")
+	demoRecordCompression         = flag.String("demo_record_compression", "none", "compression of recorded binary demos; can be 'none', 'gzip' or 'zstd'")
 )
 
-type frame struct {
+// Frame is a single frame of a demo.
+type Frame struct {
 	SaveGame *level.SaveGame  `json:",omitempty"`
 	Input    *input.DemoState `json:",omitempty"`
+	Keyframe *Keyframe        `json:",omitempty"`
 
 	// The following data is not actually played back, but compared at playback time.
 	SaveGames     []uint64        `json:",omitempty"`
 	FinalSaveGame *level.SaveGame `json:",omitempty"`
 	PlayerPos     *m.Pos          `json:",omitempty"`
+
+	// The following data is only informational, e.g. for comparing demos.
+	SaveCheckpoints []string `json:",omitempty"` // Last checkpoint at the time of each of SaveGames.
 }
 
 var (
 	demoPlayerFile            vfs.ReadSeekCloser
-	demoPlayer                *json.Decoder
-	demoPlayerFrame           frame
+	demoPlayer                FrameReader
+	demoPlayerFrame           Frame
 	demoPlayerFrameIdx        int
 	demoPlayerHasExplicitSave bool
-	demoRecorderFrame         frame
+	demoRecorderFrame         Frame
 	demoRecorderFile          io.WriteCloser
+	demoRecorderName          string
 	demoRecorderFinalSaveGame *level.SaveGame
-	demoRecorder              *json.Encoder
+	demoRecorder              FrameWriter
 )
 
 func Init() error {
+	if TAS() {
+		return tasInit()
+	}
 	if *demoPlay != "" {
 		var err error
 		demoPlayerFile, err = vfs.OSOpen(vfs.WorkDir, *demoPlay)
@@ -73,7 +83,14 @@ func Init() error {
 				return fmt.Errorf("could not open demo %v: local error: %v, VFS error: %v", *demoPlay, err, verr)
 			}
 		}
-		demoPlayer = json.NewDecoder(demoPlayerFile)
+		err = initSeek()
+		if err != nil {
+			return fmt.Errorf("could not seek in demo %v: %w", *demoPlay, err)
+		}
+		demoPlayer, err = NewFrameReader(demoPlayerFile)
+		if err != nil {
+			return fmt.Errorf("could not read demo %v: %w", *demoPlay, err)
+		}
 		vfs.CrashOnWrite("demo playback")
 	}
 	var demoRecordName string
@@ -90,27 +107,48 @@ func Init() error {
 		if is, _ := flag.Cheating(); is {
 			return errors.New("cannot record a demo while cheating")
 		}
-		var err error
+		if demoPlayerSeekKeyframeIdx > 0 {
+			return errors.New("cannot record a demo while seeking in a played demo")
+		}
+		format, err := ParseFormat(*demoRecordFormat)
+		if err != nil {
+			return err
+		}
+		compression, err := ParseCompression(*demoRecordCompression)
+		if err != nil {
+			return err
+		}
 		demoRecorderFile, err = vfs.OSCreate(vfs.WorkDir, demoRecordName)
 		if err != nil {
 			return err
 		}
-		demoRecorder = json.NewEncoder(demoRecorderFile)
-		demoRecorder.SetIndent("", "")
+		demoRecorder, err = NewFrameWriter(demoRecorderFile, format, compression)
+		if err != nil {
+			demoRecorderFile.Close()
+			return err
+		}
+		demoRecorderName = demoRecordName
 		log.Infof("recording demo to %v", demoRecordName)
 	}
 	return nil
 }
 
 func BeforeExit() error {
+	if TAS() {
+		return tasSave()
+	}
 	if demoRecorder != nil {
-		demoRecorderFrame = frame{
+		demoRecorderFrame = Frame{
 			FinalSaveGame: demoRecorderFinalSaveGame,
 		}
-		err := demoRecorder.Encode(&demoRecorderFrame)
+		err := demoRecorder.Write(&demoRecorderFrame)
 		if err != nil {
 			return fmt.Errorf("could not encode final demo frame: %w", err)
 		}
+		err = demoRecorder.Close()
+		if err != nil {
+			return fmt.Errorf("could not finish demo: %w", err)
+		}
 		err = demoRecorderFile.Close()
 		if err != nil {
 			return fmt.Errorf("failed to save demo to %v: %w", *demoRecord, err)
@@ -120,7 +158,11 @@ func BeforeExit() error {
 		if playReadFrame() {
 			regression(highPrio, "game ended but demo would still go on")
 		}
-		err := demoPlayerFile.Close()
+		err := demoPlayer.Close()
+		if err != nil {
+			return fmt.Errorf("failed to close played demo from %v: %w", *demoPlay, err)
+		}
+		err = demoPlayerFile.Close()
 		if err != nil {
 			return fmt.Errorf("failed to close played demo from %v: %w", *demoPlay, err)
 		}
@@ -132,6 +174,11 @@ func BeforeExit() error {
 	return nil
 }
 
+// Recorded returns the file name of the demo being recorded, and its final save game so far.
+func Recorded() (string, *level.SaveGame) {
+	return demoRecorderName, demoRecorderFinalSaveGame
+}
+
 func Playing() bool {
 	return demoPlayer != nil
 }
@@ -141,6 +188,10 @@ func Timedemo() bool {
 }
 
 func Update() bool {
+	if TAS() {
+		tasUpdate()
+		return false
+	}
 	wantQuit := false
 	if demoPlayer != nil {
 		wantQuit = playFrame()
@@ -152,6 +203,9 @@ func Update() bool {
 }
 
 func PostUpdate(playerPos m.Pos) {
+	if TAS() {
+		tasPostUpdate(playerPos)
+	}
 	if demoPlayer != nil {
 		postPlayFrame(playerPos)
 	}
@@ -161,6 +215,9 @@ func PostUpdate(playerPos m.Pos) {
 }
 
 func PostDraw(screen *ebiten.Image) {
+	if TAS() {
+		tasDraw(screen)
+	}
 	if demoPlayer != nil {
 		regressionPostDrawFrame(screen)
 	}
@@ -170,8 +227,7 @@ func playReadFrame() bool {
 	s := demoPlayerFrame.SaveGame
 	demoPlayerHasExplicitSave = false
 	for demoPlayer.More() {
-		demoPlayerFrame = frame{}
-		err := demoPlayer.Decode(&demoPlayerFrame)
+		err := demoPlayer.Read(&demoPlayerFrame)
 		if err != nil {
 			log.Fatalf("could not decode demo frame: %v", err)
 		}
@@ -193,7 +249,12 @@ func playReadFrame() bool {
 }
 
 func playFrame() bool {
-	if !playReadFrame() {
+	if demoPlayerFrameIdx > 0 && demoPlayerSeekKeyframeIdx > 0 {
+		if !playSeek() {
+			regression(highPrio, "demo ended before the keyframe to seek to")
+			return true
+		}
+	} else if !playReadFrame() {
 		regression(highPrio, "demo ended but game didn't quit")
 		return true
 	}
@@ -203,40 +264,54 @@ func playFrame() bool {
 
 func postPlayFrame(playerPos m.Pos) {
 	if len(demoPlayerFrame.SaveGames) != 0 {
-		regression(mediumPrio, "save game: got no saves, want %v", demoPlayerFrame.SaveGames)
+		regression(mediumPrio, "save game: got no saves, want %v", demoPlayerFrame.SaveGames).SaveHash = &reportHashMismatch{
+			Want: demoPlayerFrame.SaveGames[0],
+		}
 	}
 	if demoPlayerFrame.PlayerPos != nil && playerPos != *demoPlayerFrame.PlayerPos {
-		d := playerPos.Delta(*demoPlayerFrame.PlayerPos).Norm1()
+		delta := playerPos.Delta(*demoPlayerFrame.PlayerPos)
+		d := delta.Norm1()
 		dlog := 0
 		dpow := 1
 		for d >= dpow {
 			dlog++
 			dpow *= 2
 		}
-		regression(lowPrio.WithParam(dlog), "player pos: got %v, want %v", playerPos, *demoPlayerFrame.PlayerPos)
+		regression(lowPrio.WithParam(dlog), "player pos: got %v, want %v", playerPos, *demoPlayerFrame.PlayerPos).PlayerPos = &reportPosMismatch{
+			Got:   playerPos,
+			Want:  *demoPlayerFrame.PlayerPos,
+			Delta: delta,
+		}
 	}
 	regressionPostPlayFrame()
 	demoPlayerFrameIdx++
 }
 
 func recordFrame() {
-	demoRecorderFrame = frame{
+	demoRecorderFrame = Frame{
 		Input: input.SaveToDemo(),
 	}
 }
 
 func postRecordFrame(playerPos m.Pos) {
 	demoRecorderFrame.PlayerPos = &playerPos
-	err := demoRecorder.Encode(&demoRecorderFrame)
+	err := demoRecorder.Write(&demoRecorderFrame)
 	if err != nil {
 		log.Fatalf("could not encode demo frame: %v", err)
 	}
+	demoRecorderFrameIdx++
 }
 
-func InterceptSaveGame(save *level.SaveGame) bool {
+func InterceptSaveGame(save *level.SaveGame, lastCheckpoint string) bool {
+	// In tool-assisted mode, saves only go to memory.
+	if TAS() {
+		tasInterceptSaveGame(save, lastCheckpoint)
+		return true
+	}
 	// Always record everything.
 	if demoRecorder != nil {
 		demoRecorderFrame.SaveGames = append(demoRecorderFrame.SaveGames, save.StateHash)
+		demoRecorderFrame.SaveCheckpoints = append(demoRecorderFrame.SaveCheckpoints, lastCheckpoint)
 		demoRecorderFinalSaveGame = save
 	}
 	// While playing back, we only save to memory to allow later recalling.
@@ -249,10 +324,15 @@ func InterceptSaveGame(save *level.SaveGame) bool {
 			demoPlayerFrame.SaveGame = save
 		}
 		if len(demoPlayerFrame.SaveGames) == 0 {
-			regression(mediumPrio, "save game: got hash %v, want no saves", save.StateHash)
+			regression(mediumPrio, "save game: got hash %v, want no saves", save.StateHash).SaveHash = &reportHashMismatch{
+				Got: save.StateHash,
+			}
 		} else {
 			if save.StateHash != demoPlayerFrame.SaveGames[0] {
-				regression(mediumPrio, "save game: got hash %v, want %v", save.StateHash, demoPlayerFrame.SaveGames[0])
+				regression(mediumPrio, "save game: got hash %v, want %v", save.StateHash, demoPlayerFrame.SaveGames[0]).SaveHash = &reportHashMismatch{
+					Got:  save.StateHash,
+					Want: demoPlayerFrame.SaveGames[0],
+				}
 			}
 			demoPlayerFrame.SaveGames = demoPlayerFrame.SaveGames[1:]
 		}
@@ -262,6 +342,9 @@ func InterceptSaveGame(save *level.SaveGame) bool {
 }
 
 func InterceptPreLoadGame() (*level.SaveGame, bool) {
+	if TAS() {
+		return tasInterceptPreLoadGame()
+	}
 	// While playing back, we always return the last save game from the demo.
 	if demoPlayer != nil {
 		if demoPlayerFrame.SaveGame != nil && demoPlayerFrame.SaveGame.GameVersion == "" {
@@ -273,6 +356,10 @@ func InterceptPreLoadGame() (*level.SaveGame, bool) {
 }
 
 func InterceptPostLoadGame(save *level.SaveGame) {
+	if TAS() {
+		tasInterceptPostLoadGame(save)
+		return
+	}
 	// While recording, store the current save game.
 	if demoRecorder != nil {
 		if save == nil {
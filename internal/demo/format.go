@@ -0,0 +1,170 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package demo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Format is the file format of a demo.
+type Format int
+
+const (
+	// JSONFormat stores one JSON object per frame.
+	JSONFormat Format = iota
+	// BinaryFormat is a versioned, compact binary container.
+	BinaryFormat
+)
+
+// ParseFormat parses a demo format name as used in flags.
+func ParseFormat(s string) (Format, error) {
+	switch s {
+	case "json":
+		return JSONFormat, nil
+	case "binary":
+		return BinaryFormat, nil
+	default:
+		return JSONFormat, fmt.Errorf("unknown demo format %q: want json or binary", s)
+	}
+}
+
+// Compression is the compression applied to a binary demo.
+type Compression byte
+
+const (
+	NoCompression Compression = iota
+	GzipCompression
+	ZstdCompression
+)
+
+// ParseCompression parses a demo compression name as used in flags.
+func ParseCompression(s string) (Compression, error) {
+	switch s {
+	case "none":
+		return NoCompression, nil
+	case "gzip":
+		return GzipCompression, nil
+	case "zstd":
+		return ZstdCompression, nil
+	default:
+		return NoCompression, fmt.Errorf("unknown demo compression %q: want none, gzip or zstd", s)
+	}
+}
+
+// FrameReader reads demo frames in sequence.
+type FrameReader interface {
+	// More returns whether there is another frame to read.
+	More() bool
+	// Read reads the next frame into f, replacing all its content.
+	Read(f *Frame) error
+	// Close releases all resources. It does not close the underlying reader.
+	Close() error
+}
+
+// FrameWriter writes demo frames in sequence.
+type FrameWriter interface {
+	// Write appends a frame to the demo.
+	Write(f *Frame) error
+	// Close flushes all pending data. It does not close the underlying writer.
+	Close() error
+}
+
+// NewFrameReader returns a FrameReader for the given demo file.
+// The file format is detected automatically.
+func NewFrameReader(r io.Reader) (FrameReader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(binaryMagic))
+	if err == nil && bytes.Equal(magic, []byte(binaryMagic)) {
+		return newBinaryFrameReader(br)
+	}
+	// Anything else (including short files) is treated as JSON.
+	return &jsonFrameReader{dec: json.NewDecoder(br)}, nil
+}
+
+// NewFrameWriter returns a FrameWriter writing to w in the given format.
+func NewFrameWriter(w io.Writer, format Format, compression Compression) (FrameWriter, error) {
+	switch format {
+	case JSONFormat:
+		if compression != NoCompression {
+			return nil, fmt.Errorf("JSON demos cannot be compressed")
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "")
+		return &jsonFrameWriter{enc: enc}, nil
+	case BinaryFormat:
+		return newBinaryFrameWriter(w, compression)
+	default:
+		return nil, fmt.Errorf("unknown demo format %v", format)
+	}
+}
+
+// Convert copies all frames of a demo to a new demo with the given format.
+// No frame content is lost in the process, so conversions can go both ways.
+func Convert(r io.Reader, w io.Writer, format Format, compression Compression) error {
+	reader, err := NewFrameReader(r)
+	if err != nil {
+		return fmt.Errorf("could not open input demo: %w", err)
+	}
+	defer reader.Close()
+	writer, err := NewFrameWriter(w, format, compression)
+	if err != nil {
+		return fmt.Errorf("could not open output demo: %w", err)
+	}
+	var f Frame
+	for i := 0; reader.More(); i++ {
+		err := reader.Read(&f)
+		if err != nil {
+			return fmt.Errorf("could not read demo frame %d: %w", i, err)
+		}
+		err = writer.Write(&f)
+		if err != nil {
+			return fmt.Errorf("could not write demo frame %d: %w", i, err)
+		}
+	}
+	return writer.Close()
+}
+
+type jsonFrameReader struct {
+	dec *json.Decoder
+}
+
+func (r *jsonFrameReader) More() bool {
+	return r.dec.More()
+}
+
+func (r *jsonFrameReader) Read(f *Frame) error {
+	*f = Frame{}
+	return r.dec.Decode(f)
+}
+
+func (r *jsonFrameReader) Close() error {
+	return nil
+}
+
+type jsonFrameWriter struct {
+	enc *json.Encoder
+}
+
+func (w *jsonFrameWriter) Write(f *Frame) error {
+	return w.enc.Encode(f)
+}
+
+func (w *jsonFrameWriter) Close() error {
+	return nil
+}
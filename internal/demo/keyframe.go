@@ -0,0 +1,186 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package demo
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/log"
+)
+
+var (
+	demoRecordKeyframeInterval = flag.Int("demo_record_keyframe_interval", 600, "minimum number of frames between keyframes in recorded demos; keyframes can only be recorded when the player respawns in game; 0 disables keyframes")
+	demoPlayStartFrame         = flag.Int("demo_play_start_frame", 0, "frame index to start demo playback at; playback jumps to the nearest keyframe before it and fast forwards from there")
+)
+
+// Keyframe is the state needed to resume demo playback in the middle of a demo.
+//
+// Keyframes are only recorded when the player respawns in game, as the world
+// state right after a respawn is fully determined by the save game and the
+// checkpoint, except for the player who may still move in the same frame. At
+// any other time, entities carry state that is not saved.
+type Keyframe struct {
+	SaveGame     *level.SaveGame
+	Checkpoint   string
+	TimerStarted bool `json:",omitempty"`
+	// SavesBefore is the number of SaveGames of the same frame that happened before the respawn.
+	SavesBefore int `json:",omitempty"`
+	// Player is the state of the player at the end of the respawn's frame.
+	Player level.PersistentState
+}
+
+var (
+	demoRecorderFrameIdx        int
+	demoRecorderLastKeyframeIdx int
+	demoPlayerSeekKeyframeIdx   int
+	demoPlayerKeyframeToRestore *Keyframe
+)
+
+// WantKeyframe returns whether a keyframe should be recorded now.
+func WantKeyframe() bool {
+	if TAS() {
+		// Keyframes are what rewinding goes back to.
+		return true
+	}
+	if demoRecorder == nil || *demoRecordKeyframeInterval <= 0 {
+		return false
+	}
+	return demoRecorderFrameIdx-demoRecorderLastKeyframeIdx >= *demoRecordKeyframeInterval
+}
+
+// RecordKeyframe records a keyframe in the current frame.
+// Must be called right before an in-game respawn.
+func RecordKeyframe(save *level.SaveGame, checkpointName string, timerStarted bool) {
+	if TAS() {
+		tasRecordKeyframe(save, checkpointName, timerStarted)
+		return
+	}
+	if demoRecorder == nil {
+		return
+	}
+	demoRecorderFrame.Keyframe = &Keyframe{
+		SaveGame:     save,
+		Checkpoint:   checkpointName,
+		TimerStarted: timerStarted,
+		SavesBefore:  len(demoRecorderFrame.SaveGames),
+	}
+	demoRecorderLastKeyframeIdx = demoRecorderFrameIdx
+}
+
+// RecordKeyframePlayer adds the player state to the keyframe recorded in the current frame, if any.
+// Must be called after all entities have been updated.
+func RecordKeyframePlayer(player level.PersistentState) {
+	if TAS() {
+		tasRecordKeyframePlayer(player)
+		return
+	}
+	if demoRecorder == nil || demoRecorderFrame.Keyframe == nil {
+		return
+	}
+	demoRecorderFrame.Keyframe.Player = player
+}
+
+// findKeyframe returns the index of the last keyframe at or before the given frame.
+// Frame 0 is never returned as a keyframe, as it is played anyway to start the game.
+func findKeyframe(r FrameReader, maxIdx int) (int, error) {
+	var f Frame
+	keyframeIdx := 0
+	for i := 0; i <= maxIdx && r.More(); {
+		err := r.Read(&f)
+		if err != nil {
+			return 0, fmt.Errorf("could not decode demo frame %d: %w", i, err)
+		}
+		if f.FinalSaveGame != nil {
+			continue
+		}
+		if i > 0 && f.Keyframe != nil {
+			keyframeIdx = i
+		}
+		i++
+	}
+	return keyframeIdx, nil
+}
+
+// initSeek prepares seeking to the requested start frame.
+func initSeek() error {
+	if *demoPlayStartFrame <= 0 {
+		return nil
+	}
+	r, err := NewFrameReader(demoPlayerFile)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	demoPlayerSeekKeyframeIdx, err = findKeyframe(r, *demoPlayStartFrame)
+	if err != nil {
+		return err
+	}
+	_, err = demoPlayerFile.Seek(0, io.SeekStart)
+	if err != nil {
+		return fmt.Errorf("could not rewind demo: %w", err)
+	}
+	if demoPlayerSeekKeyframeIdx > 0 {
+		log.Infof("demo will jump to keyframe at frame %d and fast forward to frame %d", demoPlayerSeekKeyframeIdx, *demoPlayStartFrame)
+	} else {
+		log.Warningf("demo has no keyframe before frame %d; fast forwarding from the start", *demoPlayStartFrame)
+	}
+	return nil
+}
+
+// playSeek skips all frames before the keyframe to seek to without simulating them.
+// Returns false if the demo ended.
+func playSeek() bool {
+	for demoPlayerFrameIdx < demoPlayerSeekKeyframeIdx {
+		if !playReadFrame() {
+			return false
+		}
+		demoPlayerFrameIdx++
+	}
+	demoPlayerSeekKeyframeIdx = 0
+	if !playReadFrame() {
+		return false
+	}
+	kf := demoPlayerFrame.Keyframe
+	if kf == nil {
+		log.Fatalf("demo keyframe at frame %d disappeared", demoPlayerFrameIdx)
+	}
+	// Saves from before the respawn will not happen again.
+	if kf.SavesBefore <= len(demoPlayerFrame.SaveGames) {
+		demoPlayerFrame.SaveGames = demoPlayerFrame.SaveGames[kf.SavesBefore:]
+	}
+	demoPlayerKeyframeToRestore = kf
+	return true
+}
+
+// KeyframeToRestore returns the keyframe the game has to be restored to
+// instead of simulating the current frame, if any.
+func KeyframeToRestore() *Keyframe {
+	kf := demoPlayerKeyframeToRestore
+	demoPlayerKeyframeToRestore = nil
+	return kf
+}
+
+// FastForwarding returns whether demo playback is before the requested start frame,
+// or the tool-assisted mode is going to a frame.
+// The game should then run frames as fast as possible.
+func FastForwarding() bool {
+	if TAS() {
+		return tasFrameIdx < tasSeekIdx
+	}
+	return demoPlayer != nil && demoPlayerFrameIdx < *demoPlayStartFrame
+}
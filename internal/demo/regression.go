@@ -34,12 +34,14 @@ var (
 )
 
 var (
-	regressionCount           int
-	regressionScreenshotCount int
-	regressionsPrevFramePrio  prio
-	regressionsThisFramePrio  prio
-	regressionsThisFrame      []string
-	regressionsToDraw         []string
+	regressionCount             int
+	regressionScreenshotCount   int
+	regressionsPrevFramePrio    prio
+	regressionsThisFramePrio    prio
+	regressionsThisFrame        []string
+	regressionsThisFrameEntries []*reportEntry
+	regressionsToDraw           []string
+	regressionsToDrawEntries    []*reportEntry
 )
 
 type prio int
@@ -60,7 +62,8 @@ func (p prio) WithParam(q int) prio {
 	return p + prio(q)
 }
 
-func regression(prio prio, format string, args ...interface{}) {
+// regression reports a regression. The returned report entry may be annotated further.
+func regression(prio prio, format string, args ...interface{}) *reportEntry {
 	regression := fmt.Sprintf(format, args...)
 	log.Errorf("REGRESSION: %s", regression)
 	regressionsThisFrame = append(regressionsThisFrame, regression)
@@ -68,11 +71,15 @@ func regression(prio prio, format string, args ...interface{}) {
 		regressionsThisFramePrio = prio
 	}
 	regressionCount++
+	e := reportRegression(prio, regression)
+	regressionsThisFrameEntries = append(regressionsThisFrameEntries, e)
+	return e
 }
 
 func regressionPostPlayFrame() {
 	// Update state.
 	regressions := regressionsThisFrame
+	entries := regressionsThisFrameEntries
 	havePrio := regressionsThisFramePrio
 	hadPrio := regressionsPrevFramePrio
 	// HACK: We keep the highest priority within the current regression section.
@@ -82,6 +89,7 @@ func regressionPostPlayFrame() {
 		regressionsPrevFramePrio = havePrio
 	}
 	regressionsThisFrame = nil
+	regressionsThisFrameEntries = nil
 	regressionsThisFramePrio = 0
 
 	// Report this regression?
@@ -89,13 +97,16 @@ func regressionPostPlayFrame() {
 		// Worth reporting, not a dupe from last frame.
 		regressionsToDraw = append(regressionsToDraw, fmt.Sprintf("Frame %d:", demoPlayerFrameIdx))
 		regressionsToDraw = append(regressionsToDraw, regressions...)
+		regressionsToDrawEntries = append(regressionsToDrawEntries, entries...)
 	}
 }
 
 func regressionPostDrawFrame(screen *ebiten.Image) {
 	// Update state.
 	regressions := regressionsToDraw
+	entries := regressionsToDrawEntries
 	regressionsToDraw = nil
+	regressionsToDrawEntries = nil
 
 	// Only if we have regressions.
 	if len(regressions) == 0 {
@@ -132,6 +143,9 @@ func regressionPostDrawFrame(screen *ebiten.Image) {
 	name := fmt.Sprintf("%s%04d.png", *demoPlayRegressionPrefix, regressionScreenshotCount)
 	log.Errorf("dumping regression screenshot to %v", name)
 	regressionScreenshotCount++
+	for _, e := range entries {
+		e.Screenshot = name
+	}
 	err := screenshot.Write(dup, name)
 	if err != nil {
 		log.Fatalf("failed to save regression screenshot: %v", err)
@@ -139,6 +153,10 @@ func regressionPostDrawFrame(screen *ebiten.Image) {
 }
 
 func regressionBeforeExit() error {
+	err := writeReport()
+	if err != nil {
+		return err
+	}
 	if regressionCount != 0 {
 		return fmt.Errorf("detected %d regressions", regressionCount)
 	}
@@ -0,0 +1,210 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package demo
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+var (
+	demoPlayReport = flag.String("demo_play_report", "", "write a regression report to files with this prefix; creates <prefix>.json and <prefix>.junit.xml")
+)
+
+// reportPosMismatch describes a player position regression.
+type reportPosMismatch struct {
+	Got   m.Pos
+	Want  m.Pos
+	Delta m.Delta
+}
+
+// reportHashMismatch describes a save game regression.
+// A zero hash means that no save happened.
+type reportHashMismatch struct {
+	Got  uint64 `json:",omitempty"`
+	Want uint64 `json:",omitempty"`
+}
+
+// reportEntry is a single regression in the report.
+type reportEntry struct {
+	Frame      int
+	Category   string
+	Param      int
+	Message    string
+	PlayerPos  *reportPosMismatch  `json:",omitempty"`
+	SaveHash   *reportHashMismatch `json:",omitempty"`
+	Screenshot string              `json:",omitempty"`
+}
+
+// report is the JSON regression report.
+type report struct {
+	Demo        string
+	Frames      int
+	Counts      map[string]int
+	Regressions []*reportEntry
+}
+
+var (
+	reportEntries []*reportEntry
+)
+
+// reportCategories are all categories, in order of increasing priority.
+var reportCategories = []string{"low", "medium", "high"}
+
+func (p prio) category() string {
+	switch {
+	case p >= highPrio:
+		return "high"
+	case p >= mediumPrio:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+func (p prio) param() int {
+	return int(p % 1000)
+}
+
+// reportRegression adds a regression to the report.
+// The returned entry may be annotated further by the caller.
+func reportRegression(prio prio, msg string) *reportEntry {
+	e := &reportEntry{
+		Frame:    demoPlayerFrameIdx,
+		Category: prio.category(),
+		Param:    prio.param(),
+		Message:  msg,
+	}
+	if *demoPlayReport != "" {
+		reportEntries = append(reportEntries, e)
+	}
+	return e
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func writeReport() error {
+	if *demoPlayReport == "" {
+		return nil
+	}
+	rep := report{
+		Demo:        *demoPlay,
+		Frames:      demoPlayerFrameIdx,
+		Counts:      map[string]int{},
+		Regressions: reportEntries,
+	}
+	if rep.Regressions == nil {
+		rep.Regressions = []*reportEntry{}
+	}
+	byCategory := map[string][]string{}
+	for _, cat := range reportCategories {
+		rep.Counts[cat] = 0
+	}
+	for _, e := range reportEntries {
+		rep.Counts[e.Category]++
+		line := fmt.Sprintf("frame %d (param %d): %s", e.Frame, e.Param, e.Message)
+		if e.Screenshot != "" {
+			line += fmt.Sprintf(" [screenshot: %s]", e.Screenshot)
+		}
+		byCategory[e.Category] = append(byCategory[e.Category], line)
+	}
+	j, err := json.MarshalIndent(rep, "", "\t")
+	if err != nil {
+		return fmt.Errorf("could not encode JSON report: %w", err)
+	}
+	err = writeReportFile(*demoPlayReport+".json", j)
+	if err != nil {
+		return err
+	}
+
+	suite := junitTestSuite{
+		Name: *demoPlay,
+	}
+	for _, cat := range reportCategories {
+		tc := junitTestCase{
+			Name:      cat + "Prio",
+			ClassName: "demo." + cat,
+		}
+		if lines := byCategory[cat]; len(lines) != 0 {
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%d %s priority regressions", len(lines), cat),
+				Type:    "regression",
+				Text:    strings.Join(lines, "\n"),
+			}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+		suite.Tests++
+	}
+	suites := junitTestSuites{
+		Name:     "demo",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Suites:   []junitTestSuite{suite},
+	}
+	x, err := xml.MarshalIndent(suites, "", "\t")
+	if err != nil {
+		return fmt.Errorf("could not encode JUnit report: %w", err)
+	}
+	return writeReportFile(*demoPlayReport+".junit.xml", append([]byte(xml.Header), x...))
+}
+
+func writeReportFile(name string, data []byte) error {
+	f, err := vfs.OSCreate(vfs.WorkDir, name)
+	if err != nil {
+		return fmt.Errorf("could not create report %v: %w", name, err)
+	}
+	_, err = f.Write(data)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("could not write report %v: %w", name, err)
+	}
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("could not close report %v: %w", name, err)
+	}
+	return nil
+}
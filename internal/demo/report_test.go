@@ -0,0 +1,91 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package demo
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+// readReportFile reads back a file written by writeReportFile.
+func readReportFile(t *testing.T, name string) string {
+	t.Helper()
+	f, err := vfs.OSOpen(vfs.WorkDir, name)
+	if err != nil {
+		t.Fatalf("could not open report %v: %v", name, err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("could not read report %v: %v", name, err)
+	}
+	return string(data)
+}
+
+func TestWriteReport(t *testing.T) {
+	prefix := filepath.Join(t.TempDir(), "report")
+	for k, v := range map[string]string{
+		"demo_play":        "test.dem",
+		"demo_play_report": prefix,
+	} {
+		err := flag.Set(k, v)
+		if err != nil {
+			t.Fatalf("could not set %v: %v", k, err)
+		}
+		defer flag.Set(k, "")
+	}
+	defer func() {
+		reportEntries = nil
+		demoPlayerFrameIdx = 0
+	}()
+
+	demoPlayerFrameIdx = 10
+	reportRegression(lowPrio.WithParam(3), "player pos: got 1 2, want 1 10").PlayerPos = &reportPosMismatch{
+		Got:   m.Pos{X: 1, Y: 2},
+		Want:  m.Pos{X: 1, Y: 10},
+		Delta: m.Delta{DX: 0, DY: -8},
+	}
+	demoPlayerFrameIdx = 20
+	reportRegression(mediumPrio, "save game: got hash 1, want 2").SaveHash = &reportHashMismatch{
+		Got:  1,
+		Want: 2,
+	}
+	demoPlayerFrameIdx = 30
+	reportRegression(highPrio, "player died <unexpectedly>").Screenshot = "regression0000.png"
+	demoPlayerFrameIdx = 40
+
+	err := writeReport()
+	if err != nil {
+		t.Fatalf("could not write report: %v", err)
+	}
+	for _, suffix := range []string{".json", ".junit.xml"} {
+		want, err := os.ReadFile(filepath.Join("testdata", "report"+suffix))
+		if err != nil {
+			t.Fatalf("could not read golden report: %v", err)
+		}
+		got := readReportFile(t, prefix+suffix)
+		if diff := cmp.Diff(string(want), got); diff != "" {
+			t.Errorf("report%v differs (-want +got):\n%v", suffix, diff)
+		}
+	}
+}
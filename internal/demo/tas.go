@@ -0,0 +1,571 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package demo
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"os"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/log"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/palette"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+var (
+	cheatTAS = flag.String("cheat_tas", "", "local file path of a demo to edit in tool-assisted mode; it is loaded if it exists and saved on exit; F5 pauses, F6 advances a frame, F7 rewinds a frame (with shift a second), F8 toggles recording over existing frames, F9 saves, F10 starts and ends capturing a macro, shift-F10 plays the macro over the next frames, and while paused 1 to 7 toggle held (with shift just hit) of left, right, up, down, jump, action and exit in the next frame")
+)
+
+// tasImpulseNames are the short names of demoStateImpulses for the overlay.
+const tasImpulseNames = "LRUDJAE"
+
+// tasRewindFrames is how far shift-F7 rewinds; one second of game time.
+const tasRewindFrames = 60
+
+// The tool-assisted mode keeps the whole demo in memory as a timeline and
+// simulates it frame by frame.
+//
+// The world cannot be copied, as entities carry state that is not part of any
+// save game, so rewinding restores the last keyframe before the target frame
+// and fast forwards from there using the stored inputs. In tool-assisted mode,
+// a keyframe is kept at every in-game respawn. Without any keyframe, the game
+// is restarted from the save game of the first frame. A rewind thus costs
+// simulating all frames since the last respawn again; dying on purpose keeps
+// this short.
+var (
+	// tasFrames is the timeline being edited.
+	tasFrames []Frame
+	// tasLastSaves is, for each frame, the last save game at its end.
+	tasLastSaves []*level.SaveGame
+	// tasFrameIdx is the index of the next frame to simulate.
+	tasFrameIdx int
+	// tasStaleIdx is the first frame whose recorded outcome (player position,
+	// saves, keyframe) may be outdated due to an edit of an earlier frame.
+	tasStaleIdx int
+	// tasSeekIdx is the frame to fast forward to.
+	tasSeekIdx int
+	// tasPaused is set while the timeline is held at tasFrameIdx.
+	tasPaused bool
+	// tasStep is set when a single frame is to be simulated while paused.
+	tasStep bool
+	// tasRecording is set when live input replaces stored input.
+	tasRecording bool
+	// tasRestart is set when the game has to be restarted from scratch.
+	tasRestart bool
+	// tasSimulating is set while the current frame is being simulated.
+	tasSimulating bool
+	// tasRestoring is set while the current frame is replaced by its keyframe.
+	tasRestoring bool
+	// tasSaveGame is what loading the game currently returns.
+	tasSaveGame     *level.SaveGame
+	tasHaveSaveGame bool
+	// tasMacro is the captured input of a sequence of frames.
+	tasMacro []*input.DemoState
+	// tasCapturing is set while a macro is being captured from tasMacroStart on.
+	tasCapturing  bool
+	tasMacroStart int
+)
+
+// TAS returns whether the tool-assisted mode is active.
+func TAS() bool {
+	return *cheatTAS != ""
+}
+
+func tasInit() error {
+	if *demoPlay != "" || *demoRecord != "" {
+		return errors.New("cannot play or record a demo in tool-assisted mode")
+	}
+	err := tasLoad()
+	if err != nil {
+		return err
+	}
+	tasStaleIdx = 0
+	tasPaused = true
+	if len(tasFrames) == 0 {
+		// Stop right after the game has started.
+		log.Infof("starting new tool-assisted demo %v", *cheatTAS)
+		tasSeekIdx = 1
+		return nil
+	}
+	// Continue editing where we left off.
+	log.Infof("loaded tool-assisted demo %v with %d frames", *cheatTAS, len(tasFrames))
+	tasSeekIdx = len(tasFrames)
+	tasSaveGame = tasFrames[0].SaveGame
+	tasHaveSaveGame = tasSaveGame != nil
+	return nil
+}
+
+func tasLoad() error {
+	f, err := vfs.OSOpen(vfs.WorkDir, *cheatTAS)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not open tool-assisted demo %v: %w", *cheatTAS, err)
+	}
+	defer f.Close()
+	r, err := NewFrameReader(f)
+	if err != nil {
+		return fmt.Errorf("could not read tool-assisted demo %v: %w", *cheatTAS, err)
+	}
+	defer r.Close()
+	for r.More() {
+		var frame Frame
+		err := r.Read(&frame)
+		if err != nil {
+			return fmt.Errorf("could not decode frame %d of tool-assisted demo %v: %w", len(tasFrames), *cheatTAS, err)
+		}
+		if frame.FinalSaveGame != nil {
+			// Recomputed when simulating.
+			continue
+		}
+		if frame.Input != nil {
+			frame.Input = cloneDemoState(frame.Input)
+		}
+		tasFrames = append(tasFrames, frame)
+	}
+	tasLastSaves = make([]*level.SaveGame, len(tasFrames))
+	return nil
+}
+
+// tasSave writes the timeline as a regular demo.
+func tasSave() error {
+	format, err := ParseFormat(*demoRecordFormat)
+	if err != nil {
+		return err
+	}
+	compression, err := ParseCompression(*demoRecordCompression)
+	if err != nil {
+		return err
+	}
+	f, err := vfs.OSCreate(vfs.WorkDir, *cheatTAS)
+	if err != nil {
+		return fmt.Errorf("could not create tool-assisted demo %v: %w", *cheatTAS, err)
+	}
+	w, err := NewFrameWriter(f, format, compression)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	for i := range tasFrames {
+		frame := tasFrames[i]
+		if i >= tasStaleIdx {
+			// Only keep what is still known to be true.
+			frame = Frame{
+				Input: frame.Input,
+			}
+			if i == 0 {
+				frame.SaveGame = tasFrames[0].SaveGame
+			}
+		}
+		err := w.Write(&frame)
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("could not encode demo frame %d: %w", i, err)
+		}
+	}
+	if tasStaleIdx >= len(tasFrames) && len(tasFrames) > 0 {
+		err := w.Write(&Frame{
+			FinalSaveGame: tasLastSaves[len(tasFrames)-1],
+		})
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("could not encode final demo frame: %w", err)
+		}
+	} else {
+		log.Warningf("frames from %d on were not simulated since editing; saving without their expected outcome", tasStaleIdx)
+	}
+	err = w.Close()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("could not finish demo: %w", err)
+	}
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to save tool-assisted demo to %v: %w", *cheatTAS, err)
+	}
+	log.Infof("saved tool-assisted demo with %d frames to %v", len(tasFrames), *cheatTAS)
+	return nil
+}
+
+// tasRewind goes back to right before the given frame.
+func tasRewind(target int) {
+	if len(tasFrames) == 0 {
+		return
+	}
+	// Frame 0 starts the game, so the earliest state to go back to is after it.
+	target = max(min(target, len(tasFrames)), 1)
+	tasSeekIdx = target
+	tasPaused = true
+	tasStep = false
+	if target == tasFrameIdx {
+		return
+	}
+	for k := min(target, tasStaleIdx) - 1; k > 0; k-- {
+		if tasFrames[k].Keyframe != nil {
+			log.Infof("tool-assisted mode: replaying %d frames from the keyframe at frame %d", target-k-1, k)
+			tasFrameIdx = k
+			tasRestoring = true
+			demoPlayerKeyframeToRestore = tasFrames[k].Keyframe
+			return
+		}
+	}
+	log.Infof("tool-assisted mode: no keyframe before frame %d; replaying from the start", target)
+	tasFrameIdx = 0
+	tasRestart = true
+	tasSaveGame = tasFrames[0].SaveGame
+	tasHaveSaveGame = tasSaveGame != nil
+}
+
+// tasEdit toggles an impulse in the next frame.
+func tasEdit(impulse int, justHit bool) {
+	tasExtend(tasFrameIdx + 1)
+	frame := &tasFrames[tasFrameIdx]
+	if frame.Input == nil {
+		frame.Input = &input.DemoState{}
+	}
+	state := (*demoStateImpulses(frame.Input)[impulse]).OrEmpty()
+	if justHit {
+		state.JustHit = !state.JustHit
+	} else {
+		state.Held = !state.Held
+		state.JustHit = state.Held && !tasHeld(tasFrameIdx-1, impulse)
+	}
+	*demoStateImpulses(frame.Input)[impulse] = state.UnlessEmpty()
+	if !justHit {
+		// Keep the next frame consistent with the new state of this one.
+		tasFixJustHit(tasFrameIdx+1, impulse)
+	}
+	tasStaleIdx = min(tasStaleIdx, tasFrameIdx)
+	if tasRecording {
+		// Recording would immediately overwrite the edit.
+		log.Infof("tool-assisted mode: switching to playback to keep the edit")
+		tasRecording = false
+	}
+}
+
+// tasExtend appends empty frames until the timeline has at least n frames.
+// New frames keep the input map of the frame before them.
+func tasExtend(n int) {
+	for len(tasFrames) < n {
+		frame := Frame{
+			Input: &input.DemoState{},
+		}
+		if k := len(tasFrames) - 1; k >= 0 && tasFrames[k].Input != nil {
+			frame.Input.InputMap = tasFrames[k].Input.InputMap
+		}
+		tasFrames = append(tasFrames, frame)
+		tasLastSaves = append(tasLastSaves, nil)
+	}
+}
+
+// tasFixJustHit makes a held impulse in the given frame just hit exactly if it
+// was not held in the frame before. A just hit impulse that is not held (a tap
+// within the frame) is left alone.
+func tasFixJustHit(idx, impulse int) {
+	if idx < 0 || idx >= len(tasFrames) || tasFrames[idx].Input == nil {
+		return
+	}
+	imp := demoStateImpulses(tasFrames[idx].Input)[impulse]
+	state := (*imp).OrEmpty()
+	if !state.Held {
+		return
+	}
+	state.JustHit = !tasHeld(idx-1, impulse)
+	*imp = state.UnlessEmpty()
+}
+
+// tasToggleMacro starts or ends capturing a macro at the next frame.
+func tasToggleMacro() {
+	if !tasCapturing {
+		tasCapturing = true
+		tasMacroStart = tasFrameIdx
+		log.Infof("tool-assisted mode: capturing macro from frame %d", tasMacroStart)
+		return
+	}
+	tasCapturing = false
+	end := min(tasFrameIdx, len(tasFrames))
+	if end <= tasMacroStart {
+		log.Warningf("tool-assisted mode: macro would end at frame %d before it starts at frame %d; keeping the previous macro", end, tasMacroStart)
+		return
+	}
+	tasMacro = make([]*input.DemoState, 0, end-tasMacroStart)
+	for _, frame := range tasFrames[tasMacroStart:end] {
+		var state *input.DemoState
+		if frame.Input != nil {
+			state = cloneDemoState(frame.Input)
+		}
+		tasMacro = append(tasMacro, state)
+	}
+	log.Infof("tool-assisted mode: captured macro of %d frames", len(tasMacro))
+}
+
+// tasPlayMacro replaces the input of the next frames by the macro and goes to
+// the frame after it.
+func tasPlayMacro() {
+	if len(tasMacro) == 0 {
+		log.Warningf("tool-assisted mode: no macro captured yet")
+		return
+	}
+	start, end := tasFrameIdx, tasFrameIdx+len(tasMacro)
+	tasExtend(end)
+	for i, state := range tasMacro {
+		frame := &tasFrames[start+i]
+		if state == nil {
+			frame.Input = nil
+			continue
+		}
+		frame.Input = cloneDemoState(state)
+	}
+	// Both ends of the macro have new neighbors.
+	for i := range tasImpulseNames {
+		tasFixJustHit(start, i)
+		tasFixJustHit(end, i)
+	}
+	tasStaleIdx = min(tasStaleIdx, start)
+	tasSeekIdx = end
+	tasRecording = false
+}
+
+// tasHeld returns whether an impulse is held in the given frame.
+func tasHeld(idx, impulse int) bool {
+	if idx < 0 || idx >= len(tasFrames) || tasFrames[idx].Input == nil {
+		return false
+	}
+	return (*demoStateImpulses(tasFrames[idx].Input)[impulse]).OrEmpty().Held
+}
+
+// UpdateTAS handles the keys of the tool-assisted mode.
+// Must be called once per game tick, not per frame.
+func UpdateTAS() {
+	if !TAS() {
+		return
+	}
+	shift := ebiten.IsKeyPressed(ebiten.KeyShift)
+	if inpututil.IsKeyJustPressed(ebiten.KeyF5) {
+		tasPaused = !tasPaused
+		tasStep = false
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF6) && tasPaused {
+		tasStep = true
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF7) && tasFrameIdx >= tasSeekIdx {
+		n := 1
+		if shift {
+			n = tasRewindFrames
+		}
+		tasRewind(tasFrameIdx - n)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF8) {
+		tasRecording = !tasRecording
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF9) {
+		err := tasSave()
+		if err != nil {
+			log.Errorf("could not save tool-assisted demo: %v", err)
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF10) && !shift {
+		tasToggleMacro()
+	}
+	if tasPaused && !tasStep && tasFrameIdx >= tasSeekIdx && !tasRestart && !tasRestoring {
+		if inpututil.IsKeyJustPressed(ebiten.KeyF10) && shift {
+			tasPlayMacro()
+		}
+		for i := range tasImpulseNames {
+			if inpututil.IsKeyJustPressed(ebiten.Key1 + ebiten.Key(i)) {
+				tasEdit(i, shift)
+			}
+		}
+	}
+}
+
+// Paused returns whether the tool-assisted mode holds the game at the current frame.
+// The game must then skip simulating the frame.
+func Paused() bool {
+	return TAS() && !tasSimulating
+}
+
+// RestartRequested returns whether the game has to be restarted from scratch
+// before simulating the current frame.
+func RestartRequested() bool {
+	r := tasRestart
+	tasRestart = false
+	return r
+}
+
+func tasUpdate() {
+	tasSimulating = tasRestart || tasRestoring || tasFrameIdx < tasSeekIdx || !tasPaused || tasStep
+	if !tasSimulating {
+		return
+	}
+	tasStep = false
+	if tasRestoring {
+		// Just the keyframe; the frame itself stays as it is.
+		input.LoadFromDemo(tasFrames[tasFrameIdx].Input)
+		return
+	}
+	if tasFrameIdx < len(tasFrames) && (tasFrameIdx < tasSeekIdx || !tasRecording) {
+		input.LoadFromDemo(tasFrames[tasFrameIdx].Input)
+	} else {
+		state := cloneDemoState(input.SaveToDemo())
+		tasExtend(tasFrameIdx + 1)
+		prev := tasFrames[tasFrameIdx].Input
+		tasFrames[tasFrameIdx].Input = state
+		// Pressing keys while paused must still count as hitting them in the next frame.
+		for i := range tasImpulseNames {
+			tasFixJustHit(tasFrameIdx, i)
+		}
+		if !cmp.Equal(state, prev) {
+			tasStaleIdx = min(tasStaleIdx, tasFrameIdx)
+		}
+		input.LoadFromDemo(state)
+	}
+	// The outcome of the frame is recorded again.
+	frame := &tasFrames[tasFrameIdx]
+	*frame = Frame{
+		Input: frame.Input,
+	}
+}
+
+func tasPostUpdate(playerPos m.Pos) {
+	if !tasSimulating {
+		return
+	}
+	if tasRestoring {
+		// Continue with the saves as they were after the keyframe's frame.
+		tasRestoring = false
+		tasSaveGame = tasLastSaves[tasFrameIdx]
+		tasHaveSaveGame = true
+	} else {
+		tasFrames[tasFrameIdx].PlayerPos = &playerPos
+		tasLastSaves[tasFrameIdx] = tasSaveGame
+	}
+	if tasFrameIdx == tasStaleIdx {
+		tasStaleIdx++
+	}
+	tasFrameIdx++
+	tasSimulating = false
+}
+
+func tasInterceptSaveGame(save *level.SaveGame, lastCheckpoint string) {
+	tasSaveGame = save
+	tasHaveSaveGame = true
+	if !tasSimulating || tasRestoring {
+		return
+	}
+	frame := &tasFrames[tasFrameIdx]
+	frame.SaveGames = append(frame.SaveGames, save.StateHash)
+	frame.SaveCheckpoints = append(frame.SaveCheckpoints, lastCheckpoint)
+}
+
+func tasInterceptPreLoadGame() (*level.SaveGame, bool) {
+	if !tasHaveSaveGame {
+		// Only the very first load comes from disk.
+		return nil, false
+	}
+	if tasSaveGame == nil || tasSaveGame.GameVersion == "" {
+		return nil, true
+	}
+	return tasSaveGame, true
+}
+
+func tasInterceptPostLoadGame(save *level.SaveGame) {
+	if save == nil {
+		save = &level.SaveGame{}
+	}
+	tasSaveGame = save
+	tasHaveSaveGame = true
+	if tasSimulating && !tasRestoring {
+		tasFrames[tasFrameIdx].SaveGame = save
+	}
+}
+
+func tasRecordKeyframe(save *level.SaveGame, checkpointName string, timerStarted bool) {
+	if !tasSimulating || tasRestoring {
+		return
+	}
+	frame := &tasFrames[tasFrameIdx]
+	frame.Keyframe = &Keyframe{
+		SaveGame:     save,
+		Checkpoint:   checkpointName,
+		TimerStarted: timerStarted,
+		SavesBefore:  len(frame.SaveGames),
+	}
+}
+
+func tasRecordKeyframePlayer(player level.PersistentState) {
+	if !tasSimulating || tasRestoring {
+		return
+	}
+	if kf := tasFrames[tasFrameIdx].Keyframe; kf != nil {
+		kf.Player = player
+	}
+}
+
+// tasDraw draws the status of the tool-assisted mode.
+func tasDraw(screen *ebiten.Image) {
+	status := fmt.Sprintf("TAS frame %d/%d", tasFrameIdx, len(tasFrames))
+	switch {
+	case tasFrameIdx < tasSeekIdx:
+		status += fmt.Sprintf(" seeking to %d", tasSeekIdx)
+	case tasPaused:
+		status += " paused"
+	}
+	if tasRecording {
+		status += " recording"
+	}
+	if tasCapturing {
+		status += fmt.Sprintf(" capturing macro from %d", tasMacroStart)
+	} else if len(tasMacro) > 0 {
+		status += fmt.Sprintf(" macro %d frames", len(tasMacro))
+	}
+	next := "next: live input"
+	if tasFrameIdx < len(tasFrames) && tasFrames[tasFrameIdx].Input != nil && !tasRecording {
+		var b strings.Builder
+		b.WriteString("next:")
+		for i, imp := range demoStateImpulses(tasFrames[tasFrameIdx].Input) {
+			s := (*imp).OrEmpty()
+			b.WriteByte(' ')
+			switch {
+			case s.Held:
+				b.WriteByte(tasImpulseNames[i])
+			case s.JustHit:
+				b.WriteByte(tasImpulseNames[i] + 'a' - 'A')
+			default:
+				b.WriteByte('.')
+			}
+			if s.JustHit {
+				b.WriteByte('!')
+			}
+		}
+		next = b.String()
+	}
+	fg := palette.EGA(palette.LightRed, 255)
+	bg := color.NRGBA{R: 0, G: 0, B: 0, A: 192}
+	font.ByName["Small"].Draw(screen, status, m.Pos{X: 0, Y: 24}, font.Left, fg, bg)
+	font.ByName["Small"].Draw(screen, next, m.Pos{X: 0, Y: 36}, font.Left, fg, bg)
+}
@@ -0,0 +1,340 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package demo
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+// tasParse parses the input of a frame as shown in the overlay: an upper case
+// letter is a held impulse, a lower case letter one that is not held, and a
+// following ! marks it as just hit.
+func tasParse(t *testing.T, s string) *input.DemoState {
+	t.Helper()
+	state := &input.DemoState{}
+	imps := demoStateImpulses(state)
+	for s != "" {
+		c := s[0]
+		held := c >= 'A' && c <= 'Z'
+		i := strings.IndexByte(tasImpulseNames, c&^('a'-'A'))
+		if i < 0 {
+			t.Fatalf("invalid impulse %q", c)
+		}
+		s = s[1:]
+		justHit := strings.HasPrefix(s, "!")
+		s = strings.TrimPrefix(s, "!")
+		*imps[i] = &input.ImpulseState{Held: held, JustHit: justHit}
+	}
+	return state
+}
+
+// tasFormat is the inverse of tasParse.
+func tasFormat(state *input.DemoState) string {
+	if state == nil {
+		return ""
+	}
+	var b strings.Builder
+	for i, imp := range demoStateImpulses(state) {
+		s := (*imp).OrEmpty()
+		switch {
+		case s.Held:
+			b.WriteByte(tasImpulseNames[i])
+		case s.JustHit:
+			b.WriteByte(tasImpulseNames[i] + 'a' - 'A')
+		}
+		if s.JustHit {
+			b.WriteByte('!')
+		}
+	}
+	return b.String()
+}
+
+// tasSetTimeline replaces the timeline by frames with the given inputs, all
+// simulated, and pauses before frame idx.
+func tasSetTimeline(t *testing.T, frames []string, idx int) {
+	t.Helper()
+	tasFrames = nil
+	for _, f := range frames {
+		tasFrames = append(tasFrames, Frame{Input: tasParse(t, f)})
+	}
+	tasLastSaves = make([]*level.SaveGame, len(tasFrames))
+	tasFrameIdx = idx
+	tasSeekIdx = idx
+	tasStaleIdx = len(tasFrames)
+	tasPaused = true
+	tasRecording = false
+	tasCapturing = false
+	tasMacro = nil
+}
+
+func tasTimeline() []string {
+	var frames []string
+	for _, f := range tasFrames {
+		frames = append(frames, tasFormat(f.Input))
+	}
+	return frames
+}
+
+func tasTimelineOf(states []*input.DemoState) []string {
+	var frames []string
+	for _, s := range states {
+		frames = append(frames, tasFormat(s))
+	}
+	return frames
+}
+
+func TestTASEdit(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		frames  []string
+		idx     int
+		impulse string
+		justHit bool
+		want    []string
+	}{
+		{
+			name:    "press",
+			frames:  []string{"", "", ""},
+			idx:     1,
+			impulse: "J",
+			want:    []string{"", "J!", ""},
+		},
+		{
+			name:    "press before held",
+			frames:  []string{"", "", "J!", "J"},
+			idx:     1,
+			impulse: "J",
+			want:    []string{"", "J!", "J", "J"},
+		},
+		{
+			name:    "press while held",
+			frames:  []string{"J!", "", "J!"},
+			idx:     1,
+			impulse: "J",
+			want:    []string{"J!", "J", "J"},
+		},
+		{
+			name:    "release",
+			frames:  []string{"J!", "J", "J"},
+			idx:     1,
+			impulse: "J",
+			want:    []string{"J!", "", "J!"},
+		},
+		{
+			name:    "keep tap",
+			frames:  []string{"", "", "j!"},
+			idx:     1,
+			impulse: "J",
+			want:    []string{"", "J!", "j!"},
+		},
+		{
+			name:    "other impulses",
+			frames:  []string{"L!", "L", "L"},
+			idx:     1,
+			impulse: "A",
+			want:    []string{"L!", "LA!", "L"},
+		},
+		{
+			name:    "just hit",
+			frames:  []string{"J!", "J"},
+			idx:     1,
+			impulse: "J",
+			justHit: true,
+			want:    []string{"J!", "J!"},
+		},
+		{
+			name:    "tap",
+			frames:  []string{"", "", ""},
+			idx:     1,
+			impulse: "A",
+			justHit: true,
+			want:    []string{"", "a!", ""},
+		},
+		{
+			name:    "append",
+			frames:  []string{"J!"},
+			idx:     1,
+			impulse: "R",
+			want:    []string{"J!", "R!"},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			tasSetTimeline(t, c.frames, c.idx)
+			tasEdit(strings.Index(tasImpulseNames, c.impulse), c.justHit)
+			if diff := cmp.Diff(c.want, tasTimeline()); diff != "" {
+				t.Errorf("timeline differs (-want +got):\n%v", diff)
+			}
+			if tasStaleIdx != c.idx {
+				t.Errorf("got stale index %d, want %d", tasStaleIdx, c.idx)
+			}
+		})
+	}
+}
+
+func TestTASMacro(t *testing.T) {
+	tasSetTimeline(t, []string{"R!", "R", "RJ!", "R"}, 1)
+	tasToggleMacro()
+	tasFrameIdx = 3
+	tasToggleMacro()
+	if diff := cmp.Diff([]string{"R", "RJ!"}, tasTimelineOf(tasMacro)); diff != "" {
+		t.Fatalf("macro differs (-want +got):\n%v", diff)
+	}
+	macro := tasMacro
+
+	t.Run("append", func(t *testing.T) {
+		tasSetTimeline(t, []string{"R!", "R", "RJ!", "R"}, 4)
+		tasMacro = macro
+		tasPlayMacro()
+		if diff := cmp.Diff([]string{"R!", "R", "RJ!", "R", "R", "RJ!"}, tasTimeline()); diff != "" {
+			t.Errorf("timeline differs (-want +got):\n%v", diff)
+		}
+		if tasSeekIdx != 6 || tasStaleIdx != 4 {
+			t.Errorf("got seek index %d and stale index %d, want 6 and 4", tasSeekIdx, tasStaleIdx)
+		}
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		tasSetTimeline(t, []string{"", "", "J!", "J"}, 0)
+		tasMacro = macro
+		tasPlayMacro()
+		if diff := cmp.Diff([]string{"R!", "RJ!", "J", "J"}, tasTimeline()); diff != "" {
+			t.Errorf("timeline differs (-want +got):\n%v", diff)
+		}
+		if tasSeekIdx != 2 || tasStaleIdx != 0 {
+			t.Errorf("got seek index %d and stale index %d, want 2 and 0", tasSeekIdx, tasStaleIdx)
+		}
+		// Editing the timeline must not change the macro.
+		tasEdit(strings.Index(tasImpulseNames, "R"), false)
+		if diff := cmp.Diff([]string{"R", "RJ!"}, tasTimelineOf(macro)); diff != "" {
+			t.Errorf("macro changed (-want +got):\n%v", diff)
+		}
+	})
+
+	t.Run("backwards", func(t *testing.T) {
+		tasSetTimeline(t, []string{"", "", ""}, 2)
+		tasMacro = macro
+		tasToggleMacro()
+		tasFrameIdx = 1
+		tasToggleMacro()
+		if diff := cmp.Diff([]string{"R", "RJ!"}, tasTimelineOf(tasMacro)); diff != "" {
+			t.Errorf("macro changed (-want +got):\n%v", diff)
+		}
+	})
+}
+
+func TestTASSave(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "tas.dem")
+	err := flag.Set("cheat_tas", name)
+	if err != nil {
+		t.Fatalf("could not set cheat_tas: %v", err)
+	}
+	defer flag.Set("cheat_tas", "")
+	start := &level.SaveGame{StateHash: 1}
+	final := &level.SaveGame{StateHash: 2}
+	simulated := func(i int, input string) Frame {
+		return Frame{
+			Input:     tasParse(t, input),
+			PlayerPos: &m.Pos{X: i},
+			SaveGames: []uint64{uint64(i)},
+		}
+	}
+	for _, c := range []struct {
+		name  string
+		stale int
+		want  []Frame
+	}{
+		{
+			name:  "up to date",
+			stale: 3,
+			want: []Frame{
+				{SaveGame: start, Input: tasParse(t, "R!"), PlayerPos: &m.Pos{X: 0}, SaveGames: []uint64{0}},
+				simulated(1, "R"),
+				simulated(2, "RJ!"),
+				{FinalSaveGame: final},
+			},
+		},
+		{
+			name:  "stale",
+			stale: 1,
+			want: []Frame{
+				{SaveGame: start, Input: tasParse(t, "R!"), PlayerPos: &m.Pos{X: 0}, SaveGames: []uint64{0}},
+				{Input: tasParse(t, "R")},
+				{Input: tasParse(t, "RJ!")},
+			},
+		},
+		{
+			name:  "all stale",
+			stale: 0,
+			want: []Frame{
+				{SaveGame: start, Input: tasParse(t, "R!")},
+				{Input: tasParse(t, "R")},
+				{Input: tasParse(t, "RJ!")},
+			},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			tasSetTimeline(t, nil, 3)
+			tasFrames = []Frame{
+				simulated(0, "R!"),
+				simulated(1, "R"),
+				simulated(2, "RJ!"),
+			}
+			tasFrames[0].SaveGame = start
+			tasFrames[2].Keyframe = &Keyframe{SaveGame: final, Checkpoint: "checkpoint"}
+			c.want[2].Keyframe = nil
+			if c.stale == 3 {
+				c.want[2].Keyframe = tasFrames[2].Keyframe
+			}
+			tasLastSaves = []*level.SaveGame{start, start, final}
+			tasStaleIdx = c.stale
+			err := tasSave()
+			if err != nil {
+				t.Fatalf("could not save: %v", err)
+			}
+			f, err := vfs.OSOpen(vfs.WorkDir, name)
+			if err != nil {
+				t.Fatalf("could not open saved demo: %v", err)
+			}
+			defer f.Close()
+			r, err := NewFrameReader(f)
+			if err != nil {
+				t.Fatalf("could not read saved demo: %v", err)
+			}
+			defer r.Close()
+			var got []Frame
+			for r.More() {
+				var frame Frame
+				err := r.Read(&frame)
+				if err != nil {
+					t.Fatalf("could not decode frame %d: %v", len(got), err)
+				}
+				got = append(got, frame)
+			}
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Errorf("saved demo differs (-want +got):\n%v", diff)
+			}
+		})
+	}
+}
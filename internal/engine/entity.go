@@ -31,6 +31,7 @@ type Entity struct {
 	// Info needed for management.
 	Incarnation      EntityIncarnation
 	SpawnTilesGrowth m.Delta
+	spawnProps       *level.SpawnableProps // Only used by the entity inspector.
 
 	// Info needed for gameplay.
 	contents     level.Contents
@@ -110,6 +111,11 @@ func RegisterEntityType(t EntityImpl) {
 	log.Debugf("registered entity type %q", typeName)
 }
 
+// IsEntityType returns whether an entity type of the given name has been registered.
+func IsEntityType(typeName string) bool {
+	return entityTypes[typeName] != nil
+}
+
 // Precache all entities.
 func precacheEntities(lvl *level.Level) error {
 	var err error
@@ -157,6 +163,7 @@ func (w *World) spawnAt(sp *level.SpawnableProps, rect m.Rect, transform, tInv m
 		Rect:             rect,
 		Orientation:      tInv.Concat(sp.Orientation),
 		SpawnTilesGrowth: sp.SpawnTilesGrowth,
+		spawnProps:       sp,
 	}
 	e.Alpha = 1.0
 	e.ColorMod[0] = 1.0
@@ -296,4 +303,10 @@ type PlayerEntityImpl interface {
 
 	// Respawned() notifies the entity that the world respawned it.
 	Respawned()
+
+	// KeyframeState returns the state a demo keyframe needs to restore the player at the end of a frame.
+	KeyframeState() level.PersistentState
+
+	// LoadKeyframeState restores a state returned by KeyframeState.
+	LoadKeyframeState(state level.PersistentState) error
 }
@@ -0,0 +1,167 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/divVerent/aaaaxy/internal/demo"
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+var (
+	debugHotReload         = flag.Bool("debug_hot_reload", false, "watch the level and tileset files and reload the level while playing when they change; new images still require a restart")
+	debugHotReloadInterval = flag.Int("debug_hot_reload_interval", 30, "number of frames between checks for changed level files")
+)
+
+// hotReloadFile is a file watched for hot reloading.
+type hotReloadFile struct {
+	purpose, name string
+}
+
+var (
+	hotReloadModTimes map[hotReloadFile]time.Time
+	hotReloadFrames   int
+)
+
+// hotReloadFiles lists the files the current level is made of.
+func hotReloadFiles() ([]hotReloadFile, error) {
+	files := []hotReloadFile{
+		{"maps", LevelName() + ".tmx"},
+		{"generated", LevelName() + ".cp.json"},
+	}
+	tiles, err := vfs.ReadDir("tiles")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range tiles {
+		if strings.HasSuffix(name, ".tsx") {
+			files = append(files, hotReloadFile{"tiles", name})
+		}
+	}
+	return files, nil
+}
+
+// scanHotReloadFiles returns the modification times of all watched files.
+// Files that cannot be found get the zero time, so them appearing is a change too.
+func scanHotReloadFiles() (map[hotReloadFile]time.Time, error) {
+	files, err := hotReloadFiles()
+	if err != nil {
+		return nil, err
+	}
+	modTimes := make(map[hotReloadFile]time.Time, len(files))
+	for _, f := range files {
+		t, _ := vfs.ModTime(f.purpose, f.name)
+		modTimes[f] = t
+	}
+	return modTimes, nil
+}
+
+// hotReloadChanged returns whether any watched file changed since the last call.
+func hotReloadChanged() bool {
+	if !*debugHotReload {
+		return false
+	}
+	if demo.Playing() {
+		return false
+	}
+	if name, _ := demo.Recorded(); name != "" {
+		return false
+	}
+	hotReloadFrames++
+	if hotReloadFrames < *debugHotReloadInterval {
+		return false
+	}
+	hotReloadFrames = 0
+	modTimes, err := scanHotReloadFiles()
+	if err != nil {
+		log.Errorf("could not scan level files for hot reload: %v", err)
+		return false
+	}
+	prev := hotReloadModTimes
+	hotReloadModTimes = modTimes
+	if prev == nil {
+		// First scan; nothing to compare with.
+		return false
+	}
+	changed := false
+	for f, t := range modTimes {
+		if !prev[f].Equal(t) {
+			log.Infof("level file %v/%v changed", f.purpose, f.name)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// hotReload reloads the level from disk and puts the player back at the
+// current checkpoint with the current save state.
+// On failure, the world keeps running on the previous level.
+func (w *World) hotReload() error {
+	save, err := w.Level.SaveGame()
+	if err != nil {
+		return fmt.Errorf("could not save current state: %w", err)
+	}
+	checkpoint := w.PlayerState.LastCheckpoint()
+	timerStarted := w.TimerStarted
+
+	// Editing the map usually invalidates the checkpoint locations until they are regenerated.
+	lvl, err := newLevelLoader(LevelName()).SkipComparingCheckpointLocations(true).FallbackCheckpointLocations(true).Load()
+	if err != nil {
+		return fmt.Errorf("could not load level: %w", err)
+	}
+	err = precacheEntities(lvl)
+	if err != nil {
+		return fmt.Errorf("could not precache entities: %w", err)
+	}
+	prevLevel := loadLevelCache
+	loadLevelCache = lvl
+
+	restart := func() error {
+		err := w.Init(w.saveState)
+		if err != nil {
+			return err
+		}
+		// Entities that no longer exist simply lose their state.
+		err = w.Level.LoadGame(save)
+		if err != nil {
+			return err
+		}
+		w.PlayerState.Init()
+		w.TimerStarted = timerStarted
+		cp := checkpoint
+		if w.Level.Checkpoints[cp] == nil {
+			log.Warningf("checkpoint %q no longer exists, respawning at the start", cp)
+			cp = ""
+		}
+		return w.RespawnPlayer(cp, true)
+	}
+	err = restart()
+	if err != nil {
+		// Back to the old level; it was working before.
+		loadLevelCache = prevLevel
+		restoreErr := restart()
+		if restoreErr != nil {
+			return fmt.Errorf("could not restore previous level after %v: %w", err, restoreErr)
+		}
+		return fmt.Errorf("could not start new level: %w", err)
+	}
+	log.Infof("hot reloaded level %v", LevelName())
+	return nil
+}
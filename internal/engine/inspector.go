@@ -0,0 +1,355 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	"image/color"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/log"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/palette"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+var (
+	cheatEntityInspector = flag.Bool("cheat_entity_inspector", false, "click entities to inspect them and edit their persistent state")
+)
+
+const (
+	inspectorWidth      = 256
+	inspectorLineHeight = 11
+	inspectorMaxValue   = 40
+)
+
+// stateSetter is implemented by entities that can be toggled by targets.
+// Same as the interface used by mixins.SetStateOfTarget.
+type stateSetter interface {
+	SetState(originator, predecessor *Entity, state bool)
+}
+
+// inspectorLine is a line of the inspector panel.
+type inspectorLine struct {
+	text string
+	// action is run when clicking the line, if set.
+	action func()
+}
+
+// inspector is the state of the entity inspector overlay.
+type inspector struct {
+	// selected is the entity currently shown.
+	selected EntityIncarnation
+	// lines is the panel content, rebuilt every frame.
+	lines []inspectorLine
+	// scroll is the index of the first line shown.
+	scroll int
+
+	// editing is set while a PersistentState value is being typed.
+	editing bool
+	// editKey is the key being edited; empty when adding a new key=value pair.
+	editKey string
+	// editText is the text typed so far.
+	editText []rune
+}
+
+// inspectorRect returns the screen area of the inspector panel.
+func inspectorRect() m.Rect {
+	return m.Rect{
+		Origin: m.Pos{X: GameWidth - inspectorWidth, Y: 0},
+		Size:   m.Delta{DX: inspectorWidth, DY: GameHeight},
+	}
+}
+
+// inspectorEntity returns the selected entity, or nil if it is not loaded.
+func (w *World) inspectorEntity() *Entity {
+	if !w.inspector.selected.IsValid() {
+		return nil
+	}
+	var found *Entity
+	w.entities.forEach(func(e *Entity) error {
+		if e.Incarnation == w.inspector.selected {
+			found = e
+		}
+		return nil
+	})
+	return found
+}
+
+// entityAt returns the topmost entity at the given world position.
+func (w *World) entityAt(pos m.Pos) *Entity {
+	var found *Entity
+	w.entities.forEach(func(e *Entity) error {
+		if !e.Rect.DeltaPos(pos).IsZero() {
+			return nil
+		}
+		if found == nil || e.zIndex >= found.zIndex {
+			found = e
+		}
+		return nil
+	})
+	return found
+}
+
+// updateInspector handles input for the entity inspector.
+// Returns whether text is being typed, in which case the game should pause.
+func (w *World) updateInspector() bool {
+	if !*cheatEntityInspector {
+		return false
+	}
+	in := &w.inspector
+	if in.editing {
+		w.updateInspectorEdit()
+		w.buildInspectorLines()
+		return true
+	}
+	if _, dy := ebiten.Wheel(); dy != 0 {
+		if dy > 0 {
+			in.scroll--
+		} else {
+			in.scroll++
+		}
+	}
+	if pos, ok := input.ClickPos(); ok {
+		panel := inspectorRect()
+		if in.selected.IsValid() && panel.DeltaPos(pos).IsZero() {
+			i := (pos.Y-panel.Origin.Y)/inspectorLineHeight + in.scroll
+			if i >= 0 && i < len(in.lines) && in.lines[i].action != nil {
+				in.lines[i].action()
+			}
+		} else {
+			scrollDelta := m.Pos{X: GameWidth / 2, Y: GameHeight / 2}.Delta(w.scrollPos)
+			e := w.entityAt(pos.Sub(scrollDelta))
+			if e == nil {
+				in.selected = EntityIncarnation{}
+			} else {
+				in.selected = e.Incarnation
+			}
+			in.scroll = 0
+		}
+	}
+	w.buildInspectorLines()
+	return in.editing
+}
+
+// InspectorEditing returns whether text is being typed into the entity inspector.
+// While it is, Exit cancels editing instead of opening the menu.
+func (w *World) InspectorEditing() bool {
+	return w.inspector.editing
+}
+
+// updateInspectorEdit handles typing a PersistentState value.
+func (w *World) updateInspectorEdit() {
+	in := &w.inspector
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		in.editing = false
+		return
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		in.editing = false
+		e := w.inspectorEntity()
+		if e == nil || e.spawnProps == nil {
+			return
+		}
+		key, value := in.editKey, string(in.editText)
+		if key == "" {
+			var found bool
+			key, value, found = strings.Cut(value, "=")
+			if !found || key == "" {
+				return
+			}
+		}
+		if value == "" {
+			propmap.Delete(e.spawnProps.PersistentState, key)
+			log.Infof("inspector: deleted persistent state %q of entity %v", key, e.Incarnation)
+		} else {
+			propmap.Set(e.spawnProps.PersistentState, key, value)
+			log.Infof("inspector: set persistent state %q of entity %v to %q", key, e.Incarnation, value)
+		}
+		return
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(in.editText) > 0 {
+		in.editText = in.editText[:len(in.editText)-1]
+	}
+	for _, r := range ebiten.AppendInputChars(nil) {
+		if r >= ' ' && r != utf8.RuneError {
+			in.editText = append(in.editText, r)
+		}
+	}
+}
+
+// inspectorValue formats a field value for display.
+func inspectorValue(v reflect.Value) string {
+	var s string
+	switch val := v.Interface().(type) {
+	case *Entity:
+		if val == nil {
+			s = "<nil>"
+		} else {
+			s = fmt.Sprintf("entity %v", val.Incarnation)
+		}
+	default:
+		s = fmt.Sprintf("%+v", val)
+	}
+	if utf8.RuneCountInString(s) > inspectorMaxValue {
+		s = string([]rune(s)[:inspectorMaxValue]) + "..."
+	}
+	return s
+}
+
+// inspectorImplFields lists the exported fields of an entity implementation.
+func inspectorImplFields(impl EntityImpl) []string {
+	v := reflect.ValueOf(impl)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	worldType := reflect.TypeOf((*World)(nil))
+	var out []string
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if !f.IsExported() || f.Type == worldType {
+			continue
+		}
+		out = append(out, fmt.Sprintf("  %s: %s", f.Name, inspectorValue(v.Field(i))))
+	}
+	return out
+}
+
+// buildInspectorLines regenerates the panel content of the selected entity.
+func (w *World) buildInspectorLines() {
+	in := &w.inspector
+	in.lines = in.lines[:0]
+	e := w.inspectorEntity()
+	if e == nil {
+		in.selected = EntityIncarnation{}
+		in.editing = false
+		return
+	}
+	add := func(action func(), format string, args ...interface{}) {
+		in.lines = append(in.lines, inspectorLine{text: fmt.Sprintf(format, args...), action: action})
+	}
+	typeName := reflect.TypeOf(e.Impl).String()
+	if t := reflect.TypeOf(e.Impl); t.Kind() == reflect.Pointer {
+		typeName = t.Elem().Name()
+	}
+	add(nil, "entity inspector (cheat)")
+	add(nil, "incarnation: %v", e.Incarnation)
+	add(nil, "type: %s", typeName)
+	if e.name != "" {
+		add(nil, "name: %s", e.name)
+	}
+	add(nil, "rect: %v", e.Rect)
+	add(nil, "z index: %d", e.zIndex)
+	add(nil, "contents: player solid=%v object solid=%v opaque=%v", e.contents.PlayerSolid(), e.contents.ObjectSolid(), e.contents.Opaque())
+	if e.spawnProps != nil {
+		add(nil, "properties:")
+		var props []string
+		propmap.ForEach(e.spawnProps.Properties, func(k, v string) error {
+			props = append(props, fmt.Sprintf("  %s = %s", k, v))
+			return nil
+		})
+		sort.Strings(props)
+		for _, p := range props {
+			add(nil, "%s", p)
+		}
+		add(nil, "persistent state (click to edit, Enter to set, Esc to cancel):")
+		var keys []string
+		propmap.ForEach(e.spawnProps.PersistentState, func(k, _ string) error {
+			keys = append(keys, k)
+			return nil
+		})
+		sort.Strings(keys)
+		for _, k := range keys {
+			key := k
+			value := propmap.StringOr(e.spawnProps.PersistentState, key, "")
+			if in.editing && in.editKey == key {
+				add(nil, "  %s = %s_", key, string(in.editText))
+				continue
+			}
+			add(func() {
+				in.editing = true
+				in.editKey = key
+				in.editText = []rune(value)
+			}, "  %s = %s", key, value)
+		}
+		if in.editing && in.editKey == "" {
+			add(nil, "  %s_", string(in.editText))
+		} else {
+			add(func() {
+				in.editing = true
+				in.editKey = ""
+				in.editText = nil
+			}, "  + add key=value")
+		}
+	}
+	if setter, ok := e.Impl.(stateSetter); ok {
+		add(nil, "targets:")
+		for _, state := range []bool{true, false} {
+			state := state
+			add(func() {
+				log.Infof("inspector: setting state of entity %v to %v", e.Incarnation, state)
+				setter.SetState(w.Player, w.Player, state)
+			}, "  SetState %v", state)
+		}
+	}
+	add(nil, "fields:")
+	for _, f := range inspectorImplFields(e.Impl) {
+		add(nil, "%s", f)
+	}
+	in.scroll = max(min(in.scroll, len(in.lines)-1), 0)
+}
+
+// drawInspector draws the entity inspector overlay.
+func (r *renderer) drawInspector(screen *ebiten.Image, scrollDelta m.Delta) {
+	if !*cheatEntityInspector {
+		return
+	}
+	in := &r.world.inspector
+	e := r.world.inspectorEntity()
+	if e == nil {
+		return
+	}
+	highlight := palette.EGA(palette.Yellow, 255)
+	vector.StrokeRect(screen, float32(e.Rect.Origin.X+scrollDelta.DX), float32(e.Rect.Origin.Y+scrollDelta.DY), float32(e.Rect.Size.DX), float32(e.Rect.Size.DY), 1, highlight, false)
+	panel := inspectorRect()
+	vector.DrawFilledRect(screen, float32(panel.Origin.X), float32(panel.Origin.Y), float32(panel.Size.DX), float32(panel.Size.DY), color.NRGBA{R: 0, G: 0, B: 0, A: 192}, false)
+	for i := in.scroll; i < len(in.lines); i++ {
+		y := panel.Origin.Y + (i-in.scroll+1)*inspectorLineHeight - 2
+		if y > panel.Origin.Y+panel.Size.DY {
+			break
+		}
+		fg := palette.EGA(palette.LightGrey, 255)
+		if in.lines[i].action != nil {
+			fg = palette.EGA(palette.LightCyan, 255)
+		}
+		if i == 0 {
+			fg = palette.EGA(palette.LightRed, 255)
+		}
+		font.ByName["Small"].Draw(screen, in.lines[i].text, m.Pos{X: panel.Origin.X + 2, Y: y}, font.Left, fg, color.Transparent)
+	}
+}
@@ -145,38 +145,114 @@ func setGeoM(geoM *ebiten.GeoM, pos m.Pos, resize bool, entSize, imgSize m.Delta
 	}
 }
 
-func (r *renderer) drawTiles(screen *ebiten.Image, scrollDelta m.Delta) {
-	r.world.forEachTile(func(i int, tile *level.Tile) {
-		if tile.ImageSrc == "" {
-			return
-		}
-		pos := r.world.tilePos(i)
-		screenPos := pos.Mul(level.TileSize).Add(scrollDelta)
-		img, err := image.Load("tiles", tile.ImageSrc)
+func (r *renderer) drawBackdrops(screen *ebiten.Image) {
+	for _, il := range r.world.Level.ImageLayers {
+		img, err := image.Load(il.Purpose, il.ImageSrc)
 		if err != nil {
-			log.Errorf("could not load already cached image %q for tile: %v", tile.ImageSrc, err)
-			return
+			log.Errorf("could not load already cached image %q for image layer %q: %v", il.ImageSrc, il.Name, err)
+			continue
 		}
+		x := float64(il.Offset.DX) - float64(r.world.scrollPos.X)*il.Parallax
+		y := float64(il.Offset.DY) - float64(r.world.scrollPos.Y)*il.Parallax
 		if r.world.GlobalColorMSet {
 			opts := colorm.DrawImageOptions{
-				// Note: could be BlendCopy, but that can't be merged with entities pass.
 				Blend:  ebiten.BlendSourceOver,
 				Filter: ebiten.FilterNearest,
 			}
-			setGeoM(&opts.GeoM, screenPos, false, m.Delta{DX: level.TileSize, DY: level.TileSize}, m.Delta{DX: level.TileSize, DY: level.TileSize}, tile.Orientation, 1.0, 0.0)
-			colorm.DrawImage(screen, img, r.world.GlobalColorM, &opts)
+			opts.GeoM.Translate(math.Round(x), math.Round(y))
+			var colorM colorm.ColorM
+			colorM.Scale(1.0, 1.0, 1.0, il.Alpha)
+			colorM.Concat(r.world.GlobalColorM)
+			colorm.DrawImage(screen, img, colorM, &opts)
 		} else {
 			opts := ebiten.DrawImageOptions{
-				// Note: could be BlendCopy, but that can't be merged with entities pass.
 				Blend:  ebiten.BlendSourceOver,
 				Filter: ebiten.FilterNearest,
 			}
-			setGeoM(&opts.GeoM, screenPos, false, m.Delta{DX: level.TileSize, DY: level.TileSize}, m.Delta{DX: level.TileSize, DY: level.TileSize}, tile.Orientation, 1.0, 0.0)
+			opts.GeoM.Translate(math.Round(x), math.Round(y))
+			opts.ColorScale.ScaleAlpha(float32(il.Alpha))
 			screen.DrawImage(img, &opts)
 		}
+	}
+}
+
+func (r *renderer) drawTileImage(screen *ebiten.Image, screenPos m.Pos, imgSrc string, orientation m.Orientation, alpha float64) {
+	if imgSrc == "" {
+		return
+	}
+	img, err := image.Load("tiles", imgSrc)
+	if err != nil {
+		log.Errorf("could not load already cached image %q for tile: %v", imgSrc, err)
+		return
+	}
+	if r.world.GlobalColorMSet {
+		opts := colorm.DrawImageOptions{
+			// Note: could be BlendCopy, but that can't be merged with entities pass.
+			Blend:  ebiten.BlendSourceOver,
+			Filter: ebiten.FilterNearest,
+		}
+		setGeoM(&opts.GeoM, screenPos, false, m.Delta{DX: level.TileSize, DY: level.TileSize}, m.Delta{DX: level.TileSize, DY: level.TileSize}, orientation, 1.0, 0.0)
+		colorM := r.world.GlobalColorM
+		if alpha != 1.0 {
+			colorM = colorm.ColorM{}
+			colorM.Scale(1.0, 1.0, 1.0, alpha)
+			colorM.Concat(r.world.GlobalColorM)
+		}
+		colorm.DrawImage(screen, img, colorM, &opts)
+	} else {
+		opts := ebiten.DrawImageOptions{
+			// Note: could be BlendCopy, but that can't be merged with entities pass.
+			Blend:  ebiten.BlendSourceOver,
+			Filter: ebiten.FilterNearest,
+		}
+		setGeoM(&opts.GeoM, screenPos, false, m.Delta{DX: level.TileSize, DY: level.TileSize}, m.Delta{DX: level.TileSize, DY: level.TileSize}, orientation, 1.0, 0.0)
+		opts.ColorScale.ScaleAlpha(float32(alpha))
+		screen.DrawImage(img, &opts)
+	}
+}
+
+// drawTiles draws the tiles and their decoration layers.
+// If foreground is set, only the foreground layers, which go on top of entities, are drawn.
+func (r *renderer) drawTiles(screen *ebiten.Image, scrollDelta m.Delta, foreground bool) {
+	r.world.forEachTile(func(i int, tile *level.Tile) {
+		pos := r.world.tilePos(i)
+		screenPos := pos.Mul(level.TileSize).Add(scrollDelta)
+		if foreground {
+			for _, o := range tile.Overlays {
+				if o.Layer.Foreground {
+					r.drawTileImage(screen, screenPos, o.ImageSrc, o.Orientation, o.Layer.Alpha)
+				}
+			}
+			return
+		}
+		// Overlays are sorted by Z, and the collision layer goes at Z 0.
+		drewTile := false
+		for _, o := range tile.Overlays {
+			if o.Layer.Foreground {
+				continue
+			}
+			if o.Layer.Z > 0 && !drewTile {
+				r.drawTileImage(screen, screenPos, tile.ImageSrc, tile.Orientation, 1.0)
+				drewTile = true
+			}
+			r.drawTileImage(screen, screenPos, o.ImageSrc, o.Orientation, o.Layer.Alpha)
+		}
+		if !drewTile {
+			r.drawTileImage(screen, screenPos, tile.ImageSrc, tile.Orientation, 1.0)
+		}
 	})
 }
 
+// hasForegroundLayers returns whether any decoration layer is drawn on top of entities.
+func (r *renderer) hasForegroundLayers() bool {
+	for _, tl := range r.world.Level.TileLayers {
+		if tl.Foreground {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *renderer) drawEntities(screen *ebiten.Image, scrollDelta m.Delta, blurFactor float64) {
 	minZ, maxZ := zBounds(len(r.world.entitiesByZ))
 	for z := minZ; z <= maxZ; z++ {
@@ -327,6 +403,8 @@ func (r *renderer) drawDebug(screen *ebiten.Image, scrollDelta m.Delta) {
 		}
 		DrawPolyLine(screen, 3, adjustedPolygon, r.whiteImage, palette.EGA(palette.Blue, 255), &texM, &ebiten.DrawTrianglesOptions{})
 	}
+
+	r.drawInspector(screen, scrollDelta)
 }
 
 func (r *renderer) offscreenDrawDest(screen *ebiten.Image) *ebiten.Image {
@@ -471,12 +549,22 @@ func (r *renderer) Draw(screen *ebiten.Image, blurFactor float64) {
 	timing.Section("fill")
 	dest.Fill(color.Gray{0})
 
+	if len(r.world.Level.ImageLayers) != 0 {
+		timing.Section("backdrops")
+		r.drawBackdrops(dest)
+	}
+
 	timing.Section("tiles")
-	r.drawTiles(dest, scrollDelta)
+	r.drawTiles(dest, scrollDelta, false)
 
 	timing.Section("entities")
 	r.drawEntities(dest, scrollDelta, blurFactor)
 
+	if r.hasForegroundLayers() {
+		timing.Section("foreground_tiles")
+		r.drawTiles(dest, scrollDelta, true)
+	}
+
 	if *drawVisibilityMask {
 		timing.Section("visibility_mask")
 		r.drawVisibilityMask(screen, dest, scrollDelta)
@@ -0,0 +1,183 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+// SaveSlot is a named save state slot.
+type SaveSlot struct {
+	// Index is the save state index as used by SaveName and -save_state.
+	Index int
+	Name  string
+}
+
+// defaultSaveSlotNames are the names of the slots that always used to exist.
+var defaultSaveSlotNames = []string{"A", "4", "X", "Y"}
+
+// SaveSlotsName returns the state file name of the save slot list.
+func SaveSlotsName() string {
+	return stateFilePrefix() + "save-slots.json"
+}
+
+func defaultSaveSlots() []SaveSlot {
+	var slots []SaveSlot
+	for i, name := range defaultSaveSlotNames {
+		slots = append(slots, SaveSlot{Index: i, Name: name})
+	}
+	return slots
+}
+
+// SaveSlots returns all known save slots, sorted by index.
+func SaveSlots() ([]SaveSlot, error) {
+	data, err := vfs.ReadState(vfs.SavedGames, SaveSlotsName())
+	if errors.Is(err, os.ErrNotExist) {
+		return defaultSaveSlots(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read save slots: %w", err)
+	}
+	var slots []SaveSlot
+	err = json.Unmarshal(data, &slots)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse save slots: %w", err)
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i].Index < slots[j].Index
+	})
+	return slots, nil
+}
+
+func writeSaveSlots(slots []SaveSlot) error {
+	data, err := json.MarshalIndent(slots, "", "\t")
+	if err != nil {
+		return fmt.Errorf("could not marshal save slots: %w", err)
+	}
+	err = vfs.WriteState(vfs.SavedGames, SaveSlotsName(), data)
+	if err != nil {
+		return fmt.Errorf("could not write save slots: %w", err)
+	}
+	return nil
+}
+
+// SaveSlotName returns the display name of the given save state index.
+func SaveSlotName(idx int) string {
+	slots, err := SaveSlots()
+	if err == nil {
+		for _, slot := range slots {
+			if slot.Index == idx {
+				return slot.Name
+			}
+		}
+	}
+	if idx >= 0 && idx < len(defaultSaveSlotNames) {
+		return defaultSaveSlotNames[idx]
+	}
+	return fmt.Sprint(idx)
+}
+
+// NewSaveSlot creates a new empty save slot with the given name.
+func NewSaveSlot(name string) (SaveSlot, error) {
+	slots, err := SaveSlots()
+	if err != nil {
+		return SaveSlot{}, err
+	}
+	idx := len(defaultSaveSlotNames)
+	for _, slot := range slots {
+		if slot.Index >= idx {
+			idx = slot.Index + 1
+		}
+	}
+	slot := SaveSlot{Index: idx, Name: name}
+	if slot.Name == "" {
+		slot.Name = fmt.Sprint(idx)
+	}
+	// Make sure no stale save game shows up in the new slot.
+	err = vfs.RemoveState(vfs.SavedGames, SaveName(idx))
+	if err != nil {
+		return SaveSlot{}, fmt.Errorf("could not clear save slot %d: %w", idx, err)
+	}
+	err = writeSaveSlots(append(slots, slot))
+	if err != nil {
+		return SaveSlot{}, err
+	}
+	return slot, nil
+}
+
+// RenameSaveSlot renames the given save slot, adding it to the list if needed.
+func RenameSaveSlot(idx int, name string) error {
+	slots, err := SaveSlots()
+	if err != nil {
+		return err
+	}
+	found := false
+	for i := range slots {
+		if slots[i].Index == idx {
+			slots[i].Name = name
+			found = true
+		}
+	}
+	if !found {
+		slots = append(slots, SaveSlot{Index: idx, Name: name})
+	}
+	return writeSaveSlots(slots)
+}
+
+// CopySaveSlot creates a new save slot with the given name holding a copy of the save game in slot idx.
+func CopySaveSlot(idx int, name string) (SaveSlot, error) {
+	data, err := vfs.ReadState(vfs.SavedGames, SaveName(idx))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return SaveSlot{}, fmt.Errorf("could not read save slot %d: %w", idx, err)
+	}
+	slot, err := NewSaveSlot(name)
+	if err != nil {
+		return SaveSlot{}, err
+	}
+	if data == nil {
+		// Copying an empty slot yields an empty slot.
+		return slot, nil
+	}
+	err = vfs.WriteState(vfs.SavedGames, SaveName(slot.Index), data)
+	if err != nil {
+		return SaveSlot{}, fmt.Errorf("could not write save slot %d: %w", slot.Index, err)
+	}
+	return slot, nil
+}
+
+// DeleteSaveSlot deletes the save game in the given slot and removes the slot from the list.
+func DeleteSaveSlot(idx int) error {
+	err := vfs.RemoveState(vfs.SavedGames, SaveName(idx))
+	if err != nil {
+		return fmt.Errorf("could not delete save slot %d: %w", idx, err)
+	}
+	slots, err := SaveSlots()
+	if err != nil {
+		return err
+	}
+	kept := slots[:0]
+	for _, slot := range slots {
+		if slot.Index != idx {
+			kept = append(kept, slot)
+		}
+	}
+	return writeSaveSlots(kept)
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/playerstate"
+)
+
+var (
+	// personalBest is kept up to date with every save.
+	personalBest *playerstate.PersonalBest
+	// personalBestBaseline is the personal best at game start, to compare against.
+	personalBestBaseline *playerstate.PersonalBest
+)
+
+// PersonalBestName returns the state file name of the personal best.
+func PersonalBestName() string {
+	return stateFilePrefix() + "splits.json"
+}
+
+func loadPersonalBest() {
+	if personalBest != nil {
+		return
+	}
+	var err error
+	personalBest, err = playerstate.LoadPersonalBest(PersonalBestName())
+	if err != nil {
+		log.Errorf("could not load personal best, starting over: %v", err)
+		personalBest = &playerstate.PersonalBest{}
+	}
+	personalBestBaseline = personalBest.Clone()
+}
+
+// PersonalBestBaseline returns the personal best as of game start.
+func PersonalBestBaseline() *playerstate.PersonalBest {
+	loadPersonalBest()
+	return personalBestBaseline
+}
+
+// updatePersonalBest merges the current run into the personal best file.
+func (w *World) updatePersonalBest() {
+	if w.PlayerState.Edited() {
+		// Edited save games are not real runs.
+		return
+	}
+	loadPersonalBest()
+	if !personalBest.Update(&w.PlayerState) {
+		return
+	}
+	err := personalBest.Save(PersonalBestName())
+	if err != nil {
+		log.Errorf("could not save personal best: %v", err)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"slices"
 	"sort"
 	"strings"
 
@@ -29,6 +30,7 @@ import (
 	"github.com/divVerent/aaaaxy/internal/centerprint"
 	"github.com/divVerent/aaaaxy/internal/demo"
 	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/input"
 	"github.com/divVerent/aaaaxy/internal/level"
 	"github.com/divVerent/aaaaxy/internal/locale"
 	"github.com/divVerent/aaaaxy/internal/log"
@@ -120,6 +122,23 @@ type World struct {
 
 	// Name of the save state.
 	saveState int
+
+	// inspector is the state of -cheat_entity_inspector.
+	inspector inspector
+}
+
+// frameObserver is called after every frame the world updates, if set.
+var frameObserver func(w *World)
+
+// ObserveFrames sets a function to be called after every frame the world updates.
+// Tools use this to collect statistics during demo playback.
+func ObserveFrames(f func(w *World)) {
+	frameObserver = f
+}
+
+// Respawned returns whether the player got respawned by an entity during the current frame.
+func (w *World) Respawned() bool {
+	return w.respawned
 }
 
 // Initialized returns whether Init() has been called on this World before.
@@ -232,6 +251,19 @@ func SwitchLevel(name string) error {
 
 // LevelDescription returns the user visible name of the given level.
 func LevelDescription(name string) string {
+	if pack := LevelPack(name); pack != nil {
+		title := pack.Title
+		if len(pack.Maps) > 1 {
+			title = locale.G.Get("%s - %s", pack.Title, name)
+		}
+		if pack.Version != "" {
+			title = locale.G.Get("%s v%s", title, pack.Version)
+		}
+		if pack.Author != "" {
+			title = locale.G.Get("%s by %s", title, pack.Author)
+		}
+		return title
+	}
 	switch name {
 	case "level":
 		return "AAAAXY"
@@ -240,6 +272,25 @@ func LevelDescription(name string) string {
 	}
 }
 
+// LevelPack returns the level pack providing the given level, or nil if it is not from a level pack.
+func LevelPack(name string) *vfs.LevelPack {
+	packs := vfs.LevelPacks()
+	for i := range packs {
+		for _, m := range packs[i].Maps {
+			if m == name {
+				return &packs[i]
+			}
+		}
+	}
+	return nil
+}
+
+// newLevelLoader returns a loader for the given level.
+func newLevelLoader(name string) *level.Loader {
+	// Level packs may come without generated checkpoint locations.
+	return level.NewLoader(name).FallbackCheckpointLocations(LevelPack(name) != nil)
+}
+
 var levels []string
 
 func initLevels() error {
@@ -252,8 +303,20 @@ func initLevels() error {
 		if !isTMX {
 			continue
 		}
+		if len(levels) != 0 && levels[len(levels)-1] == name {
+			// Same name in multiple asset directories; only the first one is used.
+			continue
+		}
 		levels = append(levels, name)
 	}
+	// Level packs are only visible while playing them, but their level names are unique.
+	for _, pack := range vfs.LevelPacks() {
+		for _, name := range pack.Maps {
+			if !slices.Contains(levels, name) {
+				levels = append(levels, name)
+			}
+		}
+	}
 	sort.Slice(levels, func(i, j int) bool {
 		return LevelDescription(levels[i]) < LevelDescription(levels[j])
 	})
@@ -270,12 +333,21 @@ func Levels() []string {
 	return levels
 }
 
-func SaveName(idx int) string {
+// stateFilePrefix returns the prefix of all per-level state files.
+func stateFilePrefix() string {
 	if *cheatLevel == "level" {
-		return fmt.Sprintf("save-%d.json", idx)
-	} else {
-		return fmt.Sprintf("%s.save-%d.json", *cheatLevel, idx)
+		return ""
 	}
+	if pack := LevelPack(*cheatLevel); pack != nil {
+		// Include the pack name so a pack never picks up the state of a
+		// previously installed pack that had a level of the same name.
+		return fmt.Sprintf("%s.%s.", pack.Name, *cheatLevel)
+	}
+	return *cheatLevel + "."
+}
+
+func SaveName(idx int) string {
+	return fmt.Sprintf("%ssave-%d.json", stateFilePrefix(), idx)
 }
 
 var (
@@ -285,7 +357,7 @@ var (
 
 func Precache(s *splash.State) (splash.Status, error) {
 	if levelLoader == nil && !levelLoaderCreated {
-		levelLoader = level.NewLoader(LevelName())
+		levelLoader = newLevelLoader(LevelName())
 		levelLoaderCreated = true
 	}
 
@@ -315,7 +387,7 @@ func Precache(s *splash.State) (splash.Status, error) {
 
 func ReloadLevel() error {
 	// Must do this when the language changed.
-	lvl, err := level.NewLoader(LevelName()).Load()
+	lvl, err := newLevelLoader(LevelName()).Load()
 	if err != nil {
 		return err
 	}
@@ -328,7 +400,7 @@ func ReloadLevel() error {
 }
 
 func PaletteChanged() error {
-	loaded, err := level.NewLoader(LevelName()).Load()
+	loaded, err := newLevelLoader(LevelName()).Load()
 	if err != nil {
 		return err
 	}
@@ -365,6 +437,7 @@ func (w *World) Init(saveState int) error {
 	}
 	w.PlayerState.Init()
 	w.renderer.Init(w)
+	input.SetWantClicksWhilePlaying(*cheatEntityInspector)
 
 	// Load tile the player starts on.
 	w.setScrollPos(w.Level.Player.LevelPos.Mul(level.TileSize)) // Needed so we can set the tile.
@@ -445,7 +518,7 @@ func (w *World) Save() error {
 	if err != nil {
 		return err
 	}
-	if demo.InterceptSaveGame(save) {
+	if demo.InterceptSaveGame(save, w.PlayerState.LastCheckpoint()) {
 		return nil
 	}
 	state, err := json.MarshalIndent(save, "", "\t")
@@ -455,14 +528,54 @@ func (w *World) Save() error {
 	if is, cheats := flag.Cheating(); is {
 		return fmt.Errorf("not saving, as cheats are enabled: %s", cheats)
 	}
+	w.updatePersonalBest()
 	saveName := SaveName(w.saveState)
 	return vfs.WriteState(vfs.SavedGames, saveName, state)
 }
 
+// RestoreKeyframe brings the world into the state at the end of the frame
+// of an in-game respawn, as recorded by a demo keyframe.
+func (w *World) RestoreKeyframe(kf *demo.Keyframe) error {
+	defer timing.Group()()
+	err := w.Level.LoadGame(kf.SaveGame)
+	if err != nil {
+		return err
+	}
+	w.PlayerState.Init()
+	w.TimerStarted = kf.TimerStarted
+
+	// Do what a frame would do in which an entity respawns the player.
+	w.FramesSinceSpawn++
+	w.resetEntityUpdateState()
+	err = w.RespawnPlayer(kf.Checkpoint, false)
+	if err != nil {
+		return err
+	}
+	// The player may have moved on after the respawn.
+	respawnRect := w.Player.Rect
+	err = w.Player.Impl.(PlayerEntityImpl).LoadKeyframeState(kf.Player)
+	if err != nil {
+		return fmt.Errorf("could not restore player from demo keyframe: %w", err)
+	}
+	// Load the tiles the player moved to, as the player's own traces did.
+	w.LoadTilesForRect(w.Player.Rect, respawnRect.Origin.Div(level.TileSize))
+	w.compactEntities()
+	return w.updateAfterEntities()
+}
+
 // SpawnPlayer spawns the player in a newly initialized world.
 // As a side effect, it unloads all tiles.
 // Spawning at checkpoint "" means the initial player location.
 func (w *World) RespawnPlayer(checkpointName string, newGameSection bool) error {
+	// In-game respawns are the points where demo playback can later resume.
+	if !newGameSection && demo.WantKeyframe() {
+		save, err := w.Level.SaveGame()
+		if err != nil {
+			return fmt.Errorf("could not save for demo keyframe: %w", err)
+		}
+		demo.RecordKeyframe(save, checkpointName, w.TimerStarted)
+	}
+
 	// Load whether we've seen this checkpoint in flipped state.
 	flipped := w.PlayerState.CheckpointSeen(checkpointName) == playerstate.SeenFlipped
 
@@ -622,12 +735,25 @@ func (w *World) traceLineAndMark(from, to m.Pos, pathStore *[]m.Pos) TraceResult
 	return result
 }
 
-func (w *World) updateEntities() {
+func (w *World) resetEntityUpdateState() {
 	// Entities may update these.
 	w.warpzoneStatesChanged = false
 	w.respawned = false
 	w.GlobalColorM.Reset()
 	w.GlobalColorMSet = false
+}
+
+func (w *World) compactEntities() {
+	// Clean up newly spawned or despawned stuff.
+	w.entities.compact()
+	for i := range w.entitiesByZ {
+		w.entitiesByZ[i].compact()
+	}
+	w.opaqueEntities.compact()
+}
+
+func (w *World) updateEntities() {
+	w.resetEntityUpdateState()
 
 	w.entities.forEach(func(ent *Entity) error {
 		ent.Impl.Update()
@@ -639,12 +765,7 @@ func (w *World) updateEntities() {
 		return nil
 	})
 
-	// Clean up newly spawned or despawned stuff.
-	w.entities.compact()
-	for i := range w.entitiesByZ {
-		w.entitiesByZ[i].compact()
-	}
-	w.opaqueEntities.compact()
+	w.compactEntities()
 }
 
 // updateScrollPos updates the current scroll position.
@@ -927,12 +1048,35 @@ func (w *World) checkEntityOverlaps() error {
 
 func (w *World) Update() error {
 	defer timing.Group()()
+
+	if hotReloadChanged() {
+		err := w.hotReload()
+		if err != nil {
+			log.Errorf("could not hot reload level: %v", err)
+		}
+	}
+
+	if w.updateInspector() {
+		// Typing into the inspector; keep the world still.
+		return nil
+	}
+
 	w.FramesSinceSpawn++
 
 	// Let everything move.
 	timing.Section("entities")
 	w.updateEntities()
 
+	return w.updateAfterEntities()
+}
+
+// updateAfterEntities performs the part of a frame that follows entity updates.
+func (w *World) updateAfterEntities() error {
+	if w.respawned {
+		// Entities stopped updating at the respawn, but the player may have moved on.
+		demo.RecordKeyframePlayer(w.Player.Impl.(PlayerEntityImpl).KeyframeState())
+	}
+
 	// Audit overlaps.
 	err := w.checkEntityOverlaps()
 	if err != nil {
@@ -963,6 +1107,10 @@ func (w *World) Update() error {
 
 	w.AssumeChanged()
 
+	if frameObserver != nil {
+		frameObserver(w)
+	}
+
 	return nil
 }
 
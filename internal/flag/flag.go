@@ -264,6 +264,11 @@ func Parse(getSystemDefaults func() (*Config, error)) {
 	applyConfig()
 }
 
+// Args returns the non-flag command-line arguments.
+func Args() []string {
+	return flagSet.Args()
+}
+
 // NoConfig can be passed to Parse if the binary wants to do no config file processing.
 func NoConfig() (*Config, error) {
 	return nil, nil
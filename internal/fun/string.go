@@ -128,11 +128,7 @@ func TryFormatText(ps *playerstate.PlayerState, s string) (string, error) {
 			if ps == nil {
 				return "", errors.New("cannot use {{GameTime}} in static elements")
 			}
-			frames := ps.Frames()
-			ss, ms := frames/60, (frames%60)*1000/60
-			mm, ss := ss/60, ss%60
-			hh, mm := mm/60, mm%60
-			return locale.G.Get("%d:%02d:%02d.%03d", hh, mm, ss, ms), nil
+			return playerstate.FormatFrames(ps.Frames()), nil
 		},
 		"Score": func() (string, error) {
 			if ps == nil {
@@ -206,6 +202,8 @@ func TryFormatText(ps *playerstate.PlayerState, s string) (string, error) {
 				return locale.G.Get("Z"), nil
 			case input.ShiftETab:
 				return locale.G.Get("Shift/E/Tab"), nil
+			case input.CustomAction:
+				return input.CustomActionButtonNames(), nil
 			default: // case input.EnterShift:
 				return locale.G.Get("Enter/Shift"), nil
 			}
@@ -33,6 +33,7 @@ import (
 	m "github.com/divVerent/aaaaxy/internal/math"
 	"github.com/divVerent/aaaaxy/internal/noise"
 	"github.com/divVerent/aaaaxy/internal/palette"
+	"github.com/divVerent/aaaaxy/internal/propmap"
 	"github.com/divVerent/aaaaxy/internal/sound"
 )
 
@@ -516,6 +517,55 @@ func (p *Player) Respawned() {
 	p.setActionButtonAvailable()           // Update abilities.
 }
 
+// KeyframeState returns the player state at the end of the frame of a respawn,
+// as the rest of the frame may have changed it from what Respawned set.
+// GroundEntity is not included, as the next ground check finds it again.
+func (p *Player) KeyframeState() level.PersistentState {
+	state := propmap.New()
+	propmap.Set(state, "origin", p.Entity.Rect.Origin)
+	propmap.Set(state, "orientation", p.Entity.Orientation)
+	propmap.Set(state, "on_ground", p.OnGround)
+	propmap.Set(state, "on_ground_vec", p.OnGroundVec)
+	propmap.Set(state, "velocity", p.Velocity)
+	propmap.Set(state, "sub_pixel", p.SubPixel)
+	propmap.Set(state, "coyote_frames", p.CoyoteFrames)
+	propmap.Set(state, "last_ground_pos", p.LastGroundPos)
+	propmap.Set(state, "jumping", p.Jumping)
+	propmap.Set(state, "jumping_up", p.JumpingUp)
+	propmap.Set(state, "look_up", p.LookUp)
+	propmap.Set(state, "look_down", p.LookDown)
+	propmap.Set(state, "respawning", p.Respawning)
+	propmap.Set(state, "was_on_ground", p.WasOnGround)
+	propmap.Set(state, "prev_velocity", p.PrevVelocity)
+	propmap.Set(state, "vvvvvv", p.VVVVVV)
+	propmap.Set(state, "just_spawned", p.JustSpawned)
+	return state
+}
+
+// LoadKeyframeState restores a state returned by KeyframeState.
+// Missing keys keep what Respawned set.
+func (p *Player) LoadKeyframeState(state level.PersistentState) error {
+	var parseErr error
+	p.Entity.Rect.Origin = propmap.ValueOrP(state, "origin", p.Entity.Rect.Origin, &parseErr)
+	p.Entity.Orientation = propmap.ValueOrP(state, "orientation", p.Entity.Orientation, &parseErr)
+	p.OnGround = propmap.ValueOrP(state, "on_ground", p.OnGround, &parseErr)
+	p.OnGroundVec = propmap.ValueOrP(state, "on_ground_vec", p.OnGroundVec, &parseErr)
+	p.Velocity = propmap.ValueOrP(state, "velocity", p.Velocity, &parseErr)
+	p.SubPixel = propmap.ValueOrP(state, "sub_pixel", p.SubPixel, &parseErr)
+	p.CoyoteFrames = propmap.ValueOrP(state, "coyote_frames", p.CoyoteFrames, &parseErr)
+	p.LastGroundPos = propmap.ValueOrP(state, "last_ground_pos", p.LastGroundPos, &parseErr)
+	p.Jumping = propmap.ValueOrP(state, "jumping", p.Jumping, &parseErr)
+	p.JumpingUp = propmap.ValueOrP(state, "jumping_up", p.JumpingUp, &parseErr)
+	p.LookUp = propmap.ValueOrP(state, "look_up", p.LookUp, &parseErr)
+	p.LookDown = propmap.ValueOrP(state, "look_down", p.LookDown, &parseErr)
+	p.Respawning = propmap.ValueOrP(state, "respawning", p.Respawning, &parseErr)
+	p.WasOnGround = propmap.ValueOrP(state, "was_on_ground", p.WasOnGround, &parseErr)
+	p.PrevVelocity = propmap.ValueOrP(state, "prev_velocity", p.PrevVelocity, &parseErr)
+	p.VVVVVV = propmap.ValueOrP(state, "vvvvvv", p.VVVVVV, &parseErr)
+	p.JustSpawned = propmap.ValueOrP(state, "just_spawned", p.JustSpawned, &parseErr)
+	return parseErr
+}
+
 func (p *Player) ActionPressed() bool {
 	if p.Goal != nil {
 		return false
@@ -0,0 +1,203 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package target
+
+import (
+	"fmt"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/game/mixins"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+type counterOp int
+
+const (
+	counterNone counterOp = iota
+	counterIncrement
+	counterDecrement
+	counterReset
+)
+
+func parseCounterOp(s string) (counterOp, error) {
+	switch s {
+	case "none":
+		return counterNone, nil
+	case "increment":
+		return counterIncrement, nil
+	case "decrement":
+		return counterDecrement, nil
+	case "reset":
+		return counterReset, nil
+	default:
+		return counterNone, fmt.Errorf("could not parse counter operation: %v", s)
+	}
+}
+
+// CounterTarget counts incoming state changes and sends a signal while the count passes a threshold.
+//
+// Each incoming entity is only counted on its state transitions, so e.g. a
+// switch that is hit again while on does not count twice. To count how many
+// of a set of switches are on, use on_state "increment" and off_state
+// "decrement".
+//
+// The last state of each level entity is kept in the persistent state next to
+// the count, so neither the predecessor nor the counter respawning makes a
+// switch count again. Detached entities are only remembered while alive.
+type CounterTarget struct {
+	World  *engine.World
+	Entity *engine.Entity
+
+	PersistentState propmap.Map
+	Target          mixins.TargetSelection
+	Invert          bool
+	OnState         counterOp
+	OffState        counterOp
+	Step            int
+	Initial         int
+	Compare         string
+	Threshold       int
+
+	Count int
+	// IncomingState tracks detached predecessors; others are in PersistentState.
+	IncomingState map[engine.EntityIncarnation]bool
+	State         bool
+	Originator    *engine.Entity
+}
+
+func (c *CounterTarget) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	c.World = w
+	c.Entity = e
+	c.PersistentState = sp.PersistentState
+	var parseErr error
+	c.Target = mixins.ParseTarget(propmap.ValueP(sp.Properties, "target", "", &parseErr))
+	c.Invert = propmap.ValueOrP(sp.Properties, "invert", false, &parseErr)
+	c.Step = propmap.ValueOrP(sp.Properties, "step", 1, &parseErr)
+	c.Initial = propmap.ValueOrP(sp.Properties, "initial", 0, &parseErr)
+	c.Threshold = propmap.ValueOrP(sp.Properties, "threshold", 1, &parseErr)
+	c.Compare = propmap.ValueOrP(sp.Properties, "compare", ">=", &parseErr)
+	switch c.Compare {
+	case "==", "!=", "<", "<=", ">", ">=":
+	default:
+		return fmt.Errorf("could not parse counter comparison: %v", c.Compare)
+	}
+	var err error
+	c.OnState, err = parseCounterOp(propmap.ValueOrP(sp.Properties, "on_state", "increment", &parseErr))
+	if err != nil {
+		return err
+	}
+	c.OffState, err = parseCounterOp(propmap.ValueOrP(sp.Properties, "off_state", "none", &parseErr))
+	if err != nil {
+		return err
+	}
+	c.Count = propmap.ValueOrP(c.PersistentState, "count", c.Initial, &parseErr)
+	c.IncomingState = map[engine.EntityIncarnation]bool{}
+	c.Originator = e
+	return parseErr
+}
+
+func (c *CounterTarget) Despawn() {}
+
+func (c *CounterTarget) Update() {
+	for ent := range c.IncomingState {
+		if !c.World.EntityIsAlive(ent) {
+			delete(c.IncomingState, ent)
+		}
+	}
+	c.maybeSendEvent()
+}
+
+func (c *CounterTarget) Touch(other *engine.Entity) {}
+
+// incomingKey returns the persistent state key of the last state sent by a level entity.
+func incomingKey(id level.EntityID) string {
+	return fmt.Sprintf("incoming_%d", id)
+}
+
+// swapIncomingState records the state of a predecessor and returns its previous state, if any.
+func (c *CounterTarget) swapIncomingState(predecessor *engine.Entity, state bool) (prev, found bool) {
+	if !predecessor.Incarnation.ID.IsValid() {
+		prev, found = c.IncomingState[predecessor.Incarnation]
+		c.IncomingState[predecessor.Incarnation] = state
+		return prev, found
+	}
+	key := incomingKey(predecessor.Incarnation.ID)
+	found = propmap.Has(c.PersistentState, key)
+	prev, err := propmap.ValueOr(c.PersistentState, key, false)
+	if err != nil {
+		log.Errorf("could not parse counter state %v: %v", key, err)
+		found = false
+	}
+	propmap.Set(c.PersistentState, key, state)
+	return prev, found
+}
+
+func (c *CounterTarget) SetState(originator, predecessor *engine.Entity, state bool) {
+	// Only respond to state transitions of each predecessor.
+	prev, found := c.swapIncomingState(predecessor, state)
+	if found && prev == state {
+		return
+	}
+	op := c.OffState
+	if state {
+		op = c.OnState
+	}
+	switch op {
+	case counterNone:
+		return
+	case counterIncrement:
+		c.Count += c.Step
+	case counterDecrement:
+		c.Count -= c.Step
+	case counterReset:
+		c.Count = c.Initial
+	}
+	propmap.Set(c.PersistentState, "count", c.Count)
+	c.Originator = originator
+	c.maybeSendEvent()
+}
+
+// reached returns whether the count passes the threshold.
+func (c *CounterTarget) reached() bool {
+	switch c.Compare {
+	case "==":
+		return c.Count == c.Threshold
+	case "!=":
+		return c.Count != c.Threshold
+	case "<":
+		return c.Count < c.Threshold
+	case "<=":
+		return c.Count <= c.Threshold
+	case ">":
+		return c.Count > c.Threshold
+	default:
+		return c.Count >= c.Threshold
+	}
+}
+
+func (c *CounterTarget) maybeSendEvent() {
+	newState := c.reached()
+	if newState == c.State {
+		return
+	}
+	c.State = newState
+	mixins.SetStateOfTarget(c.World, c.Originator, c.Entity, c.Target, newState != c.Invert)
+}
+
+func init() {
+	engine.RegisterEntityType(&CounterTarget{})
+}
@@ -0,0 +1,87 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package target
+
+import (
+	"testing"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// spawnCounter spawns a target-less counter that counts switches being on.
+func spawnCounter(t *testing.T, w *engine.World, persistentState propmap.Map) *CounterTarget {
+	t.Helper()
+	props := propmap.New()
+	propmap.Set(props, "target", "")
+	propmap.Set(props, "off_state", "decrement")
+	c := &CounterTarget{}
+	err := c.Spawn(w, &level.SpawnableProps{
+		Properties:      props,
+		PersistentState: persistentState,
+	}, &engine.Entity{})
+	if err != nil {
+		t.Fatalf("could not spawn counter: %v", err)
+	}
+	return c
+}
+
+func TestCounterRespawn(t *testing.T) {
+	// In an empty world, the switches count as despawned after each update.
+	w := &engine.World{}
+	persistentState := propmap.New()
+	switch1 := &engine.Entity{Incarnation: engine.EntityIncarnation{ID: 1}}
+	switch2 := &engine.Entity{Incarnation: engine.EntityIncarnation{ID: 2}}
+
+	c := spawnCounter(t, w, persistentState)
+	for _, step := range []struct {
+		ent   *engine.Entity
+		state bool
+		want  int
+	}{
+		{switch1, true, 1},
+		{switch1, true, 1},
+		{switch2, true, 2},
+	} {
+		c.SetState(c.Entity, step.ent, step.state)
+		c.Update()
+		if c.Count != step.want {
+			t.Errorf("after %v sent %v: got count %v, want %v", step.ent.Incarnation, step.state, c.Count, step.want)
+		}
+	}
+
+	// After a respawn, the switches must not count again.
+	c.Despawn()
+	c = spawnCounter(t, w, persistentState)
+	for _, step := range []struct {
+		ent   *engine.Entity
+		state bool
+		want  int
+	}{
+		{switch1, true, 2},
+		{switch2, true, 2},
+		{switch2, false, 1},
+		{switch2, false, 1},
+		{switch1, false, 0},
+		{switch1, true, 1},
+	} {
+		c.SetState(c.Entity, step.ent, step.state)
+		c.Update()
+		if c.Count != step.want {
+			t.Errorf("after respawn, %v sent %v: got count %v, want %v", step.ent.Incarnation, step.state, c.Count, step.want)
+		}
+	}
+}
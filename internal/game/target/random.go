@@ -0,0 +1,100 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package target
+
+import (
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/game/mixins"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// RandomTarget turns on one of its targets, picked at random, when switched on.
+// When switched off, it turns off the target it picked last.
+//
+// The choice only depends on the seed and the number of previous choices,
+// which is kept in the persistent state; this keeps demos and save games
+// working. Like elsewhere, a target name prefixed with ! is set to the
+// opposite state.
+type RandomTarget struct {
+	World  *engine.World
+	Entity *engine.Entity
+
+	PersistentState propmap.Map
+	Targets         mixins.TargetSelection
+	Seed            int
+
+	State bool
+}
+
+func (r *RandomTarget) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	r.World = w
+	r.Entity = e
+	r.PersistentState = sp.PersistentState
+	var parseErr error
+	r.Targets = mixins.ParseTarget(propmap.ValueP(sp.Properties, "target", "", &parseErr))
+	// By default, each entity gets its own sequence.
+	r.Seed = propmap.ValueOrP(sp.Properties, "seed", int(e.Incarnation.ID), &parseErr)
+	return parseErr
+}
+
+func (r *RandomTarget) Despawn() {}
+
+func (r *RandomTarget) Update() {}
+
+func (r *RandomTarget) Touch(other *engine.Entity) {}
+
+// splitmix64 returns the n-th pseudo random number of the given seed.
+func splitmix64(seed, n uint64) uint64 {
+	z := seed + (n+1)*0x9E3779B97F4A7C15
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
+
+func (r *RandomTarget) SetState(originator, predecessor *engine.Entity, state bool) {
+	// Only respond to state transitions.
+	if state == r.State {
+		return
+	}
+	r.State = state
+	if len(r.Targets) == 0 {
+		return
+	}
+	var err error
+	defer func() {
+		if err != nil {
+			log.Errorf("could not parse RandomTarget state of %v: %v", r.Entity.Incarnation, err)
+		}
+	}()
+	if state {
+		draws := propmap.ValueOrP(r.PersistentState, "draws", 0, &err)
+		chosen := int(splitmix64(uint64(r.Seed), uint64(draws)) % uint64(len(r.Targets)))
+		propmap.Set(r.PersistentState, "draws", draws+1)
+		propmap.Set(r.PersistentState, "chosen", chosen)
+		mixins.SetStateOfTarget(r.World, originator, r.Entity, r.Targets[chosen:chosen+1], true)
+		return
+	}
+	chosen := propmap.ValueOrP(r.PersistentState, "chosen", -1, &err)
+	if chosen < 0 || chosen >= len(r.Targets) {
+		return
+	}
+	mixins.SetStateOfTarget(r.World, originator, r.Entity, r.Targets[chosen:chosen+1], false)
+}
+
+func init() {
+	engine.RegisterEntityType(&RandomTarget{})
+}
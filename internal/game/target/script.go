@@ -0,0 +1,259 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package target
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/game/interfaces"
+	"github.com/divVerent/aaaaxy/internal/game/mixins"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/log"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+	"github.com/divVerent/aaaaxy/internal/script"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+// ScriptEntity runs a script from the scripts directory on entity events.
+//
+// The script handles the events spawn, despawn, update, touch (with variable
+// other) and setstate (with variable state). See package script for the
+// language and Call for the functions available.
+type ScriptEntity struct {
+	mixins.NonSolidTouchable
+
+	Name            string
+	Properties      propmap.Map
+	PersistentState propmap.Map
+	Script          *script.Instance
+	WantsTouch      bool
+
+	// Originator is the originator of the state change being handled, if any.
+	Originator *engine.Entity
+	// Failed is set once the script had an error; it then no longer runs.
+	Failed bool
+}
+
+// scriptCache holds all parsed scripts by name.
+var scriptCache = map[string]*script.Program{}
+
+func loadScript(name string) (*script.Program, error) {
+	if prog, found := scriptCache[name]; found {
+		return prog, nil
+	}
+	f, err := vfs.Load("scripts", name)
+	if err != nil {
+		return nil, fmt.Errorf("could not open script %v: %w", name, err)
+	}
+	defer f.Close()
+	src, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not read script %v: %w", name, err)
+	}
+	prog, err := script.Parse(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse script %v: %w", name, err)
+	}
+	scriptCache[name] = prog
+	return prog, nil
+}
+
+func (s *ScriptEntity) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	s.NonSolidTouchable.Init(w, e)
+	var parseErr error
+	s.Name = propmap.ValueP(sp.Properties, "script", "", &parseErr)
+	if parseErr != nil {
+		return parseErr
+	}
+	prog, err := loadScript(s.Name)
+	if err != nil {
+		return err
+	}
+	s.Properties = sp.Properties
+	s.PersistentState = sp.PersistentState
+	s.Script = prog.NewInstance(s)
+	s.WantsTouch = prog.Handles("touch")
+	s.run("spawn", nil)
+	return nil
+}
+
+// run runs a script event handler, disabling the script on errors.
+func (s *ScriptEntity) run(event string, args map[string]script.Value) {
+	if s.Failed {
+		return
+	}
+	err := s.Script.Run(event, args)
+	if err != nil {
+		log.Errorf("script %v of entity %v failed: %v", s.Name, s.Entity.Incarnation, err)
+		s.Failed = true
+	}
+}
+
+func (s *ScriptEntity) Despawn() {
+	s.run("despawn", nil)
+}
+
+func (s *ScriptEntity) Update() {
+	if s.WantsTouch {
+		s.NonSolidTouchable.Update()
+	}
+	s.run("update", nil)
+}
+
+func (s *ScriptEntity) Touch(other *engine.Entity) {
+	name := other.Name()
+	if other == s.World.Player {
+		name = "player"
+	}
+	s.run("touch", map[string]script.Value{
+		"other": script.String(name),
+	})
+}
+
+func (s *ScriptEntity) SetState(originator, predecessor *engine.Entity, state bool) {
+	s.Originator = originator
+	s.run("setstate", map[string]script.Value{
+		"state": script.Bool(state),
+	})
+	s.Originator = nil
+}
+
+// findEntity returns the entity of the given name closest to the player.
+// The name "player" refers to the player.
+func (s *ScriptEntity) findEntity(name string) *engine.Entity {
+	if name == "player" {
+		return s.World.Player
+	}
+	var closest *engine.Entity
+	for _, ent := range s.World.FindName(name) {
+		if closest == nil || closest.Rect.Delta(s.World.Player.Rect).Norm1() > ent.Rect.Delta(s.World.Player.Rect).Norm1() {
+			closest = ent
+		}
+	}
+	return closest
+}
+
+// typedLike converts a stored string to the type of the given default value.
+func typedLike(str string, def script.Value) (script.Value, error) {
+	if def.IsString() {
+		return script.String(str), nil
+	}
+	n, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return script.Value{}, fmt.Errorf("could not parse %q as integer: %w", str, err)
+	}
+	return script.Int(n), nil
+}
+
+func checkArgs(args []script.Value, n int) error {
+	if len(args) != n {
+		return fmt.Errorf("got %d arguments, want %d", len(args), n)
+	}
+	return nil
+}
+
+// Call implements the functions available to scripts:
+//
+//   - settarget(target, state): sets the state of a target selection like the target property does.
+//   - exists(name), count(name): whether and how many entities of that name are loaded.
+//   - cansee(name): whether there is a line of sight to the closest entity of that name or "player".
+//   - hasability(ability): whether the player has the given ability.
+//   - lastcheckpoint(): the name of the last checkpoint the player reached.
+//   - property(key, default): a property of the entity, of the same type as default.
+//   - get(key, default), put(key, value): the persistent state of the entity.
+//   - print(values...): logs the values.
+func (s *ScriptEntity) Call(name string, args []script.Value) (script.Value, error) {
+	switch name {
+	case "settarget":
+		if err := checkArgs(args, 2); err != nil {
+			return script.Value{}, err
+		}
+		originator := s.Originator
+		if originator == nil {
+			originator = s.Entity
+		}
+		mixins.SetStateOfTarget(s.World, originator, s.Entity, mixins.ParseTarget(args[0].String()), args[1].Truthy())
+		return script.Value{}, nil
+	case "exists", "count":
+		if err := checkArgs(args, 1); err != nil {
+			return script.Value{}, err
+		}
+		n := len(s.World.FindName(args[0].String()))
+		if name == "exists" {
+			return script.Bool(n > 0), nil
+		}
+		return script.Int(int64(n)), nil
+	case "cansee":
+		if err := checkArgs(args, 1); err != nil {
+			return script.Value{}, err
+		}
+		other := s.findEntity(args[0].String())
+		if other == nil {
+			return script.Bool(false), nil
+		}
+		from, to := s.Entity.Rect.Center(), other.Rect.Center()
+		trace := s.World.TraceLine(from, to, engine.TraceOptions{
+			Contents:   level.OpaqueContents,
+			NoEntities: true,
+			ForEnt:     s.Entity,
+		})
+		return script.Bool(trace.EndPos == to), nil
+	case "hasability":
+		if err := checkArgs(args, 1); err != nil {
+			return script.Value{}, err
+		}
+		return script.Bool(s.World.Player.Impl.(interfaces.Abilityer).HasAbility(args[0].String())), nil
+	case "lastcheckpoint":
+		if err := checkArgs(args, 0); err != nil {
+			return script.Value{}, err
+		}
+		return script.String(s.World.PlayerState.LastCheckpoint()), nil
+	case "property", "get":
+		if err := checkArgs(args, 2); err != nil {
+			return script.Value{}, err
+		}
+		pm := s.Properties
+		if name == "get" {
+			pm = s.PersistentState
+		}
+		key := args[0].String()
+		if !propmap.Has(pm, key) {
+			return args[1], nil
+		}
+		return typedLike(propmap.StringOr(pm, key, ""), args[1])
+	case "put":
+		if err := checkArgs(args, 2); err != nil {
+			return script.Value{}, err
+		}
+		propmap.Set(s.PersistentState, args[0].String(), args[1].String())
+		return script.Value{}, nil
+	case "print":
+		strs := make([]string, len(args))
+		for i, arg := range args {
+			strs[i] = arg.String()
+		}
+		log.Infof("script %v of entity %v: %v", s.Name, s.Entity.Incarnation, strings.Join(strs, " "))
+		return script.Value{}, nil
+	}
+	return script.Value{}, fmt.Errorf("unknown function")
+}
+
+func init() {
+	engine.RegisterEntityType(&ScriptEntity{})
+}
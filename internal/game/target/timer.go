@@ -0,0 +1,105 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package target
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/game/mixins"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// TimerTarget fires its targets repeatedly while switched on.
+//
+// Every period, the targets are turned on, or, with toggle set, alternately
+// on and off. With count set, the timer stops by itself after firing that
+// many times.
+type TimerTarget struct {
+	World  *engine.World
+	Entity *engine.Entity
+
+	Target       mixins.TargetSelection
+	PeriodFrames int
+	Toggle       bool
+	Count        int
+
+	Running    bool
+	FramesLeft int
+	Fired      int
+	Originator *engine.Entity
+}
+
+func (t *TimerTarget) Spawn(w *engine.World, sp *level.SpawnableProps, e *engine.Entity) error {
+	t.World = w
+	t.Entity = e
+
+	var parseErr error
+	period := propmap.ValueP(sp.Properties, "period", time.Duration(0), &parseErr)
+	t.PeriodFrames = int((period*engine.GameTPS + (time.Second / 2)) / time.Second)
+	if parseErr == nil && t.PeriodFrames < 1 {
+		return fmt.Errorf("TimerTarget period %v is shorter than a frame", period)
+	}
+	t.Target = mixins.ParseTarget(propmap.ValueP(sp.Properties, "target", "", &parseErr))
+	t.Toggle = propmap.ValueOrP(sp.Properties, "toggle", false, &parseErr)
+	t.Count = propmap.ValueOrP(sp.Properties, "count", 0, &parseErr)
+	t.Running = propmap.ValueOrP(sp.Properties, "running", false, &parseErr)
+	t.FramesLeft = t.PeriodFrames
+	t.Originator = e
+	return parseErr
+}
+
+func (t *TimerTarget) Despawn() {}
+
+func (t *TimerTarget) Update() {
+	if !t.Running {
+		return
+	}
+	t.FramesLeft--
+	if t.FramesLeft > 0 {
+		return
+	}
+	t.FramesLeft = t.PeriodFrames
+	state := true
+	if t.Toggle {
+		state = t.Fired%2 == 0
+	}
+	t.Fired++
+	if t.Count > 0 && t.Fired >= t.Count {
+		t.Running = false
+	}
+	mixins.SetStateOfTarget(t.World, t.Originator, t.Entity, t.Target, state)
+}
+
+func (t *TimerTarget) Touch(other *engine.Entity) {}
+
+func (t *TimerTarget) SetState(originator, predecessor *engine.Entity, state bool) {
+	if state == t.Running {
+		return
+	}
+	t.Running = state
+	if state {
+		// Start a new cycle.
+		t.FramesLeft = t.PeriodFrames
+		t.Fired = 0
+		t.Originator = originator
+	}
+}
+
+func init() {
+	engine.RegisterEntityType(&TimerTarget{})
+}
@@ -0,0 +1,42 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gametime converts game times, counted in frames, to clock times.
+package gametime
+
+import (
+	"fmt"
+)
+
+// framesPerSecond is the game's fixed tick rate.
+const framesPerSecond = 60
+
+// Seconds splits a game time into whole seconds and milliseconds.
+func Seconds(frames int) (ss, ms int) {
+	return frames / framesPerSecond, (frames % framesPerSecond) * 1000 / framesPerSecond
+}
+
+// Clock splits a game time into hours, minutes, seconds and milliseconds.
+func Clock(frames int) (hh, mm, ss, ms int) {
+	ss, ms = Seconds(frames)
+	mm, ss = ss/60, ss%60
+	hh, mm = mm/60, mm%60
+	return hh, mm, ss, ms
+}
+
+// Format formats a game time as h:mm:ss.mmm regardless of locale, as external tools expect.
+func Format(frames int) string {
+	hh, mm, ss, ms := Clock(frames)
+	return fmt.Sprintf("%d:%02d:%02d.%03d", hh, mm, ss, ms)
+}
@@ -37,6 +37,8 @@ var (
 type imagePath = struct {
 	Purpose string
 	Name    string
+	// Pack is the level pack providing the image, as they may reuse names.
+	Pack string
 }
 
 var (
@@ -48,7 +50,10 @@ var (
 )
 
 func load(purpose, name string, force bool) (*ebiten.Image, error) {
-	ip := imagePath{purpose, name}
+	ip := imagePath{Purpose: purpose, Name: name}
+	if pack := vfs.LevelPackName(); pack != "" && vfs.IsFromLevelPack(purpose, name) {
+		ip.Pack = pack
+	}
 	cachedImg, found := cache[ip]
 	if found && !force {
 		return cachedImg, nil
@@ -126,13 +131,40 @@ func Precache() error {
 	for item := range toLoad {
 		return fmt.Errorf("could not find precache item for file %v", item)
 	}
+	// Level packs are not in the load order, so just load their images in a stable order.
+	for _, pack := range vfs.LevelPacks() {
+		err := vfs.WithLevelPack(pack.Name, func() error {
+			for _, purpose := range []string{"tiles", "sprites"} {
+				names, err := vfs.ReadDir(purpose)
+				if err != nil {
+					return fmt.Errorf("could not enumerate files in %v: %w", purpose, err)
+				}
+				for _, name := range names {
+					if !strings.HasSuffix(name, ".png") || !vfs.IsFromLevelPack(purpose, name) {
+						continue
+					}
+					_, err := Load(purpose, name)
+					if err != nil {
+						return fmt.Errorf("could not precache %v/%v from level pack %v: %w", purpose, name, pack.Name, err)
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
 	cacheFrozen = true
 	return nil
 }
 
 func PaletteChanged() error {
 	for ip := range cache {
-		_, err := load(ip.Purpose, ip.Name, true)
+		err := vfs.WithLevelPack(ip.Pack, func() error {
+			_, err := load(ip.Purpose, ip.Name, true)
+			return err
+		})
 		if err != nil {
 			return err
 		}
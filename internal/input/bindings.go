@@ -0,0 +1,385 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+)
+
+// KeyList is a list of keys, written as comma separated key names.
+type KeyList []ebiten.Key
+
+func (l KeyList) MarshalText() ([]byte, error) {
+	names := make([]string, len(l))
+	for i, k := range l {
+		names[i] = k.String()
+	}
+	return []byte(strings.Join(names, ",")), nil
+}
+
+func (l *KeyList) UnmarshalText(text []byte) error {
+	*l = nil
+	if len(text) == 0 {
+		return nil
+	}
+	for _, name := range strings.Split(string(text), ",") {
+		var k ebiten.Key
+		err := k.UnmarshalText([]byte(name))
+		if err != nil {
+			return err
+		}
+		*l = append(*l, k)
+	}
+	return nil
+}
+
+// buttonNames are the names of gamepad buttons, using Xbox naming like the in-game texts.
+var buttonNames = map[ebiten.StandardGamepadButton]string{
+	ebiten.StandardGamepadButtonRightBottom:      "A",
+	ebiten.StandardGamepadButtonRightRight:       "B",
+	ebiten.StandardGamepadButtonRightLeft:        "X",
+	ebiten.StandardGamepadButtonRightTop:         "Y",
+	ebiten.StandardGamepadButtonFrontTopLeft:     "LB",
+	ebiten.StandardGamepadButtonFrontTopRight:    "RB",
+	ebiten.StandardGamepadButtonFrontBottomLeft:  "LT",
+	ebiten.StandardGamepadButtonFrontBottomRight: "RT",
+	ebiten.StandardGamepadButtonCenterLeft:       "Back",
+	ebiten.StandardGamepadButtonCenterRight:      "Start",
+	ebiten.StandardGamepadButtonLeftStick:        "LS",
+	ebiten.StandardGamepadButtonRightStick:       "RS",
+	ebiten.StandardGamepadButtonLeftTop:          "DpadUp",
+	ebiten.StandardGamepadButtonLeftBottom:       "DpadDown",
+	ebiten.StandardGamepadButtonLeftLeft:         "DpadLeft",
+	ebiten.StandardGamepadButtonLeftRight:        "DpadRight",
+	ebiten.StandardGamepadButtonCenterCenter:     "Guide",
+}
+
+// ButtonList is a list of gamepad buttons, written as comma separated button names.
+type ButtonList []ebiten.StandardGamepadButton
+
+func (l ButtonList) MarshalText() ([]byte, error) {
+	names := make([]string, len(l))
+	for i, b := range l {
+		names[i] = buttonNames[b]
+	}
+	return []byte(strings.Join(names, ",")), nil
+}
+
+func (l *ButtonList) UnmarshalText(text []byte) error {
+	*l = nil
+	if len(text) == 0 {
+		return nil
+	}
+NextName:
+	for _, name := range strings.Split(string(text), ",") {
+		for b, n := range buttonNames {
+			if n == name {
+				*l = append(*l, b)
+				continue NextName
+			}
+		}
+		return fmt.Errorf("unexpected gamepad button name: %s", name)
+	}
+	return nil
+}
+
+var (
+	keysLeft             = flag.Text("keys_left", KeyList{}, "custom keys for moving left, comma separated; if empty, the keys of all keyboard layouts are used")
+	keysRight            = flag.Text("keys_right", KeyList{}, "custom keys for moving right, comma separated; if empty, the keys of all keyboard layouts are used")
+	keysUp               = flag.Text("keys_up", KeyList{}, "custom keys for moving up, comma separated; if empty, the keys of all keyboard layouts are used")
+	keysDown             = flag.Text("keys_down", KeyList{}, "custom keys for moving down, comma separated; if empty, the keys of all keyboard layouts are used")
+	keysJump             = flag.Text("keys_jump", KeyList{}, "custom keys for jumping, comma separated; if empty, the keys of all keyboard layouts are used")
+	keysAction           = flag.Text("keys_action", KeyList{}, "custom keys for performing an action, comma separated; if empty, the keys of all keyboard layouts are used")
+	keysExit             = flag.Text("keys_exit", KeyList{}, "custom keys for exiting, comma separated; if empty, the keys of all keyboard layouts are used")
+	gamepadButtonsLeft   = flag.Text("gamepad_buttons_left", ButtonList{}, "custom gamepad buttons for moving left, comma separated; if empty, the default buttons are used")
+	gamepadButtonsRight  = flag.Text("gamepad_buttons_right", ButtonList{}, "custom gamepad buttons for moving right, comma separated; if empty, the default buttons are used")
+	gamepadButtonsUp     = flag.Text("gamepad_buttons_up", ButtonList{}, "custom gamepad buttons for moving up, comma separated; if empty, the default buttons are used")
+	gamepadButtonsDown   = flag.Text("gamepad_buttons_down", ButtonList{}, "custom gamepad buttons for moving down, comma separated; if empty, the default buttons are used")
+	gamepadButtonsJump   = flag.Text("gamepad_buttons_jump", ButtonList{}, "custom gamepad buttons for jumping, comma separated; if empty, the default buttons are used")
+	gamepadButtonsAction = flag.Text("gamepad_buttons_action", ButtonList{}, "custom gamepad buttons for performing an action, comma separated; if empty, the default buttons are used")
+	gamepadButtonsExit   = flag.Text("gamepad_buttons_exit", ButtonList{}, "custom gamepad buttons for exiting, comma separated; if empty, the default buttons are used")
+)
+
+// bindings are the player-defined bindings of an impulse.
+type bindings struct {
+	keysFlag    string
+	keys        *KeyList
+	buttonsFlag string
+	buttons     *ButtonList
+}
+
+// effectiveKeys returns the keys that trigger the impulse, sorted.
+func (i *impulse) effectiveKeys() KeyList {
+	if i.bindings.keys != nil && len(*i.bindings.keys) != 0 {
+		return *i.bindings.keys
+	}
+	keys := make(KeyList, 0, len(i.keys))
+	for k := range i.keys {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		return keys[a] < keys[b]
+	})
+	return keys
+}
+
+// effectiveButtons returns the gamepad buttons that trigger the impulse.
+func (i *impulse) effectiveButtons() ButtonList {
+	if i.bindings.buttons != nil && len(*i.bindings.buttons) != 0 {
+		return *i.bindings.buttons
+	}
+	return i.padControls.buttons
+}
+
+// Binding is a key or gamepad button that can be bound to an impulse.
+type Binding struct {
+	Key      ebiten.Key
+	Button   ebiten.StandardGamepadButton
+	IsButton bool
+}
+
+func (b Binding) String() string {
+	if b.IsButton {
+		return buttonNames[b.Button]
+	}
+	return b.Key.String()
+}
+
+func (i *impulse) keyBindingNames() []string {
+	var names []string
+	for _, k := range i.effectiveKeys() {
+		names = append(names, k.String())
+	}
+	return names
+}
+
+func (i *impulse) buttonBindingNames() []string {
+	var names []string
+	for _, b := range i.effectiveButtons() {
+		names = append(names, buttonNames[b])
+	}
+	return names
+}
+
+// BindingNames returns the keys and gamepad buttons bound to the impulse, for display.
+func (i *impulse) BindingNames() string {
+	return strings.Join(append(i.keyBindingNames(), i.buttonBindingNames()...), "/")
+}
+
+// BindingConflictError is returned by Bind if the binding cannot be taken from another impulse.
+type BindingConflictError struct {
+	Binding Binding
+	Impulse string
+}
+
+func (e *BindingConflictError) Error() string {
+	return fmt.Sprintf("%v is needed for %v", e.Binding, e.Impulse)
+}
+
+// Bind makes the given key or gamepad button the only one of its kind for the impulse.
+// It is removed from other impulses that use it, unless that would leave them
+// without any key or button of that kind, in which case a BindingConflictError
+// is returned and nothing changes.
+//
+// Returns the names of the other impulses it was removed from. Those that used
+// their preset keys or buttons before now use the remaining ones as custom
+// bindings, so they no longer follow the detected keyboard layout.
+func (i *impulse) Bind(b Binding) ([]string, error) {
+	type change struct {
+		name  string
+		value interface{}
+	}
+	var changes []change
+	var changed []string
+	for _, o := range impulses {
+		if o == i {
+			continue
+		}
+		if b.IsButton {
+			buttons := o.effectiveButtons()
+			var remaining ButtonList
+			for _, ob := range buttons {
+				if ob != b.Button {
+					remaining = append(remaining, ob)
+				}
+			}
+			if len(remaining) == len(buttons) {
+				continue
+			}
+			if o.bindings.buttons == nil || len(remaining) == 0 {
+				return nil, &BindingConflictError{Binding: b, Impulse: o.Name}
+			}
+			changes = append(changes, change{o.bindings.buttonsFlag, remaining})
+			changed = append(changed, o.Name)
+		} else {
+			keys := o.effectiveKeys()
+			var remaining KeyList
+			for _, ok := range keys {
+				if ok != b.Key {
+					remaining = append(remaining, ok)
+				}
+			}
+			if len(remaining) == len(keys) {
+				continue
+			}
+			if o.bindings.keys == nil || len(remaining) == 0 {
+				return nil, &BindingConflictError{Binding: b, Impulse: o.Name}
+			}
+			changes = append(changes, change{o.bindings.keysFlag, remaining})
+			changed = append(changed, o.Name)
+		}
+	}
+	if b.IsButton {
+		changes = append(changes, change{i.bindings.buttonsFlag, ButtonList{b.Button}})
+	} else {
+		changes = append(changes, change{i.bindings.keysFlag, KeyList{b.Key}})
+	}
+	for _, c := range changes {
+		err := flag.Set(c.name, c.value)
+		if err != nil {
+			return nil, fmt.Errorf("could not set %v: %w", c.name, err)
+		}
+	}
+	return changed, nil
+}
+
+// ResetBindings returns the impulse to the preset keys and gamepad buttons.
+func (i *impulse) ResetBindings() {
+	flag.ResetFlagToDefault(i.bindings.keysFlag)
+	flag.ResetFlagToDefault(i.bindings.buttonsFlag)
+}
+
+// ResetAllBindings returns all impulses to the preset keys and gamepad buttons.
+func ResetAllBindings() {
+	for _, i := range impulses {
+		if i.bindings.keys != nil {
+			i.ResetBindings()
+		}
+	}
+}
+
+type captureState int
+
+const (
+	notCapturing captureState = iota
+	capturing
+	captureWaitRelease
+)
+
+// genericKeys maps modifier keys to their side independent variants, as used by the presets.
+var genericKeys = map[ebiten.Key]ebiten.Key{
+	ebiten.KeyAltLeft:      ebiten.KeyAlt,
+	ebiten.KeyAltRight:     ebiten.KeyAlt,
+	ebiten.KeyControlLeft:  ebiten.KeyControl,
+	ebiten.KeyControlRight: ebiten.KeyControl,
+	ebiten.KeyMetaLeft:     ebiten.KeyMeta,
+	ebiten.KeyMetaRight:    ebiten.KeyMeta,
+	ebiten.KeyShiftLeft:    ebiten.KeyShift,
+	ebiten.KeyShiftRight:   ebiten.KeyShift,
+}
+
+var (
+	capture  captureState
+	captured *Binding
+	// capturedKeys is the list of just pressed keys. Global to reduce allocation.
+	capturedKeys []ebiten.Key
+)
+
+// StartCapture makes the next key or gamepad button press available via
+// Captured instead of triggering impulses.
+func StartCapture() {
+	capture = capturing
+	captured = nil
+}
+
+// CancelCapture stops waiting for a key or gamepad button press.
+func CancelCapture() {
+	if capture == capturing {
+		capture = notCapturing
+	}
+}
+
+// Captured returns the key or gamepad button captured since StartCapture, if any.
+func Captured() (Binding, bool) {
+	if captured == nil {
+		return Binding{}, false
+	}
+	b := *captured
+	captured = nil
+	return b, true
+}
+
+// captureUpdate detects the captured key or button. Until it is released
+// again, impulses are not triggered so the press does not also act as input.
+func captureUpdate() {
+	switch capture {
+	case capturing:
+		capturedKeys = inpututil.AppendJustPressedKeys(capturedKeys[:0])
+		if len(capturedKeys) > 0 {
+			k := capturedKeys[0]
+			if generic, found := genericKeys[k]; found {
+				k = generic
+			}
+			captured = &Binding{Key: k}
+			capture = captureWaitRelease
+			return
+		}
+		for p := range gamepads {
+			for b := range buttonNames {
+				if ignoredGamepadButtons[b] {
+					continue
+				}
+				if inpututil.IsStandardGamepadButtonJustPressed(p, b) {
+					captured = &Binding{Button: b, IsButton: true}
+					capture = captureWaitRelease
+					return
+				}
+			}
+		}
+	case captureWaitRelease:
+		if !anyPressed() {
+			capture = notCapturing
+		}
+	}
+}
+
+// anyPressed returns whether any key or gamepad button is held.
+func anyPressed() bool {
+	capturedKeys = inpututil.AppendPressedKeys(capturedKeys[:0])
+	if len(capturedKeys) > 0 {
+		return true
+	}
+	for p := range gamepads {
+		for b := range buttonNames {
+			if ebiten.IsStandardGamepadButtonPressed(p, b) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Capturing returns whether a key or gamepad button press is being waited for,
+// or one has been captured but not yet retrieved using Captured.
+func Capturing() bool {
+	return capture == capturing || captured != nil
+}
@@ -0,0 +1,173 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+)
+
+func TestKeyListText(t *testing.T) {
+	for _, want := range []KeyList{
+		nil,
+		{ebiten.KeySpace},
+		{ebiten.KeyA, ebiten.KeyShift, ebiten.KeyArrowLeft, ebiten.KeyNumpadEnter},
+	} {
+		text, err := want.MarshalText()
+		if err != nil {
+			t.Fatalf("could not marshal %v: %v", want, err)
+		}
+		var got KeyList
+		err = got.UnmarshalText(text)
+		if err != nil {
+			t.Fatalf("could not unmarshal %q: %v", text, err)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("round trip via %q: got %v, want %v", text, got, want)
+		}
+	}
+	var l KeyList
+	if err := l.UnmarshalText([]byte("A,NoSuchKey")); err == nil {
+		t.Errorf("unknown key name was accepted: %v", l)
+	}
+}
+
+func TestButtonListText(t *testing.T) {
+	all := make(ButtonList, 0, len(buttonNames))
+	for b := range buttonNames {
+		all = append(all, b)
+	}
+	slices.Sort(all)
+	for _, want := range []ButtonList{
+		nil,
+		{ebiten.StandardGamepadButtonRightBottom},
+		all,
+	} {
+		text, err := want.MarshalText()
+		if err != nil {
+			t.Fatalf("could not marshal %v: %v", want, err)
+		}
+		var got ButtonList
+		err = got.UnmarshalText(text)
+		if err != nil {
+			t.Fatalf("could not unmarshal %q: %v", text, err)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("round trip via %q: got %v, want %v", text, got, want)
+		}
+	}
+	var l ButtonList
+	if err := l.UnmarshalText([]byte("A,Z")); err == nil {
+		t.Errorf("unknown button name was accepted: %v", l)
+	}
+}
+
+func TestBind(t *testing.T) {
+	defer ResetAllBindings()
+	for _, c := range []struct {
+		name     string
+		keys     map[string]string
+		impulse  *impulse
+		binding  Binding
+		changed  []string
+		conflict string
+		want     map[*impulse]KeyList
+	}{
+		{
+			name:    "unused key",
+			impulse: Jump,
+			binding: Binding{Key: ebiten.KeyN},
+			want: map[*impulse]KeyList{
+				Jump:   {ebiten.KeyN},
+				Action: Action.effectiveKeys(),
+			},
+		},
+		{
+			name:    "from preset",
+			impulse: Action,
+			binding: Binding{Key: ebiten.KeyX},
+			changed: []string{"Jump"},
+			want: map[*impulse]KeyList{
+				Jump:   {ebiten.KeySpace},
+				Action: {ebiten.KeyX},
+			},
+		},
+		{
+			name:    "from custom",
+			keys:    map[string]string{"keys_jump": "M,N"},
+			impulse: Action,
+			binding: Binding{Key: ebiten.KeyN},
+			changed: []string{"Jump"},
+			want: map[*impulse]KeyList{
+				Jump:   {ebiten.KeyM},
+				Action: {ebiten.KeyN},
+			},
+		},
+		{
+			name:     "last key",
+			keys:     map[string]string{"keys_jump": "N"},
+			impulse:  Action,
+			binding:  Binding{Key: ebiten.KeyN},
+			conflict: "Jump",
+			want: map[*impulse]KeyList{
+				Jump:   {ebiten.KeyN},
+				Action: Action.effectiveKeys(),
+			},
+		},
+		{
+			name:     "not rebindable",
+			impulse:  Jump,
+			binding:  Binding{Key: ebiten.KeyF11},
+			conflict: "Fullscreen",
+			want: map[*impulse]KeyList{
+				Jump: Jump.effectiveKeys(),
+			},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			ResetAllBindings()
+			for name, value := range c.keys {
+				err := flag.Set(name, value)
+				if err != nil {
+					t.Fatalf("could not set %v: %v", name, err)
+				}
+			}
+			changed, err := c.impulse.Bind(c.binding)
+			var conflict *BindingConflictError
+			if errors.As(err, &conflict) {
+				if conflict.Impulse != c.conflict {
+					t.Errorf("got conflict with %v, want %q", conflict.Impulse, c.conflict)
+				}
+			} else if err != nil {
+				t.Fatalf("could not bind: %v", err)
+			} else if c.conflict != "" {
+				t.Errorf("got no conflict, want conflict with %v", c.conflict)
+			}
+			if !slices.Equal(changed, c.changed) {
+				t.Errorf("got changed impulses %v, want %v", changed, c.changed)
+			}
+			for i, want := range c.want {
+				if got := i.effectiveKeys(); !slices.Equal(got, want) {
+					t.Errorf("%v: got keys %v, want %v", i.Name, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,309 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+)
+
+// DeadzoneShape is how a stick position is reduced to directions.
+type DeadzoneShape int
+
+const (
+	// AxialDeadzone checks each axis against its own threshold.
+	AxialDeadzone DeadzoneShape = iota
+	// RadialDeadzone checks the distance from the center, scaled by the thresholds of both axes.
+	RadialDeadzone
+)
+
+var deadzoneShapeNames = map[DeadzoneShape]string{
+	AxialDeadzone:  "axial",
+	RadialDeadzone: "radial",
+}
+
+// AxisCalibration is the calibration of a single stick axis.
+type AxisCalibration struct {
+	// Center is the value the axis reports at rest; used to counter drift.
+	Center float64
+	// On is the minimum amount to push for registering an action.
+	On float64
+	// Off is the maximum amount to push for unregistering an action.
+	Off float64
+}
+
+// Calibration is the stick calibration of a gamepad.
+type Calibration struct {
+	Shape DeadzoneShape
+	// Diagonal is the width in degrees of the sectors around the diagonals
+	// in which a stick counts as pushed into both directions. 0 means only
+	// four directions, 45 means eight directions of equal size, and 90 means
+	// any direction with a nonzero component counts.
+	Diagonal float64
+	// Axes are indexed by ebiten.StandardGamepadAxis.
+	Axes [StickAxes]AxisCalibration
+}
+
+const (
+	// Sticks is the number of sticks of a standard gamepad.
+	Sticks = 2
+	// StickAxes is the number of stick axes of a standard gamepad.
+	StickAxes = 2 * Sticks
+)
+
+// Stick directions, as returned by StickDirections.
+const (
+	StickLeft = iota
+	StickRight
+	StickUp
+	StickDown
+	StickDirectionCount
+)
+
+// stickDirectionVectors are the unit vectors of the stick directions.
+var stickDirectionVectors = [StickDirectionCount][2]float64{
+	StickLeft:  {-1, 0},
+	StickRight: {+1, 0},
+	StickUp:    {0, -1},
+	StickDown:  {0, +1},
+}
+
+// DefaultCalibration returns the calibration of gamepads that have not been calibrated.
+func DefaultCalibration() Calibration {
+	c := Calibration{
+		Shape:    AxialDeadzone,
+		Diagonal: 90,
+	}
+	for a := range c.Axes {
+		c.Axes[a] = AxisCalibration{
+			On:  *gamepadAxisOnThreshold,
+			Off: *gamepadAxisOffThreshold,
+		}
+	}
+	return c
+}
+
+func (c Calibration) marshal() string {
+	parts := []string{deadzoneShapeNames[c.Shape], strconv.FormatFloat(c.Diagonal, 'g', -1, 64)}
+	for _, a := range c.Axes {
+		parts = append(parts, fmt.Sprintf("%s/%s/%s",
+			strconv.FormatFloat(a.Center, 'g', -1, 64),
+			strconv.FormatFloat(a.On, 'g', -1, 64),
+			strconv.FormatFloat(a.Off, 'g', -1, 64)))
+	}
+	return strings.Join(parts, ":")
+}
+
+func (c *Calibration) unmarshal(s string) error {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2+StickAxes {
+		return fmt.Errorf("invalid calibration %q: got %d fields, want %d", s, len(parts), 2+StickAxes)
+	}
+	found := false
+	for shape, name := range deadzoneShapeNames {
+		if parts[0] == name {
+			c.Shape = shape
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("invalid deadzone shape %q: want axial or radial", parts[0])
+	}
+	var err error
+	c.Diagonal, err = strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return fmt.Errorf("invalid diagonal angle %q: %w", parts[1], err)
+	}
+	for a := range c.Axes {
+		values := strings.Split(parts[2+a], "/")
+		if len(values) != 3 {
+			return fmt.Errorf("invalid axis calibration %q: want center/on/off", parts[2+a])
+		}
+		var f [3]float64
+		for i, v := range values {
+			f[i], err = strconv.ParseFloat(v, 64)
+			if err != nil {
+				return fmt.Errorf("invalid axis calibration %q: %w", parts[2+a], err)
+			}
+		}
+		c.Axes[a] = AxisCalibration{Center: f[0], On: f[1], Off: f[2]}
+	}
+	return nil
+}
+
+// Calibrations maps SDL GUIDs to stick calibrations, written as comma
+// separated guid:shape:diagonal:axes entries, with each of the four axes
+// written as center/on/off.
+type Calibrations map[string]Calibration
+
+func (c Calibrations) MarshalText() ([]byte, error) {
+	guids := make([]string, 0, len(c))
+	for guid := range c {
+		guids = append(guids, guid)
+	}
+	sort.Strings(guids)
+	entries := make([]string, len(guids))
+	for i, guid := range guids {
+		entries[i] = guid + ":" + c[guid].marshal()
+	}
+	return []byte(strings.Join(entries, ",")), nil
+}
+
+func (c *Calibrations) UnmarshalText(text []byte) error {
+	*c = Calibrations{}
+	if len(text) == 0 {
+		return nil
+	}
+	for _, entry := range strings.Split(string(text), ",") {
+		guid, rest, found := strings.Cut(entry, ":")
+		if !found {
+			return fmt.Errorf("invalid calibration entry %q: want guid:calibration", entry)
+		}
+		var cal Calibration
+		err := cal.unmarshal(rest)
+		if err != nil {
+			return err
+		}
+		(*c)[guid] = cal
+	}
+	return nil
+}
+
+var (
+	gamepadCalibration = flag.Text("gamepad_calibration", Calibrations{}, "stick calibration per gamepad SDL GUID, as comma separated guid:shape:diagonal:LX:LY:RX:RY entries where shape is axial or radial, diagonal is the angle in degrees around diagonals where both directions count, and each axis is center/on/off; gamepads not listed use -gamepad_axis_on_threshold and -gamepad_axis_off_threshold")
+)
+
+// GetCalibration returns the calibration of a gamepad.
+func GetCalibration(p ebiten.GamepadID) Calibration {
+	c, found := (*gamepadCalibration)[ebiten.GamepadSDLID(p)]
+	if !found {
+		return DefaultCalibration()
+	}
+	return c
+}
+
+// SetCalibration changes the calibration of a gamepad and of all others with the same SDL GUID.
+func SetCalibration(p ebiten.GamepadID, c Calibration) error {
+	cals := Calibrations{}
+	for guid, cal := range *gamepadCalibration {
+		cals[guid] = cal
+	}
+	cals[ebiten.GamepadSDLID(p)] = c
+	return flag.Set("gamepad_calibration", cals)
+}
+
+// ResetCalibration makes a gamepad use the default calibration again.
+func ResetCalibration(p ebiten.GamepadID) error {
+	cals := Calibrations{}
+	for guid, cal := range *gamepadCalibration {
+		cals[guid] = cal
+	}
+	delete(cals, ebiten.GamepadSDLID(p))
+	return flag.Set("gamepad_calibration", cals)
+}
+
+// Gamepads returns the gamepads in use, in a stable order.
+func Gamepads() []ebiten.GamepadID {
+	ps := make([]ebiten.GamepadID, 0, len(gamepads))
+	for p := range gamepads {
+		ps = append(ps, p)
+	}
+	sort.Slice(ps, func(a, b int) bool {
+		return ps[a] < ps[b]
+	})
+	return ps
+}
+
+// stickAxes returns the horizontal and vertical axis of a stick.
+func stickAxes(stick int) (ebiten.StandardGamepadAxis, ebiten.StandardGamepadAxis) {
+	return ebiten.StandardGamepadAxis(2 * stick), ebiten.StandardGamepadAxis(2*stick + 1)
+}
+
+// threshold returns the threshold of an axis, depending on whether the direction is already held.
+func (a *AxisCalibration) threshold(held bool) float64 {
+	if held {
+		return a.Off
+	}
+	return a.On
+}
+
+// pushed returns whether a stick position counts as pushed into a direction.
+// x and y are relative to the calibrated center.
+func (c *Calibration) pushed(stick int, x, y float64, dir int, held bool) bool {
+	h, v := stickAxes(stick)
+	th, tv := c.Axes[h].threshold(held), c.Axes[v].threshold(held)
+	d := stickDirectionVectors[dir]
+	along := x*d[0] + y*d[1]
+	if along <= 0 {
+		return false
+	}
+	switch c.Shape {
+	case AxialDeadzone:
+		if along < th*math.Abs(d[0])+tv*math.Abs(d[1]) {
+			return false
+		}
+	case RadialDeadzone:
+		// A zero threshold accepts any movement on that axis.
+		var r float64
+		if th > 0 {
+			r += (x / th) * (x / th)
+		}
+		if tv > 0 {
+			r += (y / tv) * (y / tv)
+		}
+		if (th > 0 || tv > 0) && r < 1 {
+			return false
+		}
+	}
+	// Outside the diagonal sectors, only the dominant direction counts.
+	angle := math.Acos(along/math.Hypot(x, y)) * 180 / math.Pi
+	return angle <= 45+c.Diagonal/2+1e-9
+}
+
+// stickPushed returns whether a stick of a gamepad counts as pushed into a direction.
+func (c *Calibration) stickPushed(p ebiten.GamepadID, stick int, dir int, held bool) bool {
+	h, v := stickAxes(stick)
+	x := ebiten.StandardGamepadAxisValue(p, h) - c.Axes[h].Center
+	y := ebiten.StandardGamepadAxisValue(p, v) - c.Axes[v].Center
+	return c.pushed(stick, x, y, dir, held)
+}
+
+// StickDirections returns the raw position of a stick of a gamepad, and
+// the directions it counts as pushed into when using the given calibration.
+func StickDirections(p ebiten.GamepadID, stick int, c *Calibration) (x, y float64, dirs [StickDirectionCount]bool) {
+	h, v := stickAxes(stick)
+	x = ebiten.StandardGamepadAxisValue(p, h)
+	y = ebiten.StandardGamepadAxisValue(p, v)
+	for dir := range dirs {
+		dirs[dir] = c.pushed(stick, x-c.Axes[h].Center, y-c.Axes[v].Center, dir, false)
+	}
+	return x, y, dirs
+}
+
+// StickCenters returns the current position of all stick axes of a gamepad.
+func StickCenters(p ebiten.GamepadID) [StickAxes]float64 {
+	var centers [StickAxes]float64
+	for a := range centers {
+		centers[a] = ebiten.StandardGamepadAxisValue(p, ebiten.StandardGamepadAxis(a))
+	}
+	return centers
+}
@@ -16,6 +16,7 @@ package input
 
 import (
 	"runtime"
+	"strings"
 
 	"github.com/hajimehoshi/ebiten/v2"
 
@@ -57,8 +58,10 @@ type impulse struct {
 
 	keys              map[ebiten.Key]InputMap
 	padControls       padControls
+	bindings          bindings
 	mouseControl      bool
 	touchRect         *m.Rect
+	touchActiveRect   m.Rect
 	touchImage        *ebiten.Image
 	externallyPressed bool
 }
@@ -90,13 +93,13 @@ const (
 )
 
 var (
-	Left       = (&impulse{Name: "Left", keys: leftKeys, padControls: leftPad, touchRect: touchRectLeft}).register()
-	Right      = (&impulse{Name: "Right", keys: rightKeys, padControls: rightPad, touchRect: touchRectRight}).register()
-	Up         = (&impulse{Name: "Up", keys: upKeys, padControls: upPad, touchRect: touchRectUp}).register()
-	Down       = (&impulse{Name: "Down", keys: downKeys, padControls: downPad, touchRect: touchRectDown}).register()
-	Jump       = (&impulse{Name: "Jump", keys: jumpKeys, padControls: jumpPad, touchRect: touchRectJump}).register()
-	Action     = (&impulse{Name: "Action", keys: actionKeys, padControls: actionPad, touchRect: touchRectAction}).register()
-	Exit       = (&impulse{Name: "Exit", keys: exitKeys, padControls: exitPad, mouseControl: true, touchRect: touchRectExit}).register()
+	Left       = (&impulse{Name: "Left", keys: leftKeys, padControls: leftPad, bindings: bindings{"keys_left", keysLeft, "gamepad_buttons_left", gamepadButtonsLeft}, touchRect: touchRectLeft}).register()
+	Right      = (&impulse{Name: "Right", keys: rightKeys, padControls: rightPad, bindings: bindings{"keys_right", keysRight, "gamepad_buttons_right", gamepadButtonsRight}, touchRect: touchRectRight}).register()
+	Up         = (&impulse{Name: "Up", keys: upKeys, padControls: upPad, bindings: bindings{"keys_up", keysUp, "gamepad_buttons_up", gamepadButtonsUp}, touchRect: touchRectUp}).register()
+	Down       = (&impulse{Name: "Down", keys: downKeys, padControls: downPad, bindings: bindings{"keys_down", keysDown, "gamepad_buttons_down", gamepadButtonsDown}, touchRect: touchRectDown}).register()
+	Jump       = (&impulse{Name: "Jump", keys: jumpKeys, padControls: jumpPad, bindings: bindings{"keys_jump", keysJump, "gamepad_buttons_jump", gamepadButtonsJump}, touchRect: touchRectJump}).register()
+	Action     = (&impulse{Name: "Action", keys: actionKeys, padControls: actionPad, bindings: bindings{"keys_action", keysAction, "gamepad_buttons_action", gamepadButtonsAction}, touchRect: touchRectAction}).register()
+	Exit       = (&impulse{Name: "Exit", keys: exitKeys, padControls: exitPad, bindings: bindings{"keys_exit", keysExit, "gamepad_buttons_exit", gamepadButtonsExit}, mouseControl: true, touchRect: touchRectExit}).register()
 	Fullscreen = (&impulse{Name: "Fullscreen", keys: fullscreenKeys /* no padControls */}).register()
 
 	impulses = []*impulse{}
@@ -142,6 +145,10 @@ func (i *impulse) update() {
 	} else {
 		i.JustHit = false
 	}
+	if capture != notCapturing {
+		// Keys pressed for capturing must not trigger anything.
+		i.JustHit = false
+	}
 	i.Held = held
 	i.externallyPressed = false
 }
@@ -172,6 +179,7 @@ func Update(screenWidth, screenHeight, gameWidth, gameHeight int, crtK1, crtK2,
 	clickPos, hoverPos = nil, nil
 	mouseUpdate(screenWidth, screenHeight, gameWidth, gameHeight, crtK1, crtK2, borderStretchPower)
 	touchUpdate(screenWidth, screenHeight, gameWidth, gameHeight, crtK1, crtK2, borderStretchPower)
+	captureUpdate()
 	for _, i := range impulses {
 		i.update()
 	}
@@ -220,6 +228,11 @@ func KonamiCodeJustHit() bool {
 	return konamiCode.justHit || snesKonamiCode.justHit || kbdKonamiCode.justHit || literalKbdKonamiCode.justHit
 }
 
+// KeyboardInUse returns whether the keyboard is the input device last used.
+func KeyboardInUse() bool {
+	return inputMap.ContainsAny(AnyKeyboard)
+}
+
 type ExitButtonID int
 
 const (
@@ -236,6 +249,15 @@ func ExitButton() ExitButtonID {
 	if inputMap.ContainsAny(Touchscreen) {
 		return Back
 	}
+	if keys := *Exit.bindings.keys; len(keys) != 0 {
+		// Only Escape and Backspace have images, so prefer those if bound.
+		for _, k := range keys {
+			if k == ebiten.KeyBackspace {
+				return Backspace
+			}
+		}
+		return Escape
+	}
 	if runtime.GOOS != "js" {
 		// On JS, the Esc key is kinda "reserved" for leaving fullsreeen.
 		// Thus we never recommend it, even if the user used it before.
@@ -256,18 +278,25 @@ const (
 	Z
 	ShiftETab
 	EnterShift
+	CustomAction
 )
 
 func ActionButton() ActionButtonID {
 	if inputMap.ContainsAny(Gamepad) {
+		if len(*Action.bindings.buttons) != 0 {
+			return CustomAction
+		}
 		return BX
 	}
 	if inputMap.ContainsAny(Touchscreen) {
-		if Action.touchRect.Size.IsZero() {
+		if Action.touchActiveRect.Size.IsZero() {
 			return Elsewhere
 		}
 		return B
 	}
+	if len(*Action.bindings.keys) != 0 {
+		return CustomAction
+	}
 	if inputMap.ContainsAny(DOSKeyboard) {
 		return CtrlShift
 	}
@@ -284,6 +313,15 @@ func ActionButton() ActionButtonID {
 	return CtrlShift
 }
 
+// CustomActionButtonNames returns the names of the player-defined keys or
+// buttons for Action, to be shown when ActionButton returns CustomAction.
+func CustomActionButtonNames() string {
+	if inputMap.ContainsAny(Gamepad) {
+		return strings.Join(Action.buttonBindingNames(), "/")
+	}
+	return strings.Join(Action.keyBindingNames(), "/")
+}
+
 func HoverPos() (m.Pos, bool) {
 	if hoverPos == nil {
 		return m.Pos{}, false
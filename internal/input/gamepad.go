@@ -30,8 +30,8 @@ import (
 
 var (
 	gamepad                 = flag.Bool("gamepad", true, "enable gamepad input")
-	gamepadAxisOnThreshold  = flag.Float64("gamepad_axis_on_threshold", 0.6, "minimum amount to push the game pad for registering an action; can be zero to accept any movement")
-	gamepadAxisOffThreshold = flag.Float64("gamepad_axis_off_threshold", 0.4, "maximum amount to push the game pad for unregistering an action; can be zero to accept any movement")
+	gamepadAxisOnThreshold  = flag.Float64("gamepad_axis_on_threshold", 0.6, "minimum amount to push the game pad for registering an action; can be zero to accept any movement; applies to gamepads without -gamepad_calibration")
+	gamepadAxisOffThreshold = flag.Float64("gamepad_axis_off_threshold", 0.4, "maximum amount to push the game pad for unregistering an action; can be zero to accept any movement; applies to gamepads without -gamepad_calibration")
 	gamepadOverride         = flag.String("gamepad_override", "", "entries in SDL_GameControllerDB format to add/override gamepad support; multiple entries are permitted and can be separated by newlines or semicolons; can also be provided via $SDL_GAMECONTROLLERCONFIG environment variable")
 	debugGamepadLogging     = flag.Bool("debug_gamepad_logging", false, "log all gamepad states (spammy)")
 )
@@ -144,13 +144,26 @@ var (
 	allGamepadsList []ebiten.GamepadID
 )
 
-func (i *impulse) gamepadPressed() InputMap {
-	t := *gamepadAxisOnThreshold
-	if i.Held {
-		t = *gamepadAxisOffThreshold
+// stickDirection returns the stick direction an axis of the controls is pushed into.
+func (c *padControls) stickDirection(a ebiten.StandardGamepadAxis) int {
+	vertical := a%2 == 1
+	switch {
+	case vertical && c.axisDirection > 0:
+		return StickDown
+	case vertical:
+		return StickUp
+	case c.axisDirection > 0:
+		return StickRight
+	default:
+		return StickLeft
 	}
+}
+
+func (i *impulse) gamepadPressed() InputMap {
+	buttons := i.effectiveButtons()
 	for p := range gamepads {
-		for _, b := range i.padControls.buttons {
+		cal := GetCalibration(p)
+		for _, b := range buttons {
 			if ignoredGamepadButtons[b] {
 				continue
 			}
@@ -162,7 +175,7 @@ func (i *impulse) gamepadPressed() InputMap {
 			if ignoredGamepadAxes[a] {
 				continue
 			}
-			if ebiten.StandardGamepadAxisValue(p, a)*i.padControls.axisDirection >= t {
+			if cal.stickPushed(p, int(a)/2, i.padControls.stickDirection(a), i.Held) {
 				return Gamepad
 			}
 		}
@@ -62,6 +62,15 @@ var (
 )
 
 func (i *impulse) keyboardPressed() InputMap {
+	if i.bindings.keys != nil && len(*i.bindings.keys) != 0 {
+		for _, k := range *i.bindings.keys {
+			if ebiten.IsKeyPressed(k) {
+				// Player-defined keys belong to no layout in particular.
+				return AnyKeyboard
+			}
+		}
+		return NoInput
+	}
 	for k, m := range i.keys {
 		if ebiten.IsKeyPressed(k) {
 			return m
@@ -38,10 +38,12 @@ var (
 	mouseClicking   bool
 	mouseVisible    bool = true
 	mouseWantClicks bool
+	// mouseWantClicksWhilePlaying keeps the mouse usable during gameplay, e.g. for debug overlays.
+	mouseWantClicksWhilePlaying bool
 )
 
 func mouseUpdate(screenWidth, screenHeight, gameWidth, gameHeight int, crtK1, crtK2, borderStretchPower float64) {
-	wantVisible := *mouse && mouseWantClicks && mouseHoverFrame > 0
+	wantVisible := *mouse && (mouseWantClicks || mouseWantClicksWhilePlaying) && mouseHoverFrame > 0
 	if wantVisible != mouseVisible {
 		mouseVisible = wantVisible
 		if wantVisible {
@@ -88,6 +90,11 @@ func mouseSetWantClicks(want bool) {
 	mouseWantClicks = want
 }
 
+// SetWantClicksWhilePlaying shows the mouse pointer during gameplay too.
+func SetWantClicksWhilePlaying(want bool) {
+	mouseWantClicksWhilePlaying = want
+}
+
 func mouseCancel() {
 	mouseHoverFrame = 0
 	mouseBlockFrame = mouseBlockFrames
@@ -26,13 +26,13 @@ import (
 var (
 	touch           = flag.Bool("touch", true, "enable touch input")
 	touchForce      = flag.Bool("touch_force", false, "always show touch controls")
-	touchRectLeft   = flag.Text("touch_rect_left", m.Rect{Origin: m.Pos{X: 0, Y: 232}, Size: m.Delta{DX: 64, DY: 64}}, "touch rectangle for moving left")
-	touchRectRight  = flag.Text("touch_rect_right", m.Rect{Origin: m.Pos{X: 64, Y: 232}, Size: m.Delta{DX: 64, DY: 64}}, "touch rectangle for moving right")
-	touchRectDown   = flag.Text("touch_rect_down", m.Rect{Origin: m.Pos{X: 0, Y: 296}, Size: m.Delta{DX: 128, DY: 64}}, "touch rectangle for moving down")
-	touchRectUp     = flag.Text("touch_rect_up", m.Rect{Origin: m.Pos{X: 0, Y: 168}, Size: m.Delta{DX: 128, DY: 64}}, "touch rectangle for moving up")
-	touchRectJump   = flag.Text("touch_rect_jump", m.Rect{Origin: m.Pos{X: 576, Y: 296}, Size: m.Delta{DX: 64, DY: 64}}, "touch rectangle for jumping")
-	touchRectAction = flag.Text("touch_rect_action", m.Rect{}, "touch rectangle for performing an action")
-	touchRectExit   = flag.Text("touch_rect_exit", m.Rect{Origin: m.Pos{X: 0, Y: 0}, Size: m.Delta{DX: 128, DY: 64}}, "touch rectangle for exiting")
+	touchRectLeft   = flag.Text("touch_rect_left", touchStandardRects["left"], "touch rectangle for moving left")
+	touchRectRight  = flag.Text("touch_rect_right", touchStandardRects["right"], "touch rectangle for moving right")
+	touchRectDown   = flag.Text("touch_rect_down", touchStandardRects["down"], "touch rectangle for moving down")
+	touchRectUp     = flag.Text("touch_rect_up", touchStandardRects["up"], "touch rectangle for moving up")
+	touchRectJump   = flag.Text("touch_rect_jump", touchStandardRects["jump"], "touch rectangle for jumping")
+	touchRectAction = flag.Text("touch_rect_action", touchStandardRects["action"], "touch rectangle for performing an action")
+	touchRectExit   = flag.Text("touch_rect_exit", touchStandardRects["exit"], "touch rectangle for exiting")
 )
 
 const (
@@ -111,6 +111,7 @@ func touchUpdate(screenWidth, screenHeight, gameWidth, gameHeight int, crtK1, cr
 	} else {
 		touchEmulateMouse()
 	}
+	touchApplyLayout(screenWidth, screenHeight, gameWidth, gameHeight)
 	for id, t := range touches {
 		if !t.hit {
 			delete(touches, id)
@@ -143,7 +144,7 @@ func (i *impulse) touchPressed() InputMap {
 		return 0
 	}
 	for _, t := range touches {
-		if i.touchRect.Size.IsZero() {
+		if i.touchActiveRect.Size.IsZero() {
 			touched := false
 			for _, other := range impulses {
 				if other == i {
@@ -152,10 +153,10 @@ func (i *impulse) touchPressed() InputMap {
 				if other.touchRect == nil {
 					continue
 				}
-				if other.touchRect.Size.IsZero() {
+				if other.touchActiveRect.Size.IsZero() {
 					continue
 				}
-				if other.touchRect.DeltaPos(t.pos).IsZero() {
+				if other.touchActiveRect.DeltaPos(t.pos).IsZero() {
 					touched = true
 					break
 				}
@@ -163,7 +164,7 @@ func (i *impulse) touchPressed() InputMap {
 			if !touched {
 				return Touchscreen
 			}
-		} else if i.touchRect.DeltaPos(t.pos).IsZero() {
+		} else if i.touchActiveRect.DeltaPos(t.pos).IsZero() {
 			return Touchscreen
 		}
 	}
@@ -218,10 +219,10 @@ func touchDraw(screen *ebiten.Image) {
 
 func touchPadDraw(screen *ebiten.Image) {
 	for _, i := range impulses {
-		r := i.touchRect
-		if r == nil {
+		if i.touchRect == nil {
 			continue
 		}
+		r := &i.touchActiveRect
 		img := i.touchImage
 		if img == nil {
 			continue
@@ -260,6 +261,7 @@ func touchPadDraw(screen *ebiten.Image) {
 			colorM.Scale(-1, -1, -1, 1)
 			colorM.Translate(1, 1, 1, 0)
 		}
+		colorM.Scale(1, 1, 1, touchActiveOpacity)
 		colorm.DrawImage(screen, img, colorM, options)
 	}
 }
@@ -15,10 +15,12 @@
 package input
 
 import (
+	"strings"
+
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/vector"
 
-	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/log"
 	m "github.com/divVerent/aaaaxy/internal/math"
 	"github.com/divVerent/aaaaxy/internal/palette"
 )
@@ -35,7 +37,7 @@ const (
 type touchEditInfo struct {
 	active    bool
 	frames    int
-	rect      *m.Rect
+	control   string
 	xMode     editMode
 	yMode     editMode
 	startPos  m.Pos
@@ -121,7 +123,7 @@ func touchEditSize(mode editMode, s int, dp int) int {
 	}
 }
 
-func touchEditAllowed(rect *m.Rect, replacement m.Rect, gameWidth, gameHeight int) bool {
+func touchEditAllowed(l *TouchLayout, control string, replacement m.Rect, gameWidth, gameHeight int) bool {
 	if replacement.Origin.X < 0 || replacement.Origin.Y < 0 {
 		return false
 	}
@@ -134,24 +136,26 @@ func touchEditAllowed(rect *m.Rect, replacement m.Rect, gameWidth, gameHeight in
 	if touchReservedArea.Delta(replacement).IsZero() {
 		return false
 	}
-	for _, i := range impulses {
-		if i.touchRect == nil || i.touchRect.Size.IsZero() || i.touchRect == rect {
+	for name, r := range l.Rects {
+		if r.Size.IsZero() || name == control {
 			continue
 		}
-		if replacement.Delta(*i.touchRect).IsZero() {
+		if replacement.Delta(r).IsZero() {
 			return false
 		}
 	}
 	return true
 }
 
-func touchToggleActionButton(gameWidth, gameHeight int) {
-	if !Action.touchRect.Size.IsZero() {
-		Action.touchRect.Size = m.Delta{}
+func touchToggleActionButton(l *TouchLayout, gameWidth, gameHeight int) {
+	if action := l.Rects["action"]; !action.Size.IsZero() {
+		action.Size = m.Delta{}
+		l.Rects["action"] = action
 		return
 	}
+	jump := l.Rects["jump"]
 	// Start with the square inscribed into Jump.
-	jumpSize := Jump.touchRect.Size
+	jumpSize := jump.Size
 	for range []int{0, 1} {
 		// Find an empty place close to Jump, and make it the same size.
 		for _, neighbor := range []m.Delta{
@@ -161,7 +165,7 @@ func touchToggleActionButton(gameWidth, gameHeight int) {
 			{DX: 0, DY: 1},
 		} {
 			initialSize := jumpSize
-			initialOrigin := Jump.touchRect.Origin
+			initialOrigin := jump.Origin
 			// Minimize size in the direction moving in.
 			if neighbor.DX == 0 {
 				initialSize.DY = 64
@@ -182,7 +186,7 @@ func touchToggleActionButton(gameWidth, gameHeight int) {
 				Origin: initialOrigin,
 				Size:   initialSize,
 			}
-			if touchEditAllowed(Action.touchRect, newRect, gameWidth, gameHeight) {
+			if touchEditAllowed(l, "action", newRect, gameWidth, gameHeight) {
 				sizeStep := neighbor.Mul(gridSize)
 				originStep := m.Delta{}
 				if sizeStep.DX < 0 {
@@ -199,12 +203,12 @@ func touchToggleActionButton(gameWidth, gameHeight int) {
 						Origin: newRect.Origin.Add(originStep),
 						Size:   newRect.Size.Add(sizeStep),
 					}
-					if !touchEditAllowed(Action.touchRect, expandedRect, gameWidth, gameHeight) {
+					if !touchEditAllowed(l, "action", expandedRect, gameWidth, gameHeight) {
 						break
 					}
 					newRect = expandedRect
 				}
-				*Action.touchRect = newRect
+				l.Rects["action"] = newRect
 				return
 			}
 		}
@@ -231,14 +235,24 @@ func touchEditUpdate(gameWidth, gameHeight int) bool {
 		if t.edit.active {
 			// Move what is being hit.
 			t.edit.frames++
-			if t.edit.rect == nil {
+			if t.edit.control == "" {
 				// Hold for some time to toggle action button existence.
 				if t.edit.frames == touchToggleActionFrames {
-					touchToggleActionButton(gameWidth, gameHeight)
+					l, err := touchCustomize()
+					if err != nil {
+						log.Errorf("could not customize touch layout: %v", err)
+						continue
+					}
+					touchToggleActionButton(l, gameWidth, gameHeight)
 				}
 				continue
 			}
-			newRect := *t.edit.rect
+			l, err := touchCustomize()
+			if err != nil {
+				log.Errorf("could not customize touch layout: %v", err)
+				continue
+			}
+			newRect := l.Rects[t.edit.control]
 			// The truncate rounding in m.Div slightly prefers the same coordinate. Good.
 			dx := gridSize * m.Div(t.pos.X-t.edit.startPos.X, gridSize)
 			dy := gridSize * m.Div(t.pos.Y-t.edit.startPos.Y, gridSize)
@@ -247,28 +261,34 @@ func touchEditUpdate(gameWidth, gameHeight int) bool {
 				newRect.Origin.Y = touchEditOrigin(t.edit.yMode, t.edit.startRect.Origin.Y, dy+gridSize*snap.DY)
 				newRect.Size.DX = touchEditSize(t.edit.xMode, t.edit.startRect.Size.DX, dx+gridSize*snap.DX)
 				newRect.Size.DY = touchEditSize(t.edit.yMode, t.edit.startRect.Size.DY, dy+gridSize*snap.DY)
-				if touchEditAllowed(t.edit.rect, newRect, gameWidth, gameHeight) {
-					*t.edit.rect = newRect
+				if touchEditAllowed(l, t.edit.control, newRect, gameWidth, gameHeight) {
+					l.Rects[t.edit.control] = newRect
 					break
 				}
 			}
 		} else {
 			t.edit.active = true
-			t.edit.rect = nil
+			t.edit.control = ""
 			t.edit.startPos = t.pos
 			// Identify what is hit, set flag, xMode, yMode appropriately.
 			// Just set active if nothing is hit.
 			for _, i := range impulses {
-				if i.touchRect == nil || i.touchRect.Size.IsZero() {
+				if i.touchRect == nil || i.touchActiveRect.Size.IsZero() {
 					continue
 				}
-				gx, gy := i.touchRect.GridPos(t.pos, 4, 4)
+				gx, gy := i.touchActiveRect.GridPos(t.pos, 4, 4)
 				if gx < 0 || gy < 0 || gx >= 4 || gy >= 4 {
 					continue
 				}
+				// Editing a built-in layout starts a custom one from it.
+				l, err := touchCustomize()
+				if err != nil {
+					log.Errorf("could not customize touch layout: %v", err)
+					break
+				}
 				// Hit, so start active this rectangle.
-				t.edit.rect = i.touchRect
-				t.edit.startRect = *t.edit.rect
+				t.edit.control = strings.ToLower(i.Name)
+				t.edit.startRect = l.Rects[t.edit.control]
 				t.edit.xMode = touchEditMode(gx)
 				t.edit.yMode = touchEditMode(gy)
 				if t.edit.xMode == editNone && t.edit.yMode == editNone {
@@ -287,13 +307,14 @@ func touchEditDraw(screen *ebiten.Image) {
 		return
 	}
 	for _, i := range impulses {
-		if i.touchRect == nil || i.touchRect.Size.IsZero() {
+		if i.touchRect == nil || i.touchActiveRect.Size.IsZero() {
 			continue
 		}
+		r := &i.touchActiveRect
 		boxColor := palette.EGA(palette.White, 255)
-		vector.DrawFilledRect(screen, float32(i.touchRect.Origin.X), float32(i.touchRect.Origin.Y), float32(i.touchRect.Size.DX), float32(i.touchRect.Size.DY), boxColor, false)
+		vector.DrawFilledRect(screen, float32(r.Origin.X), float32(r.Origin.Y), float32(r.Size.DX), float32(r.Size.DY), boxColor, false)
 		innerColor := palette.EGA(palette.DarkGrey, 255)
-		vector.DrawFilledRect(screen, float32(i.touchRect.Origin.X+1), float32(i.touchRect.Origin.Y+1), float32(i.touchRect.Size.DX-2), float32(i.touchRect.Size.DY-2), innerColor, false)
+		vector.DrawFilledRect(screen, float32(r.Origin.X+1), float32(r.Origin.Y+1), float32(r.Size.DX-2), float32(r.Size.DY-2), innerColor, false)
 	}
 	gridColor := palette.EGA(palette.LightGrey, 32)
 	sz := screen.Bounds().Size()
@@ -320,12 +341,10 @@ func touchSetEditor(want bool) {
 	touchEditPad = want
 }
 
+// TouchResetEditor returns the touch controls of the current aspect ratio to defaults.
 func TouchResetEditor() {
-	flag.ResetFlagToDefault("touch_rect_left")
-	flag.ResetFlagToDefault("touch_rect_right")
-	flag.ResetFlagToDefault("touch_rect_down")
-	flag.ResetFlagToDefault("touch_rect_up")
-	flag.ResetFlagToDefault("touch_rect_jump")
-	flag.ResetFlagToDefault("touch_rect_action")
-	flag.ResetFlagToDefault("touch_rect_exit")
+	err := touchResetLayout()
+	if err != nil {
+		log.Errorf("could not reset touch layout: %v", err)
+	}
 }
@@ -0,0 +1,491 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+var (
+	touchLayout        = flag.StringMap[string]("touch_layout", map[string]string{}, "touch control layout per screen aspect ratio, as comma separated aspect=layout pairs like 16:9=tablet; layouts are custom, standard, left_handed, compact, tablet and large_buttons")
+	touchCustomLayouts = flag.Text("touch_custom_layouts", touchLayouts{}, "custom touch control layouts per screen aspect ratio, as JSON; aspect ratios without one use the touch_rect_* flags")
+	touchLayoutFile    = flag.String("touch_layout_file", "", "local file path to export touch layouts to and import them from; if empty, "+touchLayoutFileName+" next to the save games is used, so it can be copied between devices like level packs")
+)
+
+const (
+	// TouchLayoutCustom is the custom layout of the current aspect ratio.
+	TouchLayoutCustom = "custom"
+
+	// touchLayoutFileName is the default file touch layouts are exported to and imported from.
+	touchLayoutFileName = "touch_layout.json"
+)
+
+// TouchLayout is a set of touch controls.
+type TouchLayout struct {
+	// Opacity of the touch controls.
+	Opacity float64 `json:"opacity"`
+	// Scale is applied to the rectangles around the closest screen corner.
+	Scale float64 `json:"scale"`
+	// Rects are the touch rectangles by lower case impulse name.
+	Rects map[string]m.Rect `json:"rects"`
+}
+
+// touchLayouts are touch layouts by aspect ratio.
+type touchLayouts map[string]*TouchLayout
+
+func (l touchLayouts) MarshalText() ([]byte, error) {
+	if len(l) == 0 {
+		return []byte{}, nil
+	}
+	return json.Marshal(map[string]*TouchLayout(l))
+}
+
+func (l *touchLayouts) UnmarshalText(text []byte) error {
+	layouts := touchLayouts{}
+	if len(text) != 0 {
+		err := json.Unmarshal(text, (*map[string]*TouchLayout)(&layouts))
+		if err != nil {
+			return err
+		}
+	}
+	for aspect, layout := range layouts {
+		if layout == nil {
+			return fmt.Errorf("missing touch layout for %v", aspect)
+		}
+		err := layout.validate()
+		if err != nil {
+			return fmt.Errorf("invalid touch layout for %v: %w", aspect, err)
+		}
+	}
+	*l = layouts
+	return nil
+}
+
+// touchStandardRects are the default touch_rect_* values.
+var touchStandardRects = map[string]m.Rect{
+	"left":   {Origin: m.Pos{X: 0, Y: 232}, Size: m.Delta{DX: 64, DY: 64}},
+	"right":  {Origin: m.Pos{X: 64, Y: 232}, Size: m.Delta{DX: 64, DY: 64}},
+	"down":   {Origin: m.Pos{X: 0, Y: 296}, Size: m.Delta{DX: 128, DY: 64}},
+	"up":     {Origin: m.Pos{X: 0, Y: 168}, Size: m.Delta{DX: 128, DY: 64}},
+	"jump":   {Origin: m.Pos{X: 576, Y: 296}, Size: m.Delta{DX: 64, DY: 64}},
+	"action": {},
+	"exit":   {Origin: m.Pos{X: 0, Y: 0}, Size: m.Delta{DX: 128, DY: 64}},
+}
+
+// TouchLayoutNames are the selectable touch layouts, in menu order.
+var TouchLayoutNames = []string{TouchLayoutCustom, "standard", "left_handed", "compact", "tablet", "large_buttons"}
+
+// touchLayoutPresets are the built-in touch layouts.
+var touchLayoutPresets = map[string]*TouchLayout{
+	"standard": {
+		Opacity: 1,
+		Scale:   1,
+		Rects:   touchStandardRects,
+	},
+	"left_handed": {
+		// The standard layout mirrored; left and right keep pointing where they did.
+		Opacity: 1,
+		Scale:   1,
+		Rects: map[string]m.Rect{
+			"left":   {Origin: m.Pos{X: 512, Y: 232}, Size: m.Delta{DX: 64, DY: 64}},
+			"right":  {Origin: m.Pos{X: 576, Y: 232}, Size: m.Delta{DX: 64, DY: 64}},
+			"down":   {Origin: m.Pos{X: 512, Y: 296}, Size: m.Delta{DX: 128, DY: 64}},
+			"up":     {Origin: m.Pos{X: 512, Y: 168}, Size: m.Delta{DX: 128, DY: 64}},
+			"jump":   {Origin: m.Pos{X: 0, Y: 296}, Size: m.Delta{DX: 64, DY: 64}},
+			"action": {},
+			"exit":   {Origin: m.Pos{X: 512, Y: 0}, Size: m.Delta{DX: 128, DY: 64}},
+		},
+	},
+	"compact": {
+		Opacity: 0.5,
+		Scale:   0.75,
+		Rects:   touchStandardRects,
+	},
+	"tablet": {
+		// On tablets, the thumbs rest further up the sides.
+		Opacity: 0.75,
+		Scale:   1,
+		Rects: map[string]m.Rect{
+			"left":   {Origin: m.Pos{X: 0, Y: 168}, Size: m.Delta{DX: 64, DY: 64}},
+			"right":  {Origin: m.Pos{X: 64, Y: 168}, Size: m.Delta{DX: 64, DY: 64}},
+			"down":   {Origin: m.Pos{X: 0, Y: 232}, Size: m.Delta{DX: 128, DY: 64}},
+			"up":     {Origin: m.Pos{X: 0, Y: 104}, Size: m.Delta{DX: 128, DY: 64}},
+			"jump":   {Origin: m.Pos{X: 576, Y: 232}, Size: m.Delta{DX: 64, DY: 64}},
+			"action": {Origin: m.Pos{X: 576, Y: 168}, Size: m.Delta{DX: 64, DY: 64}},
+			"exit":   {Origin: m.Pos{X: 0, Y: 0}, Size: m.Delta{DX: 128, DY: 64}},
+		},
+	},
+	"large_buttons": {
+		Opacity: 1,
+		Scale:   1.25,
+		Rects:   touchStandardRects,
+	},
+}
+
+// touchAspectRatios are the aspect ratios layouts are stored for.
+var touchAspectRatios = []struct {
+	name  string
+	ratio float64
+}{
+	{"1:1", 1},
+	{"5:4", 5.0 / 4.0},
+	{"4:3", 4.0 / 3.0},
+	{"3:2", 3.0 / 2.0},
+	{"16:10", 16.0 / 10.0},
+	{"16:9", 16.0 / 9.0},
+	{"2:1", 2},
+	{"20:9", 20.0 / 9.0},
+	{"21:9", 21.0 / 9.0},
+}
+
+var (
+	// touchAspect is the aspect ratio of the screen.
+	touchAspect = "16:9"
+	// touchActiveOpacity is the opacity of the active layout.
+	touchActiveOpacity = 1.0
+)
+
+// touchAspectName returns the closest known aspect ratio of a screen.
+func touchAspectName(screenWidth, screenHeight int) string {
+	if screenWidth <= 0 || screenHeight <= 0 {
+		return touchAspect
+	}
+	ratio := float64(screenWidth) / float64(screenHeight)
+	best, bestDist := "", math.Inf(+1)
+	for _, a := range touchAspectRatios {
+		dist := math.Abs(math.Log(ratio / a.ratio))
+		if dist < bestDist {
+			best, bestDist = a.name, dist
+		}
+	}
+	return best
+}
+
+// TouchAspect returns the screen aspect ratio the touch layout is selected for.
+func TouchAspect() string {
+	return touchAspect
+}
+
+// TouchLayoutName returns the name of the touch layout for the current aspect ratio.
+func TouchLayoutName() string {
+	name := (*touchLayout)[touchAspect]
+	if _, found := touchLayoutPresets[name]; !found {
+		return TouchLayoutCustom
+	}
+	return name
+}
+
+// touchFlagLayout returns the layout defined by the touch_rect_* flags.
+func touchFlagLayout() *TouchLayout {
+	l := &TouchLayout{
+		Opacity: 1,
+		Scale:   1,
+		Rects:   map[string]m.Rect{},
+	}
+	for _, i := range impulses {
+		if i.touchRect == nil {
+			continue
+		}
+		l.Rects[strings.ToLower(i.Name)] = *i.touchRect
+	}
+	return l
+}
+
+// touchCustomLayout returns the custom layout of an aspect ratio.
+func touchCustomLayout(aspect string) *TouchLayout {
+	if l, found := (*touchCustomLayouts)[aspect]; found {
+		return l
+	}
+	return touchFlagLayout()
+}
+
+// touchLayoutFor returns the touch layout selected for an aspect ratio.
+func touchLayoutFor(aspect string) *TouchLayout {
+	preset, found := touchLayoutPresets[(*touchLayout)[aspect]]
+	if !found {
+		return touchCustomLayout(aspect)
+	}
+	return preset
+}
+
+// CurrentTouchLayout returns the touch layout for the current aspect ratio.
+// It must not be modified.
+func CurrentTouchLayout() *TouchLayout {
+	return touchLayoutFor(touchAspect)
+}
+
+// clone returns a deep copy of the layout.
+func (l *TouchLayout) clone() *TouchLayout {
+	c := *l
+	c.Rects = make(map[string]m.Rect, len(l.Rects))
+	for name, r := range l.Rects {
+		c.Rects[name] = r
+	}
+	return &c
+}
+
+// validate checks whether the layout can be used.
+func (l *TouchLayout) validate() error {
+	for name := range l.Rects {
+		if _, found := touchStandardRects[name]; !found {
+			return fmt.Errorf("unknown touch control %q", name)
+		}
+	}
+	if l.Opacity <= 0 || l.Opacity > 1 {
+		return fmt.Errorf("invalid touch control opacity: got %v, want in (0, 1]", l.Opacity)
+	}
+	if l.Scale <= 0 {
+		return fmt.Errorf("invalid touch control scale: got %v, want > 0", l.Scale)
+	}
+	return nil
+}
+
+// rect returns a scaled touch rectangle.
+func (l *TouchLayout) rect(name string, gameWidth, gameHeight int) m.Rect {
+	r := l.Rects[name]
+	if r.Size.IsZero() || l.Scale == 1 {
+		return r
+	}
+	// Scale the distance to the closest edges too, so controls grow towards the center.
+	scale := func(origin, size, total int) (int, int) {
+		newSize := int(math.Round(float64(size) * l.Scale))
+		if 2*origin+size < total {
+			return int(math.Round(float64(origin) * l.Scale)), newSize
+		}
+		end := int(math.Round(float64(total-origin-size) * l.Scale))
+		return total - end - newSize, newSize
+	}
+	r.Origin.X, r.Size.DX = scale(r.Origin.X, r.Size.DX, gameWidth)
+	r.Origin.Y, r.Size.DY = scale(r.Origin.Y, r.Size.DY, gameHeight)
+	return r
+}
+
+// touchApplyLayout updates the touch rectangles in use.
+// The editor always shows the unscaled layout.
+func touchApplyLayout(screenWidth, screenHeight, gameWidth, gameHeight int) {
+	touchAspect = touchAspectName(screenWidth, screenHeight)
+	l := CurrentTouchLayout()
+	editing := touchEditPad
+	touchActiveOpacity = l.Opacity
+	if editing {
+		touchActiveOpacity = 1
+	}
+	for _, i := range impulses {
+		if i.touchRect == nil {
+			continue
+		}
+		name := strings.ToLower(i.Name)
+		if editing {
+			i.touchActiveRect = l.Rects[name]
+		} else {
+			i.touchActiveRect = l.rect(name, gameWidth, gameHeight)
+		}
+	}
+}
+
+// setTouchLayoutName selects a touch layout for an aspect ratio.
+func setTouchLayoutName(aspect, name string) error {
+	layouts := map[string]string{}
+	for a, l := range *touchLayout {
+		layouts[a] = l
+	}
+	if name == TouchLayoutCustom {
+		delete(layouts, aspect)
+	} else {
+		layouts[aspect] = name
+	}
+	aspects := make([]string, 0, len(layouts))
+	for a := range layouts {
+		aspects = append(aspects, a)
+	}
+	sort.Strings(aspects)
+	entries := make([]string, len(aspects))
+	for i, a := range aspects {
+		entries[i] = a + "=" + layouts[a]
+	}
+	return flag.Set("touch_layout", strings.Join(entries, ","))
+}
+
+// SetTouchLayoutName selects a touch layout for the current aspect ratio.
+func SetTouchLayoutName(name string) error {
+	if _, found := touchLayoutPresets[name]; !found && name != TouchLayoutCustom {
+		return fmt.Errorf("unknown touch layout %q", name)
+	}
+	return setTouchLayoutName(touchAspect, name)
+}
+
+// setCustomTouchLayout makes a layout the custom layout of an aspect ratio and selects it.
+func setCustomTouchLayout(aspect string, l *TouchLayout) error {
+	err := l.validate()
+	if err != nil {
+		return err
+	}
+	layouts := touchLayouts{}
+	for a, layout := range *touchCustomLayouts {
+		layouts[a] = layout
+	}
+	layouts[aspect] = l.clone()
+	err = flag.Set("touch_custom_layouts", layouts)
+	if err != nil {
+		return err
+	}
+	return setTouchLayoutName(aspect, TouchLayoutCustom)
+}
+
+// touchCustomize switches to the custom layout of the current aspect ratio,
+// starting from the current layout, and returns it for editing.
+func touchCustomize() (*TouchLayout, error) {
+	if l, found := (*touchCustomLayouts)[touchAspect]; found && TouchLayoutName() == TouchLayoutCustom {
+		return l, nil
+	}
+	err := setCustomTouchLayout(touchAspect, CurrentTouchLayout())
+	if err != nil {
+		return nil, err
+	}
+	return (*touchCustomLayouts)[touchAspect], nil
+}
+
+// touchResetLayout drops the custom layout of the current aspect ratio and
+// selects the standard layout for it.
+func touchResetLayout() error {
+	layouts := touchLayouts{}
+	for a, layout := range *touchCustomLayouts {
+		if a != touchAspect {
+			layouts[a] = layout
+		}
+	}
+	err := flag.Set("touch_custom_layouts", layouts)
+	if err != nil {
+		return err
+	}
+	return setTouchLayoutName(touchAspect, "standard")
+}
+
+// SetTouchLayoutOpacity changes the opacity of the current layout.
+// Built-in layouts are copied to the custom layout first.
+func SetTouchLayoutOpacity(opacity float64) error {
+	l, err := touchCustomize()
+	if err != nil {
+		return err
+	}
+	l.Opacity = opacity
+	return nil
+}
+
+// SetTouchLayoutScale changes the size of the current layout.
+// Built-in layouts are copied to the custom layout first.
+func SetTouchLayoutScale(scale float64) error {
+	l, err := touchCustomize()
+	if err != nil {
+		return err
+	}
+	l.Scale = scale
+	return nil
+}
+
+// TouchLayoutFile returns where touch layouts are exported to and imported from.
+func TouchLayoutFile() string {
+	if *touchLayoutFile != "" {
+		return *touchLayoutFile
+	}
+	return touchLayoutFileName
+}
+
+// ExportTouchLayouts writes the touch layouts of all aspect ratios that have
+// one selected or customized, and of the current one, as JSON.
+func ExportTouchLayouts() error {
+	layouts := touchLayouts{
+		touchAspect: CurrentTouchLayout(),
+	}
+	for aspect := range *touchLayout {
+		layouts[aspect] = touchLayoutFor(aspect)
+	}
+	for aspect := range *touchCustomLayouts {
+		layouts[aspect] = touchLayoutFor(aspect)
+	}
+	data, err := json.MarshalIndent(map[string]*TouchLayout(layouts), "", "\t")
+	if err != nil {
+		return fmt.Errorf("could not marshal touch layouts: %w", err)
+	}
+	if *touchLayoutFile == "" {
+		err = vfs.WriteState(vfs.SavedGames, touchLayoutFileName, data)
+		if err != nil {
+			return fmt.Errorf("could not write touch layouts: %w", err)
+		}
+		return nil
+	}
+	f, err := vfs.OSCreate(vfs.WorkDir, *touchLayoutFile)
+	if err != nil {
+		return fmt.Errorf("could not create touch layout file %v: %w", *touchLayoutFile, err)
+	}
+	_, err = f.Write(data)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("could not write touch layout file %v: %w", *touchLayoutFile, err)
+	}
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("could not write touch layout file %v: %w", *touchLayoutFile, err)
+	}
+	return nil
+}
+
+// readTouchLayouts reads the exported touch layouts.
+func readTouchLayouts() ([]byte, error) {
+	if *touchLayoutFile == "" {
+		return vfs.ReadState(vfs.SavedGames, touchLayoutFileName)
+	}
+	f, err := vfs.OSOpen(vfs.WorkDir, *touchLayoutFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// ImportTouchLayouts replaces the custom touch layouts of all aspect ratios in
+// the exported file, and selects them. Other aspect ratios keep their layouts.
+func ImportTouchLayouts() error {
+	data, err := readTouchLayouts()
+	if err != nil {
+		return fmt.Errorf("could not read touch layouts: %w", err)
+	}
+	var layouts touchLayouts
+	err = layouts.UnmarshalText(data)
+	if err != nil {
+		return fmt.Errorf("could not parse touch layouts: %w", err)
+	}
+	aspects := make([]string, 0, len(layouts))
+	for aspect := range layouts {
+		aspects = append(aspects, aspect)
+	}
+	sort.Strings(aspects)
+	for _, aspect := range aspects {
+		err := setCustomTouchLayout(aspect, layouts[aspect])
+		if err != nil {
+			return fmt.Errorf("could not import touch layout for %v: %w", aspect, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+func resetTouchLayouts(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"touch_layout", "touch_custom_layouts", "touch_layout_file"} {
+		err := flag.ResetFlagToDefault(name)
+		if err != nil {
+			t.Fatalf("could not reset %v: %v", name, err)
+		}
+	}
+	touchAspect = "16:9"
+}
+
+func TestTouchLeftHanded(t *testing.T) {
+	const gameWidth = 640
+	want := map[string]m.Rect{}
+	for name, r := range touchStandardRects {
+		if !r.Size.IsZero() {
+			r.Origin.X = gameWidth - r.Origin.X - r.Size.DX
+		}
+		want[name] = r
+	}
+	want["left"], want["right"] = want["right"], want["left"]
+	if diff := cmp.Diff(want, touchLayoutPresets["left_handed"].Rects); diff != "" {
+		t.Errorf("left handed layout is not the mirrored standard layout (-want +got):\n%v", diff)
+	}
+}
+
+func TestTouchLayoutPerAspect(t *testing.T) {
+	resetTouchLayouts(t)
+	defer resetTouchLayouts(t)
+
+	err := SetTouchLayoutScale(1.5)
+	if err != nil {
+		t.Fatalf("could not set scale: %v", err)
+	}
+	touchAspect = "4:3"
+	if got := TouchLayoutName(); got != TouchLayoutCustom {
+		t.Errorf("4:3 layout: got %v, want %v", got, TouchLayoutCustom)
+	}
+	if got := CurrentTouchLayout().Scale; got != 1 {
+		t.Errorf("4:3 scale: got %v, want 1", got)
+	}
+	err = SetTouchLayoutName("tablet")
+	if err != nil {
+		t.Fatalf("could not select layout: %v", err)
+	}
+	touchAspect = "16:9"
+	if got := TouchLayoutName(); got != TouchLayoutCustom {
+		t.Errorf("16:9 layout: got %v, want %v", got, TouchLayoutCustom)
+	}
+	if got := CurrentTouchLayout().Scale; got != 1.5 {
+		t.Errorf("16:9 scale: got %v, want 1.5", got)
+	}
+	if diff := cmp.Diff(touchStandardRects, CurrentTouchLayout().Rects); diff != "" {
+		t.Errorf("16:9 rects differ (-want +got):\n%v", diff)
+	}
+
+	// The flags have to keep the layouts when saved.
+	text, err := touchCustomLayouts.MarshalText()
+	if err != nil {
+		t.Fatalf("could not marshal custom layouts: %v", err)
+	}
+	var saved touchLayouts
+	err = saved.UnmarshalText(text)
+	if err != nil {
+		t.Fatalf("could not unmarshal custom layouts %q: %v", text, err)
+	}
+	if diff := cmp.Diff(*touchCustomLayouts, saved); diff != "" {
+		t.Errorf("saved custom layouts differ (-want +got):\n%v", diff)
+	}
+
+	// Resetting only affects the current aspect ratio.
+	TouchResetEditor()
+	if got := TouchLayoutName(); got != "standard" {
+		t.Errorf("16:9 layout after reset: got %v, want standard", got)
+	}
+	if _, found := (*touchCustomLayouts)["16:9"]; found {
+		t.Errorf("16:9 custom layout was kept after reset")
+	}
+	if got := (*touchLayout)["4:3"]; got != "tablet" {
+		t.Errorf("4:3 layout after reset: got %v, want tablet", got)
+	}
+}
+
+func TestTouchLayoutExportImport(t *testing.T) {
+	resetTouchLayouts(t)
+	defer resetTouchLayouts(t)
+
+	err := flag.Set("touch_layout_file", filepath.Join(t.TempDir(), "layouts.json"))
+	if err != nil {
+		t.Fatalf("could not set touch_layout_file: %v", err)
+	}
+	err = SetTouchLayoutOpacity(0.5)
+	if err != nil {
+		t.Fatalf("could not set opacity: %v", err)
+	}
+	touchAspect = "4:3"
+	err = SetTouchLayoutName("tablet")
+	if err != nil {
+		t.Fatalf("could not select layout: %v", err)
+	}
+	want := map[string]*TouchLayout{
+		"16:9": touchLayoutFor("16:9").clone(),
+		"4:3":  touchLayoutFor("4:3").clone(),
+	}
+	err = ExportTouchLayouts()
+	if err != nil {
+		t.Fatalf("could not export: %v", err)
+	}
+
+	// Import on a device with other layouts.
+	for _, name := range []string{"touch_layout", "touch_custom_layouts"} {
+		flag.ResetFlagToDefault(name)
+	}
+	touchAspect = "21:9"
+	err = SetTouchLayoutName("compact")
+	if err != nil {
+		t.Fatalf("could not select layout: %v", err)
+	}
+	err = ImportTouchLayouts()
+	if err != nil {
+		t.Fatalf("could not import: %v", err)
+	}
+	for aspect, l := range want {
+		if got := (*touchLayout)[aspect]; got != "" {
+			t.Errorf("%v: got layout %q, want custom", aspect, got)
+		}
+		if diff := cmp.Diff(l, touchLayoutFor(aspect)); diff != "" {
+			t.Errorf("%v: imported layout differs (-want +got):\n%v", aspect, diff)
+		}
+	}
+	if got := TouchLayoutName(); got != "compact" {
+		t.Errorf("21:9: got layout %q, want compact", got)
+	}
+}
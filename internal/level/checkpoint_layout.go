@@ -0,0 +1,562 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package level
+
+import (
+	"fmt"
+	"sort"
+
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+const (
+	// layoutEdgeLength is the preferred length of an edge, in graphviz points.
+	layoutEdgeLength = 720
+	// layoutDeadEndLength is the preferred length of an edge to a dead end.
+	layoutDeadEndLength = 1080
+	// layoutBoxSize is the minimum distance between two checkpoints on each axis.
+	layoutBoxSize = 216
+	// layoutStraightness is how many times longer an edge must be along its direction than across it.
+	layoutStraightness = 2
+	// layoutLabelDepth is how many other edges may be redirected to give an edge a direction.
+	layoutLabelDepth = 3
+	// layoutIterations is the maximum number of relaxation steps.
+	layoutIterations = 10000
+)
+
+// layoutEdge is an edge of the checkpoint graph.
+type layoutEdge struct {
+	from, to int
+	// hint is the direction given by the next_* property.
+	hint m.Delta
+	// levelDelta is the vector between the checkpoints in the level.
+	levelDelta m.Delta
+	// deadEnd edges are not shown as map screen directions, so they have no constraints.
+	deadEnd bool
+	// dir is the direction chosen for the edge.
+	dir m.Delta
+}
+
+// candidates returns the possible directions of an edge, most preferred first.
+func (e *layoutEdge) candidates() []m.Delta {
+	across := m.Delta{DX: e.hint.DY, DY: -e.hint.DX}
+	if e.levelDelta.Dot(across) < 0 {
+		across = across.Mul(-1)
+	}
+	return []m.Delta{e.hint, across, across.Mul(-1), e.hint.Mul(-1)}
+}
+
+// edgeLabeler assigns directions to edges so that no checkpoint has two edges
+// in the same direction.
+type edgeLabeler struct {
+	edges []layoutEdge
+}
+
+// blockers returns the edges that prevent edge i from using the given direction.
+func (l *edgeLabeler) blockers(i int, dir m.Delta) []int {
+	e := &l.edges[i]
+	var blockers []int
+	for j := range l.edges {
+		f := &l.edges[j]
+		if j == i || f.deadEnd || f.dir.IsZero() {
+			continue
+		}
+		if (f.from == e.from && f.dir == dir) || (f.to == e.from && f.dir == dir.Mul(-1)) ||
+			(f.from == e.to && f.dir == dir.Mul(-1)) || (f.to == e.to && f.dir == dir) {
+			blockers = append(blockers, j)
+		}
+	}
+	return blockers
+}
+
+// before returns whether checkpoint a is required to be before b on the axis of dir.
+func (l *edgeLabeler) before(a, b int, dir m.Delta) bool {
+	seen := map[int]bool{a: true}
+	queue := []int{a}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		if i == b {
+			return true
+		}
+		for j := range l.edges {
+			f := &l.edges[j]
+			if f.deadEnd || f.dir.IsZero() {
+				continue
+			}
+			next := -1
+			if f.from == i && f.dir == dir {
+				next = f.to
+			} else if f.to == i && f.dir == dir.Mul(-1) {
+				next = f.from
+			}
+			if next >= 0 && !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return false
+}
+
+// possible returns whether edge i can use the given direction without
+// contradicting the order of checkpoints implied by the other edges.
+func (l *edgeLabeler) possible(i int, dir m.Delta) bool {
+	e := &l.edges[i]
+	return !l.before(e.to, e.from, dir)
+}
+
+// place assigns a direction to edge i, moving up to depth other edges out of the way if needed.
+func (l *edgeLabeler) place(i, depth int) bool {
+	e := &l.edges[i]
+	for _, dir := range e.candidates() {
+		if len(l.blockers(i, dir)) == 0 && l.possible(i, dir) {
+			e.dir = dir
+			return true
+		}
+	}
+	if depth == 0 {
+		return false
+	}
+	for _, dir := range e.candidates() {
+		blockers := l.blockers(i, dir)
+		if len(blockers) == 0 {
+			continue
+		}
+		saved := make([]m.Delta, len(l.edges))
+		for j := range l.edges {
+			saved[j] = l.edges[j].dir
+		}
+		for _, j := range blockers {
+			l.edges[j].dir = m.Delta{}
+		}
+		ok := l.possible(i, dir)
+		if ok {
+			e.dir = dir
+			for _, j := range blockers {
+				if !l.place(j, depth-1) {
+					ok = false
+					break
+				}
+			}
+		}
+		if ok {
+			return true
+		}
+		for j := range l.edges {
+			l.edges[j].dir = saved[j]
+		}
+	}
+	return false
+}
+
+// label assigns directions to all edges, preferring the hints.
+func (l *edgeLabeler) label() error {
+	// First give edges their hint where that is not in conflict. Edges
+	// whose hint matches the level best go first.
+	order := make([]int, 0, len(l.edges))
+	for i := range l.edges {
+		e := &l.edges[i]
+		if e.deadEnd {
+			e.dir = e.hint
+			continue
+		}
+		order = append(order, i)
+	}
+	agreement := func(e *layoutEdge) fraction {
+		return fraction{e.levelDelta.Dot(e.hint), max(e.levelDelta.Norm1(), 1)}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return agreement(&l.edges[order[b]]).Less(agreement(&l.edges[order[a]]))
+	})
+	var conflicts []int
+	for _, i := range order {
+		e := &l.edges[i]
+		if len(l.blockers(i, e.hint)) == 0 && l.possible(i, e.hint) {
+			e.dir = e.hint
+		} else {
+			conflicts = append(conflicts, i)
+		}
+	}
+	// Then resolve the conflicts.
+	for _, i := range conflicts {
+		if !l.place(i, layoutLabelDepth) {
+			return fmt.Errorf("could not find a free direction for checkpoint edge %d -> %d", l.edges[i].from, l.edges[i].to)
+		}
+	}
+	return nil
+}
+
+// checkpointLayout is the checkpoint graph as laid out for the map screen.
+type checkpointLayout struct {
+	names []string
+	pos   []m.Pos
+	edges []layoutEdge
+}
+
+// layoutCheckpoints lays out the checkpoint graph for the map screen.
+//
+// Every edge prefers the direction of the next_* property that created it.
+// As the map screen can only go one way per direction, each edge first gets
+// a direction so that no two edges of a checkpoint share one, deviating from
+// the hints as little as possible. Then, starting from the checkpoint
+// positions in the level, the checkpoints are moved until every edge mainly
+// points in its direction and no checkpoints overlap.
+//
+// All computation is done in integers, so the result and thus the checkpoint
+// locations hash are the same on every platform.
+func (l *Level) layoutCheckpoints() (*checkpointLayout, error) {
+	var names []string
+	for name := range l.Checkpoints {
+		if name == "" {
+			// Not a real CP, but the player initial spawn.
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	index := make(map[EntityID]int, len(names))
+	pos := make([]m.Pos, len(names))
+	deadEnd := make([]bool, len(names))
+	var parseErr error
+	for i, name := range names {
+		cp := l.Checkpoints[name]
+		index[cp.ID] = i
+		pos[i] = cp.LevelPos.Mul(TileSize).Add(cp.RectInTile.Center().Delta(m.Pos{}))
+		deadEnd[i] = propmap.ValueOrP(cp.Properties, "dead_end", false, &parseErr)
+	}
+
+	var edges []layoutEdge
+	type pair struct{ a, b int }
+	seen := map[pair]bool{}
+	for i, name := range names {
+		cp := l.Checkpoints[name]
+		for _, conn := range []struct {
+			name string
+			dir  m.Delta
+		}{
+			{"next_left", m.West()},
+			{"next_right", m.East()},
+			{"next_up", m.North()},
+			{"next_down", m.South()},
+		} {
+			id := propmap.ValueOrP(cp.Properties, conn.name, -1, &parseErr)
+			if id == -1 {
+				continue
+			}
+			j, found := index[EntityID(id)]
+			if !found {
+				return nil, fmt.Errorf("next checkpoint ID for %q property %q is not a checkpoint", name, conn.name)
+			}
+			if seen[pair{i, j}] || seen[pair{j, i}] {
+				// Already linked from the other side.
+				continue
+			}
+			seen[pair{i, j}] = true
+			edges = append(edges, layoutEdge{
+				from:       i,
+				to:         j,
+				hint:       conn.dir,
+				levelDelta: pos[j].Delta(pos[i]),
+				deadEnd:    deadEnd[i] || deadEnd[j],
+			})
+		}
+	}
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	err := (&edgeLabeler{edges: edges}).label()
+	if err != nil {
+		return nil, err
+	}
+
+	// Initial placement: the level positions, scaled so that edges have
+	// about their preferred length.
+	levelLength := 0
+	for _, edge := range edges {
+		levelLength += edge.levelDelta.Norm1()
+	}
+	if levelLength > 0 {
+		for i := range pos {
+			pos[i] = m.Pos{}.Add(pos[i].Delta(m.Pos{}).MulFrac(layoutEdgeLength*len(edges), levelLength))
+		}
+	}
+
+	// Relaxation. This need not converge; the directions are already decided,
+	// so the positions only affect how nice the map screen looks.
+	for iter := 0; iter < layoutIterations; iter++ {
+		changed := false
+		move := func(i, j int, d m.Delta) {
+			if d.IsZero() {
+				return
+			}
+			pos[i] = pos[i].Sub(d)
+			pos[j] = pos[j].Add(d)
+			changed = true
+		}
+		for _, edge := range edges {
+			d := pos[edge.to].Delta(pos[edge.from])
+			acrossDir := m.Delta{DX: edge.dir.DY, DY: -edge.dir.DX}
+			if edge.deadEnd {
+				// Just pull the dead end towards its preferred place.
+				move(edge.from, edge.to, edge.dir.Mul(layoutDeadEndLength).Sub(d).Div(8))
+				continue
+			}
+			// The edge must mainly point in its direction, i.e. be inside the
+			// cone along >= layoutStraightness * |across|. Project onto it.
+			along, across := d.Dot(edge.dir), d.Dot(acrossDir)
+			absAcross := max(across, -across)
+			if along >= layoutBoxSize && along >= layoutStraightness*absAcross {
+				continue
+			}
+			wantAlong, wantAbsAcross := along, absAcross
+			if along < layoutStraightness*absAcross {
+				t := max(0, (along*layoutStraightness+absAcross)/(layoutStraightness*layoutStraightness+1))
+				wantAlong, wantAbsAcross = layoutStraightness*t, t
+			}
+			wantAlong = max(wantAlong, layoutBoxSize)
+			wantAcross := wantAbsAcross
+			if across < 0 {
+				wantAcross = -wantAcross
+			}
+			move(edge.from, edge.to, edge.dir.Mul((wantAlong-along+1)/2).Add(acrossDir.Mul((wantAcross-across)/2)))
+		}
+		// Push overlapping checkpoints apart along the axis of least overlap.
+		for i := range names {
+			for j := i + 1; j < len(names); j++ {
+				d := pos[j].Delta(pos[i])
+				overlapX := layoutBoxSize - max(d.DX, -d.DX)
+				overlapY := layoutBoxSize - max(d.DY, -d.DY)
+				if overlapX <= 0 || overlapY <= 0 {
+					continue
+				}
+				var push m.Delta
+				if overlapX <= overlapY {
+					push.DX = (overlapX + 1) / 2
+					if d.DX < 0 || (d.DX == 0 && i%2 == 1) {
+						push.DX = -push.DX
+					}
+				} else {
+					push.DY = (overlapY + 1) / 2
+					if d.DY < 0 || (d.DY == 0 && i%2 == 1) {
+						push.DY = -push.DY
+					}
+				}
+				move(i, j, push)
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	return &checkpointLayout{
+		names: names,
+		pos:   pos,
+		edges: edges,
+	}, nil
+}
+
+// GenerateCheckpointLocations lays out the checkpoints natively, without
+// needing graphviz. Unlike with the generated file, the directions of the
+// edges are taken from the layout directly rather than inferred from the
+// positions.
+func (l *Level) GenerateCheckpointLocations(filename string) (*CheckpointLocations, error) {
+	layout, err := l.layoutCheckpoints()
+	if err != nil {
+		return nil, fmt.Errorf("could not lay out checkpoints for %q: %w", filename, err)
+	}
+	loc := &CheckpointLocations{
+		Locs: map[string]*CheckpointLocation{},
+	}
+	var minPos, maxPos m.Pos
+	for i, name := range layout.names {
+		pos := layout.pos[i]
+		if i == 0 {
+			minPos, maxPos = pos, pos
+		}
+		minPos = m.Pos{X: min(minPos.X, pos.X), Y: min(minPos.Y, pos.Y)}
+		maxPos = m.Pos{X: max(maxPos.X, pos.X), Y: max(maxPos.Y, pos.Y)}
+		loc.Locs[name] = &CheckpointLocation{
+			MapPos:    pos,
+			NextByDir: map[m.Delta]CheckpointEdge{},
+		}
+	}
+	loc.Rect = m.Rect{
+		Origin: minPos,
+		Size:   maxPos.Delta(minPos),
+	}
+	// The map screen divides by the size, so avoid degenerate layouts.
+	loc.Rect.Size.DX = max(loc.Rect.Size.DX, 1)
+	loc.Rect.Size.DY = max(loc.Rect.Size.DY, 1)
+
+	for _, edge := range layout.edges {
+		a, b := layout.names[edge.from], layout.names[edge.to]
+		la, lb := loc.Locs[a], loc.Locs[b]
+		if edge.deadEnd {
+			la.NextDeadEnds = append(la.NextDeadEnds, CheckpointEdge{
+				Other:   b,
+				Forward: true,
+			})
+			lb.NextDeadEnds = append(lb.NextDeadEnds, CheckpointEdge{
+				Other:   a,
+				Forward: false,
+			})
+			continue
+		}
+		la.NextByDir[edge.dir] = CheckpointEdge{
+			Other:   b,
+			Forward: true,
+		}
+		lb.NextByDir[edge.dir.Mul(-1)] = CheckpointEdge{
+			Other:   a,
+			Forward: false,
+		}
+	}
+
+	// Finally fill up the keyboard directions, like loadCheckpointLocations does.
+	assignOptionalEdge := func(a, b string, dir m.Delta) {
+		la := loc.Locs[a]
+		if _, found := la.NextByDir[dir]; !found {
+			la.NextByDir[dir] = CheckpointEdge{
+				Other:    b,
+				Forward:  true,
+				Optional: true,
+			}
+		}
+		lb := loc.Locs[b]
+		revDir := dir.Mul(-1)
+		if _, found := lb.NextByDir[revDir]; !found {
+			lb.NextByDir[revDir] = CheckpointEdge{
+				Other:    a,
+				Forward:  false,
+				Optional: true,
+			}
+		}
+	}
+	for _, edge := range layout.edges {
+		if edge.deadEnd {
+			continue
+		}
+		a, b := layout.names[edge.from], layout.names[edge.to]
+		delta := loc.Locs[b].MapPos.Delta(loc.Locs[a].MapPos)
+		bestDir, otherDir := possibleDirs(delta)
+		assignOptionalEdge(a, b, bestDir)
+		assignOptionalEdge(a, b, otherDir)
+	}
+
+	return loc, nil
+}
+
+// CheckpointLayoutMismatches returns the edges of the checkpoint graph whose
+// direction on the map screen does not match the property that defined them.
+// Edges to or from dead ends are not checked, as they have no direction.
+// Neither are edges whose direction is also wanted by another edge of the same
+// checkpoint, as only one of them can have it, nor edges on a loop of edges all
+// going the same direction, as such a loop cannot be drawn.
+func (l *Level) CheckpointLayoutMismatches() ([]string, error) {
+	id2name := map[EntityID]string{}
+	for name, cp := range l.Checkpoints {
+		if name == "" {
+			// Not a real CP, but the player initial spawn.
+			continue
+		}
+		id2name[cp.ID] = name
+	}
+	type hint struct {
+		name, other string
+		prop        string
+		dir         m.Delta
+	}
+	type slot struct {
+		name string
+		dir  m.Delta
+	}
+	var hints []hint
+	wanted := map[slot]map[string]bool{}
+	want := func(name string, dir m.Delta, other string) {
+		s := slot{name, dir}
+		if wanted[s] == nil {
+			wanted[s] = map[string]bool{}
+		}
+		wanted[s][other] = true
+	}
+	var parseErr error
+	for name, cp := range l.Checkpoints {
+		if name == "" {
+			// Not a real CP, but the player initial spawn.
+			continue
+		}
+		if propmap.ValueOrP(cp.Properties, "dead_end", false, &parseErr) {
+			continue
+		}
+		for _, conn := range []struct {
+			name string
+			dir  m.Delta
+		}{
+			{"next_left", m.West()},
+			{"next_right", m.East()},
+			{"next_up", m.North()},
+			{"next_down", m.South()},
+		} {
+			id := propmap.ValueOrP(cp.Properties, conn.name, -1, &parseErr)
+			if id == -1 {
+				continue
+			}
+			other := id2name[EntityID(id)]
+			if other == "" || propmap.ValueOrP(l.Checkpoints[other].Properties, "dead_end", false, &parseErr) {
+				continue
+			}
+			hints = append(hints, hint{name, other, conn.name, conn.dir})
+			want(name, conn.dir, other)
+			want(other, conn.dir.Mul(-1), name)
+		}
+	}
+	// inLoop returns whether the hints in direction h.dir lead from h.other back to h.name.
+	inLoop := func(h hint) bool {
+		seen := map[string]bool{h.other: true}
+		queue := []string{h.other}
+		for len(queue) > 0 {
+			cur := queue[0]
+			queue = queue[1:]
+			if cur == h.name {
+				return true
+			}
+			for other := range wanted[slot{cur, h.dir}] {
+				if !seen[other] {
+					seen[other] = true
+					queue = append(queue, other)
+				}
+			}
+		}
+		return false
+	}
+	var mismatches []string
+	for _, h := range hints {
+		if len(wanted[slot{h.name, h.dir}]) > 1 || len(wanted[slot{h.other, h.dir.Mul(-1)}]) > 1 || inLoop(h) {
+			// Some edge has to give way.
+			continue
+		}
+		loc := l.CheckpointLocations.Locs[h.name]
+		if edge, found := loc.NextByDir[h.dir]; found && edge.Other == h.other && !edge.Optional {
+			continue
+		}
+		mismatches = append(mismatches, fmt.Sprintf("%v %v", h.name, h.prop))
+	}
+	sort.Strings(mismatches)
+	return mismatches, parseErr
+}
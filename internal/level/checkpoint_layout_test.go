@@ -0,0 +1,90 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package level
+
+import (
+	"os"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/mitchellh/hashstructure/v2"
+
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+func TestMain(m *testing.M) {
+	// The VFS finds the assets relative to the source root.
+	err := os.Chdir("../..")
+	if err != nil {
+		panic(err)
+	}
+	err = vfs.Init()
+	if err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// generateCheckpointLocations loads the named level and lays out its checkpoints natively.
+func generateCheckpointLocations(t *testing.T, name string) *Level {
+	t.Helper()
+	lvl, err := NewLoader(name).SkipCheckpointLocations(true).Load()
+	if err != nil {
+		t.Fatalf("could not load level %v: %v", name, err)
+	}
+	lvl.CheckpointLocations, err = lvl.GenerateCheckpointLocations(name)
+	if err != nil {
+		t.Fatalf("could not lay out checkpoints of level %v: %v", name, err)
+	}
+	return lvl
+}
+
+func TestGenerateCheckpointLocations(t *testing.T) {
+	for _, c := range []struct {
+		level string
+		hash  uint64
+		// mismatches are the edges that cannot get their direction. In the
+		// main level, all four directions of the_hub are wanted by other
+		// edges, and the one from pre_hub_2 shares its direction with another
+		// edge at pre_hub_2, so some edge of the_hub has to give way.
+		mismatches []string
+	}{
+		{"level", 2993703158037748660, []string{"the_hub next_left"}},
+		{"level2", 16162743822032777946, nil},
+	} {
+		t.Run(c.level, func(t *testing.T) {
+			a := generateCheckpointLocations(t, c.level)
+			b := generateCheckpointLocations(t, c.level)
+			if !reflect.DeepEqual(a.CheckpointLocations, b.CheckpointLocations) {
+				t.Errorf("laying out checkpoints twice gave different results")
+			}
+			hash, err := hashstructure.Hash(a.CheckpointLocations, hashstructure.FormatV2, nil)
+			if err != nil {
+				t.Fatalf("could not hash checkpoint locations: %v", err)
+			}
+			if hash != c.hash {
+				t.Errorf("checkpoint locations hash: got %v, want %v", hash, c.hash)
+			}
+			mismatches, err := a.CheckpointLayoutMismatches()
+			if err != nil {
+				t.Fatalf("could not check checkpoint layout: %v", err)
+			}
+			if !slices.Equal(mismatches, c.mismatches) {
+				t.Errorf("checkpoint layout mismatches: got %v, want %v", mismatches, c.mismatches)
+			}
+		})
+	}
+}
@@ -155,6 +155,35 @@ func (l *Level) LoadCheckpointLocations(filename string) (*CheckpointLocations,
 	return loc0, err0
 }
 
+// FallbackCheckpointLocations lays out the checkpoints by their position in the level.
+// This is used for levels that come without a generated checkpoint locations file.
+func (l *Level) FallbackCheckpointLocations(filename string) (*CheckpointLocations, error) {
+	var g JSONCheckpointGraph
+	for name, cp := range l.Checkpoints {
+		if name == "" {
+			// Not a real CP, but the player initial spawn.
+			continue
+		}
+		pos := cp.LevelPos.Mul(TileSize).Add(cp.RectInTile.Center().Delta(m.Pos{}))
+		// Note: reverse Y coordinate between graphviz and ebiten.
+		g.Objects = append(g.Objects, JSONCheckpointObject{
+			Name: name,
+			Pos:  fmt.Sprintf("%d,%d", pos.X, -pos.Y),
+		})
+	}
+	sort.Slice(g.Objects, func(i, j int) bool {
+		return g.Objects[i].Name < g.Objects[j].Name
+	})
+	loc, err := l.loadCheckpointLocations(filename, g, m.Delta{DX: 1, DY: 0}, m.Delta{DX: 0, DY: 1})
+	if err != nil {
+		return nil, err
+	}
+	// The map screen divides by the size, so avoid degenerate layouts.
+	loc.Rect.Size.DX = max(loc.Rect.Size.DX, 1)
+	loc.Rect.Size.DY = max(loc.Rect.Size.DY, 1)
+	return loc, nil
+}
+
 // loadCheckpointLocations loads the checkpoint locations for the given level, possibly with a matrix transform.
 func (l *Level) loadCheckpointLocations(filename string, g JSONCheckpointGraph, right, down m.Delta) (*CheckpointLocations, error) {
 	id2name := map[EntityID]string{}
@@ -0,0 +1,231 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package level
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/fardog/tmx"
+
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+// TileLayer is a decoration tile layer drawn in addition to the collision layer.
+//
+// Only the collision layer affects gameplay; warpzones and visibility are
+// computed from it alone. Decoration layers merely add images to the tiles.
+type TileLayer struct {
+	Name string
+	// Z is the drawing order relative to the collision layer, which has Z 0.
+	Z int
+	// Foreground layers are drawn on top of entities.
+	Foreground bool
+	// Alpha is the layer opacity.
+	Alpha float64
+}
+
+// TileOverlay is the image a decoration layer has at a tile.
+type TileOverlay struct {
+	Layer       *TileLayer
+	Orientation m.Orientation
+	ImageSrc    string
+
+	// Same as Tile.imageSrcByOrientation.
+	imageSrcByOrientation map[m.Orientation]string
+}
+
+// ImageLayer is a static backdrop image drawn behind all tiles.
+type ImageLayer struct {
+	Name string
+	// Purpose and ImageSrc are the arguments to image.Load.
+	Purpose  string
+	ImageSrc string
+	// Offset is the screen position of the image.
+	Offset m.Delta
+	// Parallax is how much the image follows scrolling; 0 keeps it fixed on the screen.
+	Parallax float64
+	// Alpha is the layer opacity.
+	Alpha float64
+}
+
+// layerAlpha returns the opacity of a layer.
+// Tiled omits the attribute for fully opaque layers, so zero means opaque.
+func layerAlpha(opacity float32) float64 {
+	if opacity == 0 {
+		return 1
+	}
+	return float64(opacity)
+}
+
+// collisionLayer returns the index of the tile layer that defines gameplay.
+// This is the layer with the "collision" property, or the first layer.
+func collisionLayer(layers []tmx.Layer) (int, error) {
+	found := -1
+	for i := range layers {
+		prop := layers[i].Properties.WithName("collision")
+		if prop == nil {
+			continue
+		}
+		is, err := layers[i].Properties.Bool("collision")
+		if err != nil {
+			return 0, fmt.Errorf("could not parse collision property of layer %q: %w", layers[i].Name, err)
+		}
+		if !is {
+			continue
+		}
+		if found >= 0 {
+			return 0, fmt.Errorf("got more than one collision layer: %q and %q", layers[found].Name, layers[i].Name)
+		}
+		found = i
+	}
+	return max(found, 0), nil
+}
+
+// checkTileLayer verifies that a tile layer can be merged with the others.
+func checkTileLayer(layer *tmx.Layer, width, height int) error {
+	if layer.X != 0 || layer.Y != 0 {
+		return errors.New("unsupported map: layer has been shifted")
+	}
+	if layer.Width != width || layer.Height != height {
+		return fmt.Errorf("unsupported map: layer %q has size %dx%d, want %dx%d", layer.Name, layer.Width, layer.Height, width, height)
+	}
+	// layer.Opacity used for decoration layers.
+	// layer.Visible not used (we allow it though as it may help in the editor).
+	if layer.OffsetX != 0 || layer.OffsetY != 0 {
+		return errors.New("unsupported map: layer has an offset")
+	}
+	// layer.Properties used by collisionLayer and parseTileLayer.
+	// layer.RawData not used.
+	return nil
+}
+
+// parseTileLayer reads the properties of a decoration layer.
+// idx and collisionIdx are used to derive the default Z.
+func parseTileLayer(layer *tmx.Layer, idx, collisionIdx int) (*TileLayer, error) {
+	tl := &TileLayer{
+		Name:  layer.Name,
+		Z:     idx - collisionIdx,
+		Alpha: layerAlpha(layer.Opacity),
+	}
+	if layer.Properties.WithName("z") != nil {
+		z, err := layer.Properties.Int("z")
+		if err != nil {
+			return nil, fmt.Errorf("could not parse z property of layer %q: %w", layer.Name, err)
+		}
+		if z == 0 {
+			return nil, fmt.Errorf("layer %q has z 0, which is reserved for the collision layer", layer.Name)
+		}
+		tl.Z = int(z)
+	}
+	if layer.Properties.WithName("foreground") != nil {
+		fg, err := layer.Properties.Bool("foreground")
+		if err != nil {
+			return nil, fmt.Errorf("could not parse foreground property of layer %q: %w", layer.Name, err)
+		}
+		tl.Foreground = fg
+	}
+	if tl.Foreground && tl.Z < 0 {
+		return nil, fmt.Errorf("layer %q is a foreground layer below the collision layer", layer.Name)
+	}
+	return tl, nil
+}
+
+// parseImageLayer converts a Tiled image layer to a backdrop.
+// The image must be in the tiles or sprites directory so it is precached.
+func parseImageLayer(il *tmx.ImageLayer) (ImageLayer, error) {
+	if il.Image.Source == "" {
+		return ImageLayer{}, fmt.Errorf("image layer %q has no image", il.Name)
+	}
+	src := path.Clean(il.Image.Source)
+	purpose := path.Base(path.Dir(src))
+	if purpose != "tiles" && purpose != "sprites" {
+		return ImageLayer{}, fmt.Errorf("image layer %q uses image %q outside tiles or sprites", il.Name, il.Image.Source)
+	}
+	out := ImageLayer{
+		Name:     il.Name,
+		Purpose:  purpose,
+		ImageSrc: path.Base(src),
+		Offset:   m.Delta{DX: il.OffsetX + il.X, DY: il.OffsetY + il.Y},
+		Alpha:    layerAlpha(il.Opacity),
+	}
+	if il.Properties.WithName("parallax") != nil {
+		parallax, err := il.Properties.Float("parallax")
+		if err != nil {
+			return ImageLayer{}, fmt.Errorf("could not parse parallax property of image layer %q: %w", il.Name, err)
+		}
+		out.Parallax = parallax
+	}
+	return out, nil
+}
+
+// addTileLayer merges a decoration layer into the tiles of the level.
+func (l *Level) addTileLayer(layer *tmx.Layer, idx, collisionIdx int, tileSets []tmx.TileSet) error {
+	err := checkTileLayer(layer, l.width, len(l.tiles)/l.width)
+	if err != nil {
+		return err
+	}
+	tl, err := parseTileLayer(layer, idx, collisionIdx)
+	if err != nil {
+		return fmt.Errorf("unsupported map: %w", err)
+	}
+	tds, err := layer.TileDefs(tileSets)
+	if err != nil {
+		return fmt.Errorf("invalid map layer %q: %w", layer.Name, err)
+	}
+	for i, td := range tds {
+		if td.Nil {
+			continue
+		}
+		if !l.tiles[i].Valid {
+			// Tiles missing from the collision layer never get loaded.
+			continue
+		}
+		properties, orientation, err := parseTileDef(td)
+		if err != nil {
+			return fmt.Errorf("invalid map layer %q: %w", layer.Name, err)
+		}
+		imgSrc := td.Tile.Image.Source
+		imgSrcByOrientation, err := ParseImageSrcByOrientation(imgSrc, properties)
+		if err != nil {
+			return fmt.Errorf("invalid map layer %q: %w", layer.Name, err)
+		}
+		tile := &l.tiles[i].Tile
+		tile.Overlays = append(tile.Overlays, TileOverlay{
+			Layer:                 tl,
+			Orientation:           orientation,
+			ImageSrc:              imgSrc,
+			imageSrcByOrientation: imgSrcByOrientation,
+		})
+	}
+	l.TileLayers = append(l.TileLayers, tl)
+	return nil
+}
+
+// sortTileOverlays orders the overlays of a tile for drawing.
+func sortTileOverlays(overlays []TileOverlay) {
+	sort.SliceStable(overlays, func(i, j int) bool {
+		return overlays[i].Layer.Z < overlays[j].Layer.Z
+	})
+}
+
+// sortTileLayers orders the decoration layers for drawing.
+func sortTileLayers(layers []*TileLayer) {
+	sort.SliceStable(layers, func(i, j int) bool {
+		return layers[i].Z < layers[j].Z
+	})
+}
@@ -0,0 +1,104 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package level
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/fardog/tmx"
+
+	m "github.com/divVerent/aaaaxy/internal/math"
+)
+
+// loadTestMap parses a map from testdata without going through the VFS.
+func loadTestMap(t *testing.T, name string) *Level {
+	t.Helper()
+	// TestMain changes to the source root.
+	f, err := os.Open("internal/level/testdata/" + name)
+	if err != nil {
+		t.Fatalf("could not open %v: %v", name, err)
+	}
+	defer f.Close()
+	tm, err := tmx.Decode(f)
+	if err != nil {
+		t.Fatalf("could not decode %v: %v", name, err)
+	}
+	lvl, err := parseTmx(tm)
+	if err != nil {
+		t.Fatalf("could not parse %v: %v", name, err)
+	}
+	return lvl
+}
+
+func TestLayers(t *testing.T) {
+	lvl := loadTestMap(t, "layers.tmx")
+
+	// Decoration layers are sorted by Z; the default Z is relative to the collision layer.
+	wantLayers := []TileLayer{
+		{Name: "far", Z: -5, Alpha: 1},
+		{Name: "near", Z: -2, Alpha: 1},
+		{Name: "front", Z: 1, Foreground: true, Alpha: 0.5},
+	}
+	var gotLayers []TileLayer
+	for _, tl := range lvl.TileLayers {
+		gotLayers = append(gotLayers, *tl)
+	}
+	if !reflect.DeepEqual(gotLayers, wantLayers) {
+		t.Errorf("tile layers: got %+v, want %+v", gotLayers, wantLayers)
+	}
+
+	for _, c := range []struct {
+		pos      m.Pos
+		image    string
+		contents Contents
+		overlays []string
+	}{
+		{m.Pos{X: 0, Y: 0}, "air.png", 0, []string{"far:wall.png", "near:vine.png", "front:vine.png"}},
+		// The solid tile in the near layer must not affect collision.
+		{m.Pos{X: 1, Y: 0}, "air.png", 0, []string{"near:wall.png"}},
+		{m.Pos{X: 2, Y: 0}, "wall.png", SolidContents | OpaqueContents, nil},
+		{m.Pos{X: 0, Y: 1}, "wall.png", SolidContents | OpaqueContents, nil},
+	} {
+		tile := lvl.Tile(c.pos)
+		if tile == nil {
+			t.Errorf("tile %v: missing", c.pos)
+			continue
+		}
+		if tile.Tile.ImageSrc != c.image || tile.Tile.Contents != c.contents {
+			t.Errorf("tile %v: got %v %v, want %v %v", c.pos, tile.Tile.ImageSrc, tile.Tile.Contents, c.image, c.contents)
+		}
+		var overlays []string
+		for _, o := range tile.Tile.Overlays {
+			overlays = append(overlays, o.Layer.Name+":"+o.ImageSrc)
+		}
+		if !reflect.DeepEqual(overlays, c.overlays) {
+			t.Errorf("tile %v: got overlays %v, want %v", c.pos, overlays, c.overlays)
+		}
+	}
+
+	// Tiles missing from the collision layer do not exist, even if a decoration layer has them.
+	if tile := lvl.Tile(m.Pos{X: 1, Y: 1}); tile != nil {
+		t.Errorf("tile missing from the collision layer got loaded: %+v", tile)
+	}
+
+	wantImageLayers := []ImageLayer{
+		{Name: "backdrop", Purpose: "tiles", ImageSrc: "bg_8.png", Offset: m.Delta{DX: 3, DY: 4}, Parallax: 0.5, Alpha: 0.25},
+	}
+	if !reflect.DeepEqual(lvl.ImageLayers, wantImageLayers) {
+		t.Errorf("image layers: got %+v, want %+v", lvl.ImageLayers, wantImageLayers)
+	}
+}
@@ -17,6 +17,8 @@ package level
 import (
 	"errors"
 	"fmt"
+	"os"
+	"slices"
 
 	"github.com/fardog/tmx"
 	"github.com/mitchellh/hashstructure/v2"
@@ -32,7 +34,8 @@ import (
 )
 
 var (
-	debugCheckTnihSigns = flag.Bool("debug_check_tnih_signs", false, "if set, we verify that all checkpoints have a TnihSign")
+	debugCheckTnihSigns              = flag.Bool("debug_check_tnih_signs", false, "if set, we verify that all checkpoints have a TnihSign")
+	debugGenerateCheckpointLocations = flag.Bool("debug_generate_checkpoint_locations", false, "if set, checkpoint locations are laid out at load time instead of loaded from the generated file, and not compared against the level")
 )
 
 // Level is a parsed form of a loaded level.
@@ -48,8 +51,20 @@ type Level struct {
 	QuestionBlocks          []*Spawnable
 	Abilities               map[string]bool
 
+	// Splits are the checkpoints first reached in the current run, in order.
+	// Game state only, thus not part of the hash.
+	Splits []Split `hash:"-"`
+
+	// Rendering only, thus not part of the hash.
+	TileLayers  []*TileLayer `hash:"-"`
+	ImageLayers []ImageLayer `hash:"-"`
+
 	tiles []LevelTile
 	width int
+
+	// generateCheckpointLocations makes the loader lay out the checkpoints
+	// natively instead of loading the file generated using graphviz.
+	generateCheckpointLocations bool
 }
 
 // Tile returns the tile at the given position.
@@ -102,6 +117,15 @@ type SaveGameDataV1 struct {
 	LevelHash    uint64
 }
 
+// Split is the game time at which a checkpoint was first reached.
+type Split struct {
+	Checkpoint string
+	// Frames is the game time when first reaching the checkpoint.
+	Frames int
+	// Segment is the number of frames since the previous split.
+	Segment int
+}
+
 // SaveGame is the data structure we save game state with.
 // It contains all needed (in addition to loading the level) to reset to the last visited checkpoint.
 // Separate hashes govern the info parts and the state itself so demo regression testing can work across version changes.
@@ -110,6 +134,12 @@ type SaveGame struct {
 	InfoHash  uint64
 	StateHash uint64
 
+	// Splits only record timing and have their own hash, so adding them does
+	// not change the state hashes demos are checked against. They are still
+	// checked, as personal bests are computed from them.
+	Splits     []Split `json:",omitempty"`
+	SplitsHash uint64  `json:",omitempty"`
+
 	// Legacy hash for v0 save games.
 	Hash uint64 `json:",omitempty"`
 }
@@ -124,7 +154,7 @@ type SaveGameData struct {
 // SaveGame returns the current state as a SaveGame.
 func (l *Level) SaveGame() (*SaveGame, error) {
 	if l.SaveGameVersion != 1 {
-		return nil, errors.New("please FIXME! On the next SaveGameVersion, please remove the SaveGameData v0 support, make all uint64 hashes `json:\",string\"`, and remove this check too")
+		return nil, errors.New("please FIXME! On the next SaveGameVersion, please remove the SaveGameData v0 support from SaveGame.Validate (SaveGame.Migrate still converts v0 save games), make all uint64 hashes `json:\",string\"`, and remove this check too")
 	}
 	save := &SaveGame{
 		SaveGameDataV1: SaveGameDataV1{
@@ -133,6 +163,7 @@ func (l *Level) SaveGame() (*SaveGame, error) {
 			LevelVersion: l.SaveGameVersion,
 			LevelHash:    l.Hash,
 		},
+		Splits: slices.Clone(l.Splits),
 	}
 	saveOne := func(sp *Spawnable) {
 		if !propmap.Empty(sp.PersistentState) {
@@ -145,12 +176,7 @@ func (l *Level) SaveGame() (*SaveGame, error) {
 		}
 	})
 	saveOne(l.Player)
-	var err error
-	save.StateHash, err = hashstructure.Hash(save.State, hashstructure.FormatV2, nil)
-	if err != nil {
-		return nil, err
-	}
-	save.InfoHash, err = hashstructure.Hash(save.SaveGameDataV1, hashstructure.FormatV2, nil)
+	err := save.Rehash()
 	if err != nil {
 		return nil, err
 	}
@@ -190,6 +216,7 @@ func (l *Level) Clone() *Level {
 	for i, q := range l.QuestionBlocks {
 		out.QuestionBlocks[i] = clone(q)
 	}
+	out.Splits = slices.Clone(l.Splits)
 	out.tiles = make([]LevelTile, len(l.tiles))
 	for i := range l.tiles {
 		tile := &l.tiles[i]
@@ -206,34 +233,9 @@ func (l *Level) Clone() *Level {
 // LoadGame loads the given SaveGame into the map.
 // Note that when this returns an error, the SaveGame might have been partially loaded and the world may need to be reset.
 func (l *Level) LoadGame(save *SaveGame) error {
-	if save.Hash != 0 && save.InfoHash == 0 && save.StateHash == 0 {
-		saveV0 := &SaveGameData{
-			State:        save.State,
-			LevelVersion: save.LevelVersion,
-			LevelHash:    save.LevelHash,
-		}
-		saveHash, err := hashstructure.Hash(saveV0, hashstructure.FormatV2, nil)
-		if err != nil {
-			return err
-		}
-		if saveHash != save.Hash {
-			return fmt.Errorf("someone tampered with the save game: got %v, want %v", saveHash, save.Hash)
-		}
-	} else {
-		infoHash, err := hashstructure.Hash(save.SaveGameDataV1, hashstructure.FormatV2, nil)
-		if err != nil {
-			return err
-		}
-		if infoHash != save.InfoHash {
-			return errors.New("someone tampered with the save game info")
-		}
-		stateHash, err := hashstructure.Hash(save.State, hashstructure.FormatV2, nil)
-		if err != nil {
-			return err
-		}
-		if stateHash != save.StateHash {
-			return errors.New("someone tampered with the save game state")
-		}
+	err := save.Validate()
+	if err != nil {
+		return err
 	}
 	if save.GameVersion != version.Revision() {
 		log.Warningf("save game does not match game version: got %v, want %v", save.GameVersion, version.Revision())
@@ -262,6 +264,7 @@ func (l *Level) LoadGame(save *SaveGame) error {
 		}
 	})
 	loadOne(l.Player)
+	l.Splits = slices.Clone(save.Splits)
 	return nil
 }
 
@@ -341,6 +344,64 @@ func FetchTileset(ts *tmx.TileSet) error {
 	return nil
 }
 
+// parseTileDef validates a tile reference and returns its properties and orientation.
+func parseTileDef(td *tmx.TileDef) (propmap.Map, m.Orientation, error) {
+	if td.Tile == nil {
+		return propmap.Map{}, m.Orientation{}, fmt.Errorf("invalid tiledef: %v [%s]", td, td.TileSet.Source)
+	}
+	// td.Tile.Probability not used (editor only).
+	// td.Tile.Properties used later.
+	// td.Tile.Image used later.
+	if len(td.Tile.Animation) != 0 {
+		return propmap.Map{}, m.Orientation{}, errors.New("unsupported tileset: got an animation")
+	}
+	if len(td.Tile.ObjectGroup.Objects) != 0 {
+		return propmap.Map{}, m.Orientation{}, errors.New("unsupported tileset: got objects in a tile")
+	}
+	// td.Tile.RawTerrainType not used (editor only).
+	orientation := m.Identity()
+	if td.HorizontallyFlipped {
+		orientation = m.FlipX().Concat(orientation)
+	}
+	if td.VerticallyFlipped {
+		orientation = m.FlipY().Concat(orientation)
+	}
+	if td.DiagonallyFlipped {
+		orientation = m.FlipD().Concat(orientation)
+	}
+	properties := propmap.New()
+	for i := range td.Tile.Properties {
+		prop := &td.Tile.Properties[i]
+		propmap.Set(properties, prop.Name, prop.Value)
+	}
+	return properties, orientation, nil
+}
+
+// CheckTnihSign verifies that the given checkpoint has a TnihSign if and only if expected.
+func (l *Level) CheckTnihSign(name string) error {
+	if name == "" {
+		// This isn't a real CP.
+		return nil
+	}
+	cpSp := l.Checkpoints[name]
+	if cpSp == nil {
+		return fmt.Errorf("checkpoint %v not found", name)
+	}
+	var parseErr error
+	got := len(l.TnihSignsByCheckpoint[name]) != 0
+	want := propmap.ValueOrP(cpSp.Properties, "tnih_sign_expected", true, &parseErr)
+	if parseErr != nil {
+		return parseErr
+	}
+	if !got && want {
+		return fmt.Errorf("note: checkpoint %v has no TnihSign - intended?", name)
+	}
+	if got && !want {
+		return fmt.Errorf("note: checkpoint %v unexpectedly has TnihSign - intended?", name)
+	}
+	return nil
+}
+
 func parseTmx(t *tmx.Map) (*Level, error) {
 	if t.Orientation != "orthogonal" {
 		return nil, fmt.Errorf("unsupported map: got orientation %q, want orthogonal", t.Orientation)
@@ -357,30 +418,27 @@ func parseTmx(t *tmx.Map) (*Level, error) {
 	// t.NextObjectID doesn't matter.
 	// t.TileSets used later.
 	// t.Properties used later.
-	if len(t.Layers) != 1 {
-		return nil, fmt.Errorf("unsupported map: got %d layers, want 1", len(t.Layers))
+	if len(t.Layers) == 0 {
+		return nil, errors.New("unsupported map: got no tile layers")
 	}
 	// t.ObjectGroups used later.
-	if len(t.ImageLayers) != 0 {
-		return nil, fmt.Errorf("unsupported map: got %d image layers, want 0", len(t.ImageLayers))
-	}
+	// t.ImageLayers used later.
 	for i := range t.TileSets {
 		err := FetchTileset(&t.TileSets[i])
 		if err != nil {
 			return nil, fmt.Errorf("unsupported map: failed to decode tileset %d: %w", i, err)
 		}
 	}
-	layer := &t.Layers[0]
-	if layer.X != 0 || layer.Y != 0 {
-		return nil, errors.New("unsupported map: layer has been shifted")
+	collisionIdx, err := collisionLayer(t.Layers)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported map: %w", err)
 	}
+	layer := &t.Layers[collisionIdx]
 	// layer.Width, layer.Height used later.
-	// layer.Opacity, layer.Visible not used (we allow it though as it may help in the editor).
-	if layer.OffsetX != 0 || layer.OffsetY != 0 {
-		return nil, errors.New("unsupported map: layer has an offset")
+	err = checkTileLayer(layer, layer.Width, layer.Height)
+	if err != nil {
+		return nil, err
 	}
-	// layer.Properties not used.
-	// layer.RawData not used.
 	tds, err := layer.TileDefs(t.TileSets)
 	if err != nil {
 		return nil, fmt.Errorf("invalid map layer: %w", err)
@@ -400,6 +458,13 @@ func parseTmx(t *tmx.Map) (*Level, error) {
 			return nil, errors.New("unsupported map: could not parse checkpoint_locations_hash")
 		}
 	}
+	var generateCheckpointLocations bool
+	if prop := t.Properties.WithName("generate_checkpoint_locations"); prop != nil {
+		generateCheckpointLocations, err = t.Properties.Bool("generate_checkpoint_locations")
+		if err != nil {
+			return nil, fmt.Errorf("unsupported map: could not read generate_checkpoint_locations: %w", err)
+		}
+	}
 	level := Level{
 		Checkpoints:             map[string]*Spawnable{},
 		TnihSignsByCheckpoint:   map[string][]*Spawnable{},
@@ -409,6 +474,8 @@ func parseTmx(t *tmx.Map) (*Level, error) {
 		Abilities:               map[string]bool{},
 		tiles:                   make([]LevelTile, layer.Width*layer.Height),
 		width:                   layer.Width,
+
+		generateCheckpointLocations: generateCheckpointLocations,
 	}
 	var parseErr error
 	var tnihSigns []*Spawnable
@@ -417,34 +484,10 @@ func parseTmx(t *tmx.Map) (*Level, error) {
 		if td.Nil {
 			continue
 		}
-		if td.Tile == nil {
-			return nil, fmt.Errorf("invalid tiledef: %v [%s]", td, td.TileSet.Source)
-		}
-		// td.Tile.Probability not used (editor only).
-		// td.Tile.Properties used later.
-		// td.Tile.Image used later.
-		if len(td.Tile.Animation) != 0 {
-			return nil, errors.New("unsupported tileset: got an animation")
-		}
-		if len(td.Tile.ObjectGroup.Objects) != 0 {
-			return nil, errors.New("unsupported tileset: got objects in a tile")
-		}
-		// td.Tile.RawTerrainType not used (editor only).
 		pos := m.Pos{X: i % layer.Width, Y: i / layer.Width}
-		orientation := m.Identity()
-		if td.HorizontallyFlipped {
-			orientation = m.FlipX().Concat(orientation)
-		}
-		if td.VerticallyFlipped {
-			orientation = m.FlipY().Concat(orientation)
-		}
-		if td.DiagonallyFlipped {
-			orientation = m.FlipD().Concat(orientation)
-		}
-		properties := propmap.New()
-		for i := range td.Tile.Properties {
-			prop := &td.Tile.Properties[i]
-			propmap.Set(properties, prop.Name, prop.Value)
+		properties, orientation, err := parseTileDef(td)
+		if err != nil {
+			return nil, err
 		}
 		var contents Contents
 		if propmap.ValueOrP(properties, "solid", true, &parseErr) {
@@ -469,6 +512,26 @@ func parseTmx(t *tmx.Map) (*Level, error) {
 			Valid: true,
 		}
 	}
+	for i := range t.Layers {
+		if i == collisionIdx {
+			continue
+		}
+		err := level.addTileLayer(&t.Layers[i], i, collisionIdx, t.TileSets)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sortTileLayers(level.TileLayers)
+	for i := range level.tiles {
+		sortTileOverlays(level.tiles[i].Tile.Overlays)
+	}
+	for i := range t.ImageLayers {
+		il, err := parseImageLayer(&t.ImageLayers[i])
+		if err != nil {
+			return nil, fmt.Errorf("unsupported map: %w", err)
+		}
+		level.ImageLayers = append(level.ImageLayers, il)
+	}
 	type RawWarpZone struct {
 		StartTile, EndTile m.Pos
 		Orientation        m.Orientation
@@ -686,19 +749,11 @@ func parseTmx(t *tmx.Map) (*Level, error) {
 		name := propmap.ValueP(cp.Properties, "name", "", &parseErr)
 		level.TnihSignsByCheckpoint[name] = append(level.TnihSignsByCheckpoint[name], sign)
 	}
-	for name, cpSp := range level.Checkpoints {
-		if name == "" {
-			// This isn't a real CP.
-			continue
-		}
-		if *debugCheckTnihSigns {
-			got := len(level.TnihSignsByCheckpoint[name]) != 0
-			want := propmap.ValueOrP(cpSp.Properties, "tnih_sign_expected", true, &parseErr)
-			if !got && want {
-				return nil, fmt.Errorf("note: checkpoint %v has no TnihSign - intended?", name)
-			}
-			if got && !want {
-				return nil, fmt.Errorf("note: checkpoint %v unexpectedly has TnihSign - intended?", name)
+	if *debugCheckTnihSigns {
+		for name := range level.Checkpoints {
+			err := level.CheckTnihSign(name)
+			if err != nil {
+				return nil, err
 			}
 		}
 	}
@@ -759,6 +814,7 @@ type Loader struct {
 	filename                         string
 	skipCheckpointLocations          bool
 	skipComparingCheckpointLocations bool
+	fallbackCheckpointLocations      bool
 
 	level   *Level
 	tmxData *tmx.Map
@@ -778,6 +834,13 @@ func (l *Loader) SkipComparingCheckpointLocations(s bool) *Loader {
 	return l
 }
 
+// FallbackCheckpointLocations makes a missing checkpoint locations file not an error.
+// Instead, the checkpoints are laid out natively, or if that fails, by their position in the level.
+func (l *Loader) FallbackCheckpointLocations(f bool) *Loader {
+	l.fallbackCheckpointLocations = f
+	return l
+}
+
 func (l *Loader) Level() *Level {
 	return l.level
 }
@@ -790,6 +853,8 @@ func (l *Loader) Load() (*Level, error) {
 // LoadStepwise loads a level in steps.
 func (l *Loader) LoadStepwise(s *splash.State) (splash.Status, error) {
 	status, err := s.Enter("loading level file", locale.G.Get("loading level file"), "could not load level file", splash.Single(func() error {
+		// The level and everything it refers to may come from a level pack.
+		vfs.SelectLevel(l.filename)
 		r, err := vfs.Load("maps", l.filename+".tmx")
 		if err != nil {
 			return fmt.Errorf("could not open map: %w", err)
@@ -819,18 +884,31 @@ func (l *Loader) LoadStepwise(s *splash.State) (splash.Status, error) {
 	if !l.skipCheckpointLocations {
 		status, err = s.Enter("loading checkpoints", locale.G.Get("loading checkpoints"), "could not load checkpoint locations", splash.Single(func() error {
 			var err error
-			l.level.CheckpointLocations, err = l.level.LoadCheckpointLocations(l.filename)
-			if err != nil {
-				return err
+			fallback := false
+			switch {
+			case l.level.generateCheckpointLocations:
+				l.level.CheckpointLocations, err = l.level.GenerateCheckpointLocations(l.filename)
+			case *debugGenerateCheckpointLocations:
+				// The hash stored in the level is for the generated file, so don't compare.
+				l.level.CheckpointLocations, err = l.level.GenerateCheckpointLocations(l.filename)
+				fallback = true
+			default:
+				l.level.CheckpointLocations, err = l.level.LoadCheckpointLocations(l.filename)
+			}
+			if err != nil && l.fallbackCheckpointLocations && errors.Is(err, os.ErrNotExist) {
+				log.Warningf("no checkpoint locations for %q, laying out checkpoints", l.filename)
+				l.level.CheckpointLocations, err = l.level.GenerateCheckpointLocations(l.filename)
+				if err != nil {
+					log.Warningf("could not lay out checkpoints for %q, using level positions: %v", l.filename, err)
+					l.level.CheckpointLocations, err = l.level.FallbackCheckpointLocations(l.filename)
+				}
+				fallback = true
 			}
-			h, err := hashstructure.Hash(l.level.CheckpointLocations, hashstructure.FormatV2, nil)
 			if err != nil {
 				return err
 			}
-			if !l.skipComparingCheckpointLocations {
-				if h != l.level.CheckpointLocationsHash {
-					return fmt.Errorf("checkpoint location hash mismatch: got %v, want %v - may need to update level file?", h, l.level.CheckpointLocationsHash)
-				}
+			if !l.skipComparingCheckpointLocations && !fallback {
+				return l.level.VerifyCheckpointLocationsHash()
 			}
 			return nil
 		}))
@@ -849,6 +927,18 @@ func (l *Loader) LoadStepwise(s *splash.State) (splash.Status, error) {
 	return splash.Continue, nil
 }
 
+// VerifyCheckpointLocationsHash returns an error if the checkpoint locations do not match the hash stored in the level.
+func (l *Level) VerifyCheckpointLocationsHash() error {
+	h, err := hashstructure.Hash(l.CheckpointLocations, hashstructure.FormatV2, nil)
+	if err != nil {
+		return err
+	}
+	if h != l.CheckpointLocationsHash {
+		return fmt.Errorf("checkpoint location hash mismatch: got %v, want %v - may need to update level file?", h, l.CheckpointLocationsHash)
+	}
+	return nil
+}
+
 // VerifyHash returns an error if the level hash changed.
 func (l *Level) VerifyHash() error {
 	hash, err := hashstructure.Hash(l, hashstructure.FormatV2, nil)
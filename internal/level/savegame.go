@@ -0,0 +1,155 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package level
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/mitchellh/hashstructure/v2"
+
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/version"
+)
+
+// IsV0 returns whether the save game still uses the legacy v0 hash.
+func (save *SaveGame) IsV0() bool {
+	return save.Hash != 0 && save.InfoHash == 0 && save.StateHash == 0
+}
+
+// validateV0 verifies the legacy v0 hash of the save game.
+func (save *SaveGame) validateV0() error {
+	saveV0 := &SaveGameData{
+		State:        save.State,
+		LevelVersion: save.LevelVersion,
+		LevelHash:    save.LevelHash,
+	}
+	saveHash, err := hashstructure.Hash(saveV0, hashstructure.FormatV2, nil)
+	if err != nil {
+		return err
+	}
+	if saveHash != save.Hash {
+		return fmt.Errorf("someone tampered with the save game: got %v, want %v", saveHash, save.Hash)
+	}
+	return nil
+}
+
+// Validate verifies the tamper checks of the save game.
+func (save *SaveGame) Validate() error {
+	if save.IsV0() {
+		// Remove this on the next SaveGameVersion; Migrate keeps converting v0 save games.
+		return save.validateV0()
+	}
+	infoHash, err := hashstructure.Hash(save.SaveGameDataV1, hashstructure.FormatV2, nil)
+	if err != nil {
+		return err
+	}
+	if infoHash != save.InfoHash {
+		return errors.New("someone tampered with the save game info")
+	}
+	stateHash, err := hashstructure.Hash(save.State, hashstructure.FormatV2, nil)
+	if err != nil {
+		return err
+	}
+	if stateHash != save.StateHash {
+		return errors.New("someone tampered with the save game state")
+	}
+	splitsHash, err := save.splitsHash()
+	if err != nil {
+		return err
+	}
+	if splitsHash != save.SplitsHash {
+		return errors.New("someone tampered with the save game splits")
+	}
+	return nil
+}
+
+// splitsHash returns the tamper check of the splits.
+// Save games without splits have none, so older save games stay valid.
+func (save *SaveGame) splitsHash() (uint64, error) {
+	if len(save.Splits) == 0 {
+		return 0, nil
+	}
+	return hashstructure.Hash(save.Splits, hashstructure.FormatV2, nil)
+}
+
+// Rehash recomputes the tamper checks of the save game.
+// This always yields a v1 save game.
+func (save *SaveGame) Rehash() error {
+	var err error
+	save.StateHash, err = hashstructure.Hash(save.State, hashstructure.FormatV2, nil)
+	if err != nil {
+		return err
+	}
+	save.InfoHash, err = hashstructure.Hash(save.SaveGameDataV1, hashstructure.FormatV2, nil)
+	if err != nil {
+		return err
+	}
+	save.SplitsHash, err = save.splitsHash()
+	if err != nil {
+		return err
+	}
+	save.Hash = 0
+	return nil
+}
+
+// Migrate converts a valid save game to the current game version and the given level.
+// The save game is validated first, so migration cannot be used to hide tampering.
+// Legacy v0 save games are converted to separate info and state hashes.
+// Save games from a different SaveGameVersion are rejected, as no conversion exists yet.
+func (save *SaveGame) Migrate(l *Level) error {
+	var err error
+	if save.IsV0() {
+		err = save.validateV0()
+	} else {
+		err = save.Validate()
+	}
+	if err != nil {
+		return err
+	}
+	if save.LevelVersion != l.SaveGameVersion {
+		// Only one SaveGameVersion exists so far, so there is no conversion yet.
+		return fmt.Errorf("cannot migrate save game from level version %v to %v", save.LevelVersion, l.SaveGameVersion)
+	}
+	if save.State == nil {
+		save.State = map[EntityID]PersistentState{}
+	}
+	save.GameVersion = version.Revision()
+	save.LevelHash = l.Hash
+	return save.Rehash()
+}
+
+// UnknownEntities returns the IDs of entities in the save game that do not exist in the given level.
+func (save *SaveGame) UnknownEntities(l *Level) []EntityID {
+	known := map[EntityID]bool{
+		l.Player.ID: true,
+	}
+	l.ForEachTile(func(_ m.Pos, tile *LevelTile) {
+		for _, sp := range tile.Tile.Spawnables {
+			known[sp.ID] = true
+		}
+	})
+	var unknown []EntityID
+	for id := range save.State {
+		if !known[id] {
+			unknown = append(unknown, id)
+		}
+	}
+	sort.Slice(unknown, func(i, j int) bool {
+		return unknown[i] < unknown[j]
+	})
+	return unknown
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package level
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mitchellh/hashstructure/v2"
+
+	"github.com/divVerent/aaaaxy/internal/propmap"
+	"github.com/divVerent/aaaaxy/internal/version"
+)
+
+// saveGameV0 returns a save game as written before the info and state hashes were split.
+func saveGameV0(t *testing.T) *SaveGame {
+	t.Helper()
+	player := propmap.New()
+	propmap.Set(player, "frames", 1234)
+	save := &SaveGame{
+		SaveGameDataV1: SaveGameDataV1{
+			State:        map[EntityID]PersistentState{1: player},
+			LevelVersion: 1,
+			LevelHash:    42,
+		},
+	}
+	var err error
+	save.Hash, err = hashstructure.Hash(&SaveGameData{
+		State:        save.State,
+		LevelVersion: save.LevelVersion,
+		LevelHash:    save.LevelHash,
+	}, hashstructure.FormatV2, nil)
+	if err != nil {
+		t.Fatalf("could not hash v0 save game: %v", err)
+	}
+	return save
+}
+
+func TestMigrateV0(t *testing.T) {
+	save := saveGameV0(t)
+	if !save.IsV0() {
+		t.Fatalf("test save game is not v0")
+	}
+	lvl := &Level{SaveGameVersion: 1, Hash: 43}
+	err := save.Migrate(lvl)
+	if err != nil {
+		t.Fatalf("could not migrate v0 save game: %v", err)
+	}
+	if save.IsV0() || save.Hash != 0 {
+		t.Errorf("migrated save game still uses the v0 hash")
+	}
+	if save.GameVersion != version.Revision() || save.LevelHash != lvl.Hash {
+		t.Errorf("migrated save game info: got %v %v, want %v %v", save.GameVersion, save.LevelHash, version.Revision(), lvl.Hash)
+	}
+	if got := propmap.StringOr(save.State[1], "frames", ""); got != "1234" {
+		t.Errorf("migrated save game state: got frames %q, want %q", got, "1234")
+	}
+	err = save.Validate()
+	if err != nil {
+		t.Errorf("migrated save game does not validate: %v", err)
+	}
+}
+
+func TestMigrateV0Tampered(t *testing.T) {
+	save := saveGameV0(t)
+	propmap.Set(save.State[1], "frames", 1)
+	err := save.Migrate(&Level{SaveGameVersion: 1})
+	if err == nil {
+		t.Errorf("migrating a tampered v0 save game succeeded")
+	}
+	if !save.IsV0() {
+		t.Errorf("tampered v0 save game was converted anyway")
+	}
+}
+
+func TestMigrateOtherLevelVersion(t *testing.T) {
+	save := saveGameV0(t)
+	err := save.Migrate(&Level{SaveGameVersion: 2})
+	if err == nil {
+		t.Errorf("migrating a save game to another level version succeeded")
+	}
+}
+
+func TestSplitsTampered(t *testing.T) {
+	save := &SaveGame{
+		SaveGameDataV1: SaveGameDataV1{
+			State:        map[EntityID]PersistentState{},
+			LevelVersion: 1,
+		},
+		Splits: []Split{
+			{Checkpoint: "a", Frames: 100, Segment: 100},
+			{Checkpoint: "b", Frames: 250, Segment: 150},
+		},
+	}
+	err := save.Rehash()
+	if err != nil {
+		t.Fatalf("could not hash save game: %v", err)
+	}
+	err = save.Validate()
+	if err != nil {
+		t.Fatalf("save game does not validate: %v", err)
+	}
+	for _, c := range []struct {
+		name   string
+		tamper func(save *SaveGame)
+	}{
+		{"faster split", func(save *SaveGame) {
+			save.Splits[1].Frames = 200
+			save.Splits[1].Segment = 100
+		}},
+		{"dropped split", func(save *SaveGame) {
+			save.Splits = save.Splits[:1]
+		}},
+		{"dropped hash", func(save *SaveGame) {
+			save.SplitsHash = 0
+		}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			tampered := *save
+			tampered.Splits = slices.Clone(save.Splits)
+			c.tamper(&tampered)
+			err := tampered.Validate()
+			if err == nil {
+				t.Errorf("save game with tampered splits validates")
+			}
+		})
+	}
+}
@@ -83,14 +83,30 @@ type Tile struct {
 	// - I = O^-1 Orientation
 	imageSrcByOrientation map[m.Orientation]string
 
+	// Images of decoration layers, sorted by Z.
+	// Their orientation is not adjusted for transform until ResolveImage is called.
+	Overlays []TileOverlay
+
 	// Debug info.
 	LoadedFromNeighbor m.Pos
 }
 
 // ResolveImage applies imageSrcByOrientation data to Image, and possibly changes Orientation when it did.
+// Overlays get their orientation adjusted for the transform too, as they are shared with the level.
 func (t *Tile) ResolveImage() {
 	t.ImageSrc, t.Orientation = ResolveImage(t.Transform, t.Orientation, t.ImageSrc, t.imageSrcByOrientation)
 	t.imageSrcByOrientation = nil
+	if len(t.Overlays) == 0 {
+		return
+	}
+	overlays := make([]TileOverlay, len(t.Overlays))
+	for i, o := range t.Overlays {
+		o.Orientation = t.Transform.Inverse().Concat(o.Orientation)
+		o.ImageSrc, o.Orientation = ResolveImage(t.Transform, o.Orientation, o.ImageSrc, o.imageSrcByOrientation)
+		o.imageSrcByOrientation = nil
+		overlays[i] = o
+	}
+	t.Overlays = overlays
 }
 
 // ResolveImage applies the given imageSrcByOrientation map.
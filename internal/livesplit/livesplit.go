@@ -0,0 +1,235 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package livesplit drives an external timer using the LiveSplit Server protocol.
+//
+// LiveSplit Server listens on a TCP port and accepts one command per line;
+// the game connects to it as a client, so no memory scanning is needed.
+package livesplit
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"github.com/divVerent/aaaaxy/internal/flag"
+	"github.com/divVerent/aaaaxy/internal/gametime"
+	"github.com/divVerent/aaaaxy/internal/log"
+)
+
+var (
+	livesplitServer = flag.String("livesplit_server", "", "address of a LiveSplit Server to send timer events to, e.g. localhost:16834; empty disables")
+)
+
+const (
+	// dialTimeout limits how long connecting may take. Runs in the background.
+	dialTimeout = time.Second
+	// queueSize is the number of commands to buffer; if exceeded, commands are dropped.
+	queueSize = 256
+)
+
+// redialInterval limits how often reconnecting is attempted.
+var redialInterval = 5 * time.Second
+
+// State is the timer related game state, sampled once per frame.
+type State struct {
+	TimerStarted bool
+	TimerStopped bool
+	Frames       int
+	Splits       int
+}
+
+type client struct {
+	addr    string
+	cmds    chan string
+	done    chan struct{}
+	prev    State
+	running bool
+	ended   bool
+}
+
+var active *client
+
+// Init connects to the LiveSplit server if one is configured.
+func Init() error {
+	if *livesplitServer == "" {
+		return nil
+	}
+	active = newClient(*livesplitServer)
+	log.Infof("sending timer events to LiveSplit Server at %v", *livesplitServer)
+	return nil
+}
+
+// Active returns whether timer events are being sent.
+func Active() bool {
+	return active != nil
+}
+
+// Update sends timer events according to the new game state.
+func Update(s State) {
+	if active == nil {
+		return
+	}
+	active.update(s)
+}
+
+// Close sends all pending commands and disconnects.
+func Close() {
+	if active == nil {
+		return
+	}
+	active.close()
+	active = nil
+}
+
+func newClient(addr string) *client {
+	c := &client{
+		addr: addr,
+		cmds: make(chan string, queueSize),
+		done: make(chan struct{}),
+	}
+	go c.run()
+	return c
+}
+
+func (c *client) send(cmd string) {
+	select {
+	case c.cmds <- cmd:
+	default:
+		log.Warningf("LiveSplit Server queue full, dropping %q", cmd)
+	}
+}
+
+func (c *client) update(s State) {
+	prev := c.prev
+	c.prev = s
+
+	if c.running && s.Frames < prev.Frames {
+		// A different game got loaded.
+		c.send("reset")
+		c.running, c.ended = false, false
+	}
+	if !c.running && s.TimerStarted && !s.TimerStopped {
+		c.send("reset")
+		c.send("starttimer")
+		// Game time comes exclusively from us.
+		c.send("pausegametime")
+		c.send("setgametime " + gametime.Format(s.Frames))
+		c.running, c.ended = true, false
+		return
+	}
+	if !c.running || c.ended {
+		return
+	}
+	if s.Frames != prev.Frames {
+		c.send("setgametime " + gametime.Format(s.Frames))
+	}
+	for i := prev.Splits; i < s.Splits; i++ {
+		c.send("split")
+	}
+	if s.TimerStopped && !prev.TimerStopped {
+		// The final split is the end of the game.
+		c.send("split")
+		c.ended = true
+	}
+}
+
+func (c *client) close() {
+	close(c.cmds)
+	<-c.done
+}
+
+// enqueue adds a command to those not sent yet.
+func enqueue(pending []string, cmd string) []string {
+	const setGameTime = "setgametime "
+	switch {
+	case cmd == "reset":
+		// Nothing before a reset matters anymore.
+		pending = pending[:0]
+	case strings.HasPrefix(cmd, setGameTime) && len(pending) > 0 && strings.HasPrefix(pending[len(pending)-1], setGameTime):
+		// Only the most recent game time matters.
+		pending = pending[:len(pending)-1]
+	case len(pending) >= queueSize:
+		log.Warningf("LiveSplit Server still unreachable, dropping %q", pending[0])
+		pending = pending[1:]
+	}
+	return append(pending, cmd)
+}
+
+// run sends the queued commands in order. While no connection can be made,
+// commands are kept and sent once a connection succeeds.
+func (c *client) run() {
+	defer close(c.done)
+	var (
+		conn     net.Conn
+		pending  []string
+		lastDial time.Time
+		retry    <-chan time.Time
+	)
+	scheduleRetry := func() {
+		retry = time.After(redialInterval - time.Since(lastDial))
+	}
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		if conn == nil {
+			if time.Since(lastDial) < redialInterval {
+				if retry == nil {
+					scheduleRetry()
+				}
+				return
+			}
+			lastDial = time.Now()
+			var err error
+			conn, err = net.DialTimeout("tcp", c.addr, dialTimeout)
+			if err != nil {
+				log.Errorf("could not connect to LiveSplit Server at %v: %v", c.addr, err)
+				conn = nil
+				scheduleRetry()
+				return
+			}
+		}
+		for len(pending) > 0 {
+			_, err := conn.Write([]byte(pending[0] + "\r\n"))
+			if err != nil {
+				log.Errorf("could not send to LiveSplit Server at %v: %v", c.addr, err)
+				conn.Close()
+				conn = nil
+				scheduleRetry()
+				return
+			}
+			pending = pending[1:]
+		}
+	}
+	for {
+		select {
+		case cmd, ok := <-c.cmds:
+			if !ok {
+				flush()
+				if len(pending) != 0 {
+					log.Warningf("LiveSplit Server unreachable, dropping %d commands", len(pending))
+				}
+				if conn != nil {
+					conn.Close()
+				}
+				return
+			}
+			pending = enqueue(pending, cmd)
+		case <-retry:
+			retry = nil
+		}
+		flush()
+	}
+}
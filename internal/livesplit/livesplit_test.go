@@ -0,0 +1,138 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package livesplit
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeServer stands in for LiveSplit Server and collects all received commands.
+func fakeServer(t *testing.T, addr string) (string, func() []string) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	got := make(chan []string)
+	go func() {
+		defer l.Close()
+		conn, err := l.Accept()
+		if err != nil {
+			got <- nil
+			return
+		}
+		defer conn.Close()
+		var cmds []string
+		s := bufio.NewScanner(conn)
+		for s.Scan() {
+			cmds = append(cmds, strings.TrimSuffix(s.Text(), "\r"))
+		}
+		got <- cmds
+	}()
+	return l.Addr().String(), func() []string { return <-got }
+}
+
+func TestRun(t *testing.T) {
+	addr, commands := fakeServer(t, "127.0.0.1:0")
+	c := newClient(addr)
+	for _, s := range []State{
+		{},
+		{TimerStarted: true, Frames: 1},
+		{TimerStarted: true, Frames: 2},
+		{TimerStarted: true, Frames: 3, Splits: 1},
+		{TimerStarted: true, Frames: 3, Splits: 1},
+		{TimerStarted: true, TimerStopped: true, Frames: 3723 * 60, Splits: 1},
+		{TimerStarted: true, TimerStopped: true, Frames: 3723 * 60, Splits: 1},
+	} {
+		c.update(s)
+	}
+	c.close()
+	want := []string{
+		"reset",
+		"starttimer",
+		"pausegametime",
+		"setgametime 0:00:00.016",
+		"setgametime 0:00:00.033",
+		"setgametime 0:00:00.050",
+		"split",
+		"setgametime 1:02:03.000",
+		"split",
+	}
+	if diff := cmp.Diff(want, commands()); diff != "" {
+		t.Errorf("unexpected commands (-want +got):\n%v", diff)
+	}
+}
+
+func TestReset(t *testing.T) {
+	addr, commands := fakeServer(t, "127.0.0.1:0")
+	c := newClient(addr)
+	for _, s := range []State{
+		{TimerStarted: true, Frames: 100},
+		{TimerStarted: false, Frames: 0},
+	} {
+		c.update(s)
+	}
+	c.close()
+	want := []string{
+		"reset",
+		"starttimer",
+		"pausegametime",
+		"setgametime 0:00:01.666",
+		"reset",
+	}
+	if diff := cmp.Diff(want, commands()); diff != "" {
+		t.Errorf("unexpected commands (-want +got):\n%v", diff)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	defer func(d time.Duration) { redialInterval = d }(redialInterval)
+	redialInterval = 100 * time.Millisecond
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	c := newClient(addr)
+	for _, s := range []State{
+		{TimerStarted: true, Frames: 1},
+		{TimerStarted: true, Frames: 2},
+	} {
+		c.update(s)
+	}
+	// Give the client time to fail connecting before the server comes up.
+	time.Sleep(redialInterval / 2)
+	_, commands := fakeServer(t, addr)
+	time.Sleep(3 * redialInterval)
+	c.update(State{TimerStarted: true, Frames: 3, Splits: 1})
+	c.close()
+	want := []string{
+		"reset",
+		"starttimer",
+		"pausegametime",
+		"setgametime 0:00:00.033",
+		"setgametime 0:00:00.050",
+		"split",
+	}
+	if diff := cmp.Diff(want, commands()); diff != "" {
+		t.Errorf("unexpected commands (-want +got):\n%v", diff)
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maprender
+
+import (
+	"fmt"
+	"image"
+	"sort"
+
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// Layout maps tile positions in the rendering to the tiles shown there.
+// Like in the world, each tile carries the transform it was loaded with.
+type Layout map[m.Pos]*level.Tile
+
+// RawLayout returns the level as seen in the editor, i.e. without warpzones applied.
+func RawLayout(lvl *level.Level) Layout {
+	layout := Layout{}
+	lvl.ForEachTile(func(pos m.Pos, t *level.LevelTile) {
+		if !t.Valid {
+			return
+		}
+		tile := t.Tile
+		tile.Transform = m.Identity()
+		tile.ResolveImage()
+		layout[pos] = &tile
+	})
+	return layout
+}
+
+// UnfoldedLayout returns the level as seen by the player when spawning at
+// the given checkpoint, following warpzones up to the given distance in tiles.
+//
+// Tiles are loaded like World.LoadTile does, with all warpzones in their
+// initial state. Where different paths lead to the same position, the
+// shortest one wins; the game itself may pick a different one depending on
+// where the player is.
+func UnfoldedLayout(lvl *level.Level, checkpointName string, radius int) (Layout, error) {
+	cpSp := lvl.Checkpoints[checkpointName]
+	if cpSp == nil {
+		return nil, fmt.Errorf("checkpoint %q not found", checkpointName)
+	}
+	cpTransform := m.Identity()
+	cpTransformStr := propmap.StringOr(cpSp.Properties, "required_orientation", "")
+	if cpTransformStr != "" {
+		cpTransforms, err := m.ParseOrientations(cpTransformStr)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse checkpoint orientation: %w", err)
+		}
+		cpTransform = cpTransforms[0]
+	}
+	tile := lvl.Tile(cpSp.LevelPos).Tile
+	tile.Transform = cpTransform
+	tile.Orientation = tile.Transform.Inverse().Concat(tile.Orientation)
+	tile.ResolveImage()
+
+	layout := Layout{cpSp.LevelPos: &tile}
+	queue := []m.Pos{cpSp.LevelPos}
+	for len(queue) > 0 {
+		pos := queue[0]
+		queue = queue[1:]
+		from := layout[pos]
+		if from.Contents.Opaque() {
+			// Can't see through it, so can't load from it.
+			continue
+		}
+		for _, d := range []m.Delta{m.North(), m.East(), m.South(), m.West()} {
+			newPos := pos.Add(d)
+			if _, found := layout[newPos]; found {
+				continue
+			}
+			dist := newPos.Delta(cpSp.LevelPos)
+			if max(dist.DX, -dist.DX, dist.DY, -dist.DY) > radius {
+				continue
+			}
+			newTile := loadTile(lvl, from, d)
+			if newTile == nil {
+				continue
+			}
+			layout[newPos] = newTile
+			queue = append(queue, newPos)
+		}
+	}
+	return layout, nil
+}
+
+// loadTile returns the tile seen when moving by d from the given tile.
+func loadTile(lvl *level.Level, from *level.Tile, d m.Delta) *level.Tile {
+	t := from.Transform
+	newLevelPos := from.LevelPos.Add(t.Apply(d))
+	newLevelTile := lvl.Tile(newLevelPos)
+	if newLevelTile == nil {
+		return nil
+	}
+	warped := false
+	for _, warp := range newLevelTile.WarpZones {
+		// Don't enter warps from behind.
+		if warp.PrevTile != from.LevelPos {
+			continue
+		}
+		// Switchable warpzones are active initially only if inverted.
+		if warp.Switchable && !warp.Invert {
+			continue
+		}
+		if warped {
+			return nil
+		}
+		warped = true
+		t = warp.Transform.Concat(t)
+		newLevelTile = lvl.Tile(warp.ToTile)
+		if newLevelTile == nil {
+			return nil
+		}
+	}
+	tile := newLevelTile.Tile
+	tile.Transform = t
+	tile.Orientation = t.Inverse().Concat(tile.Orientation)
+	tile.ResolveImage()
+	return &tile
+}
+
+// Bounds returns the pixel rectangle covered by the layout.
+func (l Layout) Bounds() image.Rectangle {
+	var bounds image.Rectangle
+	for pos := range l {
+		bounds = bounds.Union(image.Rect(pos.X*level.TileSize, pos.Y*level.TileSize, (pos.X+1)*level.TileSize, (pos.Y+1)*level.TileSize))
+	}
+	return bounds
+}
+
+// positions returns the tile positions of the layout in a stable order.
+func (l Layout) positions() []m.Pos {
+	positions := make([]m.Pos, 0, len(l))
+	for pos := range l {
+		positions = append(positions, pos)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].Y != positions[j].Y {
+			return positions[i].Y < positions[j].Y
+		}
+		return positions[i].X < positions[j].X
+	})
+	return positions
+}
+
+// Placed is a spawnable as the world would spawn it in a layout.
+type Placed struct {
+	*level.Spawnable
+
+	// Rect is the rectangle of the entity in pixels.
+	Rect m.Rect
+	// Transform is the transform of the tile the entity was spawned from.
+	Transform m.Orientation
+	// Orientation is the orientation to render the entity with.
+	Orientation m.Orientation
+}
+
+// Spawnables returns all spawnables of the layout, placed like World.Spawn does.
+// Like in the game, an entity shows up again when reached through a different path.
+func (l Layout) Spawnables() []Placed {
+	type incarnation struct {
+		id      level.EntityID
+		tilePos m.Pos
+	}
+	seen := map[incarnation]bool{}
+	var placed []Placed
+	pivot2InTile := m.Pos{X: level.TileSize, Y: level.TileSize}
+	for _, pos := range l.positions() {
+		t := l[pos]
+		tInv := t.Transform.Inverse()
+		for _, sp := range t.Spawnables {
+			originTilePos := pos.Add(tInv.Apply(sp.LevelPos.Delta(t.LevelPos)))
+			inc := incarnation{id: sp.ID, tilePos: originTilePos}
+			if seen[inc] {
+				continue
+			}
+			seen[inc] = true
+			rect := tInv.ApplyToRect2(pivot2InTile, sp.RectInTile)
+			rect.Origin = originTilePos.Mul(level.TileSize).Add(rect.Origin.Delta(m.Pos{}))
+			placed = append(placed, Placed{
+				Spawnable:   sp,
+				Rect:        rect,
+				Transform:   t.Transform,
+				Orientation: tInv.Concat(sp.Orientation),
+			})
+		}
+	}
+	return placed
+}
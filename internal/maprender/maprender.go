@@ -0,0 +1,148 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package maprender renders whole levels to images without needing a GPU.
+//
+// Levels can be rendered as seen in the editor, or unfolded as seen by the
+// player; see Layout. This is meant for tools, not for the game itself.
+package maprender
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+// Renderer draws levels, caching the images it loads.
+type Renderer struct {
+	images map[string]image.Image
+}
+
+// New creates a new renderer.
+func New() *Renderer {
+	return &Renderer{
+		images: map[string]image.Image{},
+	}
+}
+
+// LoadImage loads an image from the VFS.
+func (r *Renderer) LoadImage(purpose, name string) (image.Image, error) {
+	key := purpose + "/" + name
+	if img, found := r.images[key]; found {
+		return img, nil
+	}
+	f, err := vfs.Load(purpose, name)
+	if err != nil {
+		return nil, fmt.Errorf("could not open image %v: %w", key, err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode image %v: %w", key, err)
+	}
+	r.images[key] = img
+	return img, nil
+}
+
+// Orient returns the image as drawn with the given orientation.
+func Orient(img image.Image, orientation m.Orientation) image.Image {
+	if orientation == m.Identity() {
+		return img
+	}
+	b := img.Bounds()
+	size := orientation.Apply(m.Delta{DX: b.Dx(), DY: b.Dy()})
+	w, h := size.DX, size.DY
+	if w < 0 {
+		w = -w
+	}
+	if h < 0 {
+		h = -h
+	}
+	out := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			// Rotate around the center in doubled coordinates, like setGeoM does.
+			d := orientation.Apply(m.Delta{DX: 2*x + 1 - b.Dx(), DY: 2*y + 1 - b.Dy()})
+			out.Set((d.DX-1+w)/2, (d.DY-1+h)/2, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return out
+}
+
+// DrawImage draws an image at the given position with the given orientation and opacity.
+func DrawImage(dst draw.Image, pos m.Pos, img image.Image, orientation m.Orientation, alpha float64) {
+	img = Orient(img, orientation)
+	b := img.Bounds()
+	r := image.Rect(pos.X, pos.Y, pos.X+b.Dx(), pos.Y+b.Dy())
+	if alpha >= 1 {
+		draw.Draw(dst, r, img, b.Min, draw.Over)
+		return
+	}
+	mask := image.NewUniform(color.Alpha{A: uint8(alpha*255 + 0.5)})
+	draw.DrawMask(dst, r, img, b.Min, mask, image.Point{}, draw.Over)
+}
+
+// Tiles draws all tiles of the layout, including decoration layers, onto dst.
+// dst uses layout pixel coordinates; see Layout.Bounds.
+func (r *Renderer) Tiles(dst draw.Image, layout Layout) error {
+	for _, pos := range layout.positions() {
+		tile := layout[pos]
+		screenPos := pos.Mul(level.TileSize)
+		for _, o := range tile.Overlays {
+			if o.Layer.Z >= 0 {
+				continue
+			}
+			img, err := r.LoadImage("tiles", o.ImageSrc)
+			if err != nil {
+				return err
+			}
+			DrawImage(dst, screenPos, img, o.Orientation, o.Layer.Alpha)
+		}
+		if tile.ImageSrc != "" {
+			img, err := r.LoadImage("tiles", tile.ImageSrc)
+			if err != nil {
+				return err
+			}
+			DrawImage(dst, screenPos, img, tile.Orientation, 1)
+		}
+		for _, o := range tile.Overlays {
+			if o.Layer.Z < 0 {
+				continue
+			}
+			img, err := r.LoadImage("tiles", o.ImageSrc)
+			if err != nil {
+				return err
+			}
+			DrawImage(dst, screenPos, img, o.Orientation, o.Layer.Alpha)
+		}
+	}
+	return nil
+}
+
+// Render renders the tiles of a layout into a new image in layout pixel coordinates.
+func (r *Renderer) Render(layout Layout) (*image.NRGBA, error) {
+	img := image.NewNRGBA(layout.Bounds())
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	err := r.Tiles(img, layout)
+	if err != nil {
+		return nil, err
+	}
+	return img, nil
+}
@@ -0,0 +1,193 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maprender
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"sort"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/divVerent/aaaaxy/internal/level"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+)
+
+// staticSpriteZ are the entity types drawn as sprites with their default z index.
+// Keep the z indices in sync with internal/game/constants.
+var staticSpriteZ = map[string]int{
+	"Sprite":           0,
+	"SwitchableSprite": 6,
+}
+
+type sprite struct {
+	img    image.Image
+	pos    m.Pos
+	size   m.Delta
+	resize bool
+	alpha  float64
+	z      int
+}
+
+// scale scales an image to the given size using nearest neighbor sampling.
+func scale(img image.Image, size m.Delta) image.Image {
+	b := img.Bounds()
+	if b.Dx() == size.DX && b.Dy() == size.DY {
+		return img
+	}
+	out := image.NewNRGBA(image.Rect(0, 0, size.DX, size.DY))
+	for y := 0; y < size.DY; y++ {
+		for x := 0; x < size.DX; x++ {
+			out.Set(x, y, img.At(b.Min.X+x*b.Dx()/size.DX, b.Min.Y+y*b.Dy()/size.DY))
+		}
+	}
+	return out
+}
+
+// sprite prepares a static sprite for drawing the way the Sprite entity does.
+// It returns nil if the entity is not a static sprite or not visible initially.
+// Color mapping is not applied.
+func (r *Renderer) sprite(p *Placed) (*sprite, error) {
+	zDefault, found := staticSpriteZ[p.EntityType]
+	if !found {
+		return nil, nil
+	}
+	var parseErr error
+	if p.EntityType == "SwitchableSprite" && !propmap.ValueOrP(p.Properties, "invert", false, &parseErr) {
+		// Off initially.
+		return nil, parseErr
+	}
+	directory := propmap.StringOr(p.Properties, "image_dir", "sprites")
+	imgSrc := propmap.ValueP(p.Properties, "image", "", &parseErr)
+	imgSrcByOrientation, err := level.ParseImageSrcByOrientation(imgSrc, p.Properties)
+	if err != nil {
+		return nil, err
+	}
+	imgSrc, orientation := level.ResolveImage(p.Transform, p.Orientation, imgSrc, imgSrcByOrientation)
+	img, err := r.LoadImage(directory, imgSrc)
+	if err != nil {
+		return nil, err
+	}
+	region := propmap.ValueOrP(p.Properties, "image_region", m.Rect{}, &parseErr)
+	if !region.Size.IsZero() {
+		img = img.(interface {
+			SubImage(image.Rectangle) image.Image
+		}).SubImage(image.Rect(region.Origin.X, region.Origin.Y, region.Origin.X+region.Size.DX, region.Origin.Y+region.Size.DY))
+	}
+	s := &sprite{
+		pos:   p.Rect.Origin,
+		size:  p.Rect.Size,
+		alpha: propmap.ValueOrP(p.Properties, "alpha", 1.0, &parseErr),
+		z:     propmap.ValueOrP(p.Properties, "z_index", zDefault, &parseErr),
+	}
+	renderOffset := propmap.ValueOrP(p.Properties, "render_offset", m.Delta{}, &parseErr)
+	s.pos = s.pos.Add(renderOffset)
+	s.resize = renderOffset.IsZero()
+
+	// The following matches SpriteBase.
+	if propmap.ValueOrP(p.Properties, "no_transform", false, &parseErr) {
+		orientation = p.Spawnable.Orientation
+	}
+	if p.Transform.Determinant() < 0 {
+		switch flip := propmap.StringOr(p.Properties, "no_flip", ""); flip {
+		case "x":
+			orientation = orientation.Concat(m.FlipX())
+		case "y":
+			orientation = orientation.Concat(m.FlipY())
+		case "", "false":
+			// Nothing to do.
+		default:
+			return nil, fmt.Errorf("invalid no_flip value: got %v, want one of empty, x, y, false", flip)
+		}
+	}
+	requiredTransforms := propmap.ValueOrP(p.Properties, "required_orientation", m.Orientations{}, &parseErr)
+	if len(requiredTransforms) != 0 {
+		show := false
+		for _, requiredTransform := range requiredTransforms {
+			if p.Transform == requiredTransform || p.Transform == requiredTransform.Concat(m.FlipX()) {
+				show = true
+			}
+		}
+		if !show {
+			return nil, parseErr
+		}
+	}
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	s.img = Orient(img, orientation)
+	return s, nil
+}
+
+// Sprites draws the static sprites of the layout onto dst, ordered by z index.
+func (r *Renderer) Sprites(dst draw.Image, layout Layout) error {
+	var sprites []*sprite
+	placed := layout.Spawnables()
+	for i := range placed {
+		s, err := r.sprite(&placed[i])
+		if err != nil {
+			return fmt.Errorf("could not render entity %v: %w", placed[i].ID, err)
+		}
+		if s == nil || s.alpha == 0 {
+			continue
+		}
+		sprites = append(sprites, s)
+	}
+	sort.SliceStable(sprites, func(i, j int) bool {
+		return sprites[i].z < sprites[j].z
+	})
+	for _, s := range sprites {
+		img := s.img
+		if s.resize {
+			img = scale(img, s.size)
+		}
+		DrawImage(dst, s.pos, img, m.Identity(), s.alpha)
+	}
+	return nil
+}
+
+// CheckpointLabels writes the names of all checkpoints of the layout onto dst.
+func CheckpointLabels(dst draw.Image, layout Layout) {
+	face := basicfont.Face7x13
+	for _, p := range layout.Spawnables() {
+		if p.EntityType != "Checkpoint" {
+			continue
+		}
+		name := propmap.StringOr(p.Properties, "name", "")
+		width := font.MeasureString(face, name).Ceil()
+		center := p.Rect.Center()
+		x, y := center.X-width/2, p.Rect.Origin.Y-2
+		// Outline, so the label is readable on any background.
+		for _, d := range []m.Delta{{DX: -1}, {DX: 1}, {DY: -1}, {DY: 1}} {
+			drawString(dst, face, color.Black, x+d.DX, y+d.DY, name)
+		}
+		drawString(dst, face, color.White, x, y, name)
+	}
+}
+
+func drawString(dst draw.Image, face font.Face, c color.Color, x, y int, s string) {
+	d := font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(s)
+}
@@ -0,0 +1,294 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package menu
+
+import (
+	"math"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/palette"
+)
+
+type CalibrationScreenItem int
+
+const (
+	CalibrationGamepad = iota
+	CalibrationShape
+	CalibrationDiagonal
+	CalibrationHorizontal
+	CalibrationVertical
+	CalibrationCenter
+	CalibrationReset
+	CalibrationBack
+	CalibrationCount
+)
+
+const (
+	// calibrationStickSize is the size of the stick visualisation.
+	calibrationStickSize = 80
+	// calibrationThresholdStep is how much a threshold changes per step.
+	calibrationThresholdStep = 0.05
+	// calibrationHysteresis is how much lower the off threshold is than the on threshold.
+	calibrationHysteresis = 0.2
+)
+
+// calibrationDiagonals are the diagonal handling modes to cycle through.
+var calibrationDiagonals = []float64{0, 45, 90}
+
+type CalibrationScreen struct {
+	Controller *Controller
+	Item       CalibrationScreenItem
+	Gamepad    int
+	Message    string
+}
+
+func (s *CalibrationScreen) Init(m *Controller) error {
+	s.Controller = m
+	s.Item = CalibrationGamepad
+	return nil
+}
+
+// gamepad returns the selected gamepad, if any.
+func (s *CalibrationScreen) gamepad() (ebiten.GamepadID, bool) {
+	gamepads := input.Gamepads()
+	if len(gamepads) == 0 {
+		return 0, false
+	}
+	s.Gamepad = m.Mod(s.Gamepad, len(gamepads))
+	return gamepads[s.Gamepad], true
+}
+
+// setThreshold changes the on threshold of all axes of the given orientation.
+func setThreshold(cal *input.Calibration, vertical bool, delta int) {
+	for a := range cal.Axes {
+		if (a%2 == 1) != vertical {
+			continue
+		}
+		on := cal.Axes[a].On
+		switch delta {
+		case 0:
+			on += calibrationThresholdStep
+			if on > 1+1e-9 {
+				on = 0
+			}
+		default:
+			on += float64(delta) * calibrationThresholdStep
+			on = math.Min(math.Max(on, 0), 1)
+		}
+		// Keep the values in steps.
+		on = math.Round(on/calibrationThresholdStep) * calibrationThresholdStep
+		cal.Axes[a].On = on
+		cal.Axes[a].Off = math.Max(on-calibrationHysteresis, 0)
+	}
+}
+
+// change applies a menu action to the selected gamepad.
+func (s *CalibrationScreen) change(delta int) error {
+	if s.Item == CalibrationGamepad {
+		if delta == 0 {
+			delta = 1
+		}
+		s.Gamepad += delta
+		s.Message = ""
+		return nil
+	}
+	p, ok := s.gamepad()
+	if !ok {
+		return nil
+	}
+	cal := input.GetCalibration(p)
+	switch s.Item {
+	case CalibrationShape:
+		if cal.Shape == input.AxialDeadzone {
+			cal.Shape = input.RadialDeadzone
+		} else {
+			cal.Shape = input.AxialDeadzone
+		}
+	case CalibrationDiagonal:
+		i := 0
+		for i < len(calibrationDiagonals)-1 && calibrationDiagonals[i] < cal.Diagonal {
+			i++
+		}
+		if delta == 0 {
+			delta = 1
+		}
+		cal.Diagonal = calibrationDiagonals[m.Mod(i+delta, len(calibrationDiagonals))]
+	case CalibrationHorizontal:
+		setThreshold(&cal, false, delta)
+	case CalibrationVertical:
+		setThreshold(&cal, true, delta)
+	case CalibrationCenter:
+		if delta != 0 {
+			return nil
+		}
+		centers := input.StickCenters(p)
+		for a := range cal.Axes {
+			cal.Axes[a].Center = centers[a]
+		}
+		s.Message = locale.G.Get("The current stick positions are now the center.")
+	case CalibrationReset:
+		if delta != 0 {
+			return nil
+		}
+		s.Message = locale.G.Get("The calibration was reset to defaults.")
+		return input.ResetCalibration(p)
+	default:
+		return nil
+	}
+	return input.SetCalibration(p, cal)
+}
+
+func (s *CalibrationScreen) Update() error {
+	clicked := s.Controller.QueryMouseItem(&s.Item, CalibrationCount)
+	if input.Down.JustHit {
+		s.Item++
+		s.Controller.MoveSound(nil)
+	}
+	if input.Up.JustHit {
+		s.Item--
+		s.Controller.MoveSound(nil)
+	}
+	s.Item = CalibrationScreenItem(m.Mod(int(s.Item), CalibrationCount))
+	if _, ok := s.gamepad(); !ok {
+		s.Item = CalibrationBack
+	}
+	if input.Exit.JustHit {
+		return s.Controller.ActivateSound(s.Controller.SaveConfigAndSwitchToScreen(&ControlsScreen{}))
+	}
+	if input.Jump.JustHit || input.Action.JustHit || clicked == CenterClicked {
+		if s.Item == CalibrationBack {
+			return s.Controller.ActivateSound(s.Controller.SaveConfigAndSwitchToScreen(&ControlsScreen{}))
+		}
+		return s.Controller.ActivateSound(s.change(0))
+	}
+	if input.Left.JustHit || clicked == LeftClicked {
+		return s.Controller.ActivateSound(s.change(-1))
+	}
+	if input.Right.JustHit || clicked == RightClicked {
+		return s.Controller.ActivateSound(s.change(+1))
+	}
+	return nil
+}
+
+// drawStick visualises the live position of a stick and its calibration.
+func drawStick(screen *ebiten.Image, p ebiten.GamepadID, stick int, cal *input.Calibration, origin m.Pos, name string) {
+	const half = calibrationStickSize / 2
+	x, y, dirs := input.StickDirections(p, stick, cal)
+	h, v := 2*stick, 2*stick+1
+	toScreen := func(sx, sy float64) (float32, float32) {
+		return float32(float64(origin.X+half) + sx*half), float32(float64(origin.Y+half) + sy*half)
+	}
+	vector.StrokeRect(screen, float32(origin.X)+0.5, float32(origin.Y)+0.5, calibrationStickSize-1, calibrationStickSize-1, 1, palette.EGA(palette.DarkGrey, 255), false)
+
+	// The area in which the stick does not count as pushed.
+	cx, cy := cal.Axes[h].Center, cal.Axes[v].Center
+	th, tv := cal.Axes[h].On, cal.Axes[v].On
+	deadzone := palette.EGA(palette.LightGrey, 255)
+	switch cal.Shape {
+	case input.AxialDeadzone:
+		x0, y0 := toScreen(cx-th, cy-tv)
+		x1, y1 := toScreen(cx+th, cy+tv)
+		vector.StrokeRect(screen, x0, y0, x1-x0, y1-y0, 1, deadzone, false)
+	case input.RadialDeadzone:
+		const segments = 32
+		for i := 0; i < segments; i++ {
+			a0 := 2 * math.Pi * float64(i) / segments
+			a1 := 2 * math.Pi * float64(i+1) / segments
+			x0, y0 := toScreen(cx+th*math.Cos(a0), cy+tv*math.Sin(a0))
+			x1, y1 := toScreen(cx+th*math.Cos(a1), cy+tv*math.Sin(a1))
+			vector.StrokeLine(screen, x0, y0, x1, y1, 1, deadzone, false)
+		}
+	}
+
+	// The stick position.
+	dot := palette.EGA(palette.White, 255)
+	var names []string
+	for dir, dirName := range []string{locale.G.Get("Left"), locale.G.Get("Right"), locale.G.Get("Up"), locale.G.Get("Down")} {
+		if dirs[dir] {
+			dot = palette.EGA(palette.Yellow, 255)
+			names = append(names, dirName)
+		}
+	}
+	px, py := toScreen(x, y)
+	vector.DrawFilledRect(screen, px-1, py-1, 3, 3, dot, false)
+
+	fg := palette.EGA(palette.LightGrey, 255)
+	bg := palette.EGA(palette.DarkGrey, 255)
+	font.ByName["MenuSmall"].Draw(screen, name, m.Pos{X: origin.X + half, Y: origin.Y + calibrationStickSize + 12}, font.Center, fg, bg)
+	font.ByName["MenuSmall"].Draw(screen, strings.Join(names, " "), m.Pos{X: origin.X + half, Y: origin.Y + calibrationStickSize + 24}, font.Center, palette.EGA(palette.Yellow, 255), bg)
+}
+
+func (s *CalibrationScreen) Draw(screen *ebiten.Image) {
+	fgs := palette.EGA(palette.Yellow, 255)
+	bgs := palette.EGA(palette.Black, 255)
+	fgn := palette.EGA(palette.LightGrey, 255)
+	bgn := palette.EGA(palette.DarkGrey, 255)
+	font.ByName["MenuBig"].Draw(screen, locale.G.Get("Calibrate Gamepad"), m.Pos{X: CenterX, Y: HeaderY}, font.Center, fgs, bgs)
+	item := func(i CalibrationScreenItem, text string) {
+		fg, bg := fgn, bgn
+		if s.Item == i {
+			fg, bg = fgs, bgs
+		}
+		font.ByName["Menu"].Draw(screen, text, m.Pos{X: CenterX, Y: ItemBaselineY(int(i), CalibrationCount)}, font.Center, fg, bg)
+	}
+	p, ok := s.gamepad()
+	if !ok {
+		font.ByName["MenuSmall"].Draw(screen, locale.G.Get("No gamepad connected."), m.Pos{X: CenterX, Y: ItemBaselineY(CalibrationCount, CalibrationCount)}, font.Center, fgn, bgn)
+		item(CalibrationBack, locale.G.Get("Back"))
+		return
+	}
+	cal := input.GetCalibration(p)
+	item(CalibrationGamepad, locale.G.Get("Gamepad: %s", ebiten.GamepadName(p)))
+	shape := locale.G.Get("Axial")
+	if cal.Shape == input.RadialDeadzone {
+		shape = locale.G.Get("Radial")
+	}
+	item(CalibrationShape, locale.G.Get("Deadzone: %s", shape))
+	var diagonal string
+	switch cal.Diagonal {
+	case 0:
+		diagonal = locale.G.Get("4 Directions")
+	case 45:
+		diagonal = locale.G.Get("8 Directions")
+	case 90:
+		diagonal = locale.G.Get("Wide")
+	default:
+		diagonal = locale.G.Get("%g Degrees", cal.Diagonal)
+	}
+	item(CalibrationDiagonal, locale.G.Get("Diagonals: %s", diagonal))
+	item(CalibrationHorizontal, locale.G.Get("Horizontal Threshold: %d%%", int(math.Round(cal.Axes[0].On*100))))
+	item(CalibrationVertical, locale.G.Get("Vertical Threshold: %d%%", int(math.Round(cal.Axes[1].On*100))))
+	item(CalibrationCenter, locale.G.Get("Set Center"))
+	item(CalibrationReset, locale.G.Get("Reset to Defaults"))
+	item(CalibrationBack, locale.G.Get("Back"))
+
+	message := s.Message
+	if message == "" {
+		message = locale.G.Get("Release the sticks before setting the center.")
+	}
+	font.ByName["MenuSmall"].Draw(screen, message, m.Pos{X: CenterX, Y: ItemBaselineY(CalibrationCount, CalibrationCount)}, font.Center, fgn, bgn)
+
+	y := ItemBaselineY(CalibrationGamepad, CalibrationCount)
+	drawStick(screen, p, 0, &cal, m.Pos{X: engine.GameWidth/8 - calibrationStickSize/2, Y: y}, locale.G.Get("Left Stick"))
+	drawStick(screen, p, 1, &cal, m.Pos{X: 7*engine.GameWidth/8 - calibrationStickSize/2, Y: y}, locale.G.Get("Right Stick"))
+}
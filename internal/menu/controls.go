@@ -0,0 +1,254 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/palette"
+)
+
+type ControlsScreenItem int
+
+const (
+	ControlsDynamic1 = iota
+	ControlsDynamic2
+	ControlsLeft
+	ControlsRight
+	ControlsUp
+	ControlsDown
+	ControlsJump
+	ControlsAction
+	ControlsExit
+	ControlsReset
+	ControlsBack
+	ControlsCount
+)
+
+// captureFrames is how long to wait for a key press before giving up.
+const captureFrames = 5 * engine.GameTPS
+
+type controlsScreenBinding struct {
+	impulse interface {
+		BindingNames() string
+		Bind(b input.Binding) ([]string, error)
+		ResetBindings()
+	}
+	id   string
+	name string
+}
+
+type ControlsScreen struct {
+	Controller   *Controller
+	Item         ControlsScreenItem
+	TopItem      ControlsScreenItem
+	Touch        ControlsScreenItem
+	Gamepad      ControlsScreenItem
+	Bindings     []controlsScreenBinding
+	CaptureFrame int
+	Message      string
+}
+
+func (s *ControlsScreen) Init(m *Controller) error {
+	s.Controller = m
+	// Indexed by item - ControlsLeft.
+	s.Bindings = []controlsScreenBinding{
+		{input.Left, input.Left.Name, locale.G.Get("Left")},
+		{input.Right, input.Right.Name, locale.G.Get("Right")},
+		{input.Up, input.Up.Name, locale.G.Get("Up")},
+		{input.Down, input.Down.Name, locale.G.Get("Down")},
+		{input.Jump, input.Jump.Name, locale.G.Get("Jump")},
+		{input.Action, input.Action.Name, locale.G.Get("Action")},
+		{input.Exit, input.Exit.Name, locale.G.Get("Exit")},
+	}
+	s.TopItem = ControlsLeft
+	s.Touch = ControlsCount
+	s.Gamepad = ControlsCount
+	if input.HaveTouch() {
+		s.TopItem--
+		s.Touch = s.TopItem
+	}
+	if len(input.Gamepads()) > 0 {
+		s.TopItem--
+		s.Gamepad = s.TopItem
+	}
+	s.Item = s.TopItem
+	return nil
+}
+
+func (s *ControlsScreen) binding() *controlsScreenBinding {
+	if s.Item < ControlsLeft || s.Item > ControlsExit {
+		return nil
+	}
+	return &s.Bindings[s.Item-ControlsLeft]
+}
+
+// impulseName returns the translated name of an impulse.
+func (s *ControlsScreen) impulseName(name string) string {
+	switch name {
+	case input.Fullscreen.Name:
+		return locale.G.Get("Fullscreen")
+	}
+	for _, binding := range s.Bindings {
+		if binding.id == name {
+			return binding.name
+		}
+	}
+	return name
+}
+
+func (s *ControlsScreen) updateCapture() error {
+	b, ok := input.Captured()
+	if !ok {
+		s.CaptureFrame++
+		if s.CaptureFrame >= captureFrames {
+			input.CancelCapture()
+			s.Message = locale.G.Get("Nothing was changed.")
+		}
+		return nil
+	}
+	binding := s.binding()
+	changed, err := binding.impulse.Bind(b)
+	var conflict *input.BindingConflictError
+	if errors.As(err, &conflict) {
+		s.Message = locale.G.Get("Cannot use %s, it is needed for %s.", b, s.impulseName(conflict.Impulse))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not bind %v: %w", b, err)
+	}
+	if len(changed) == 0 {
+		s.Message = locale.G.Get("%s is now %s.", binding.name, b)
+		return s.Controller.ActivateSound(nil)
+	}
+	names := make([]string, len(changed))
+	for i, name := range changed {
+		names[i] = s.impulseName(name)
+	}
+	s.Message = locale.G.Get("%s is now %s, taken from %s.", binding.name, b, strings.Join(names, locale.G.Get(", ")))
+	return s.Controller.ActivateSound(nil)
+}
+
+func (s *ControlsScreen) Update() error {
+	if input.Capturing() {
+		return s.updateCapture()
+	}
+	saveItem := s.Item
+	clicked := s.Controller.QueryMouseItem(&s.Item, ControlsCount)
+	if s.Item < s.TopItem {
+		clicked = NotClicked
+		s.Item = saveItem
+	}
+	if input.Down.JustHit {
+		s.Item++
+		s.Controller.MoveSound(nil)
+	}
+	if input.Up.JustHit {
+		s.Item--
+		s.Controller.MoveSound(nil)
+	}
+	s.Item = ControlsScreenItem(m.Mod(int(s.Item-s.TopItem), int(ControlsCount-s.TopItem))) + s.TopItem
+	if input.Exit.JustHit {
+		return s.Controller.ActivateSound(s.Controller.SaveConfigAndSwitchToScreen(&SettingsScreen{}))
+	}
+	if input.Jump.JustHit || input.Action.JustHit || clicked == CenterClicked {
+		switch s.Item {
+		case s.Touch:
+			return s.Controller.ActivateSound(s.Controller.SaveConfigAndSwitchToScreen(&TouchEditScreen{}))
+		case s.Gamepad:
+			return s.Controller.ActivateSound(s.Controller.SaveConfigAndSwitchToScreen(&CalibrationScreen{}))
+		case ControlsReset:
+			input.ResetAllBindings()
+			s.Message = locale.G.Get("All controls were reset to defaults.")
+			return s.Controller.ActivateSound(nil)
+		case ControlsBack:
+			return s.Controller.ActivateSound(s.Controller.SaveConfigAndSwitchToScreen(&SettingsScreen{}))
+		default:
+			input.StartCapture()
+			s.CaptureFrame = 0
+			s.Message = ""
+			return s.Controller.ActivateSound(nil)
+		}
+	}
+	if input.Left.JustHit || clicked == LeftClicked {
+		if binding := s.binding(); binding != nil {
+			binding.impulse.ResetBindings()
+			s.Message = locale.G.Get("%s was reset to defaults.", binding.name)
+			return s.Controller.ActivateSound(nil)
+		}
+	}
+	return nil
+}
+
+func (s *ControlsScreen) Draw(screen *ebiten.Image) {
+	fgs := palette.EGA(palette.Yellow, 255)
+	bgs := palette.EGA(palette.Black, 255)
+	fgn := palette.EGA(palette.LightGrey, 255)
+	bgn := palette.EGA(palette.DarkGrey, 255)
+	headerY := HeaderY
+	if s.TopItem < ControlsDynamic2 {
+		// Both optional items are shown; move the header up to make room.
+		headerY -= ItemBaselineY(ControlsDynamic2, ControlsCount) - ItemBaselineY(ControlsDynamic1, ControlsCount)
+	}
+	font.ByName["MenuBig"].Draw(screen, locale.G.Get("Controls"), m.Pos{X: CenterX, Y: headerY}, font.Center, fgs, bgs)
+	message := s.Message
+	if input.Capturing() {
+		message = locale.G.Get("Press a key or button for %s... (%d sec)", s.binding().name, (captureFrames-s.CaptureFrame+engine.GameTPS-1)/engine.GameTPS)
+	} else if message == "" {
+		message = locale.G.Get("Select to change, press left to reset.")
+	}
+	font.ByName["MenuSmall"].Draw(screen, message, m.Pos{X: CenterX, Y: ItemBaselineY(ControlsCount, ControlsCount)}, font.Center, fgn, bgn)
+	if s.Touch != ControlsCount {
+		fg, bg := fgn, bgn
+		if s.Item == s.Touch {
+			fg, bg = fgs, bgs
+		}
+		font.ByName["Menu"].Draw(screen, locale.G.Get("Edit Touch Controls"), m.Pos{X: CenterX, Y: ItemBaselineY(int(s.Touch), ControlsCount)}, font.Center, fg, bg)
+	}
+	if s.Gamepad != ControlsCount {
+		fg, bg := fgn, bgn
+		if s.Item == s.Gamepad {
+			fg, bg = fgs, bgs
+		}
+		font.ByName["Menu"].Draw(screen, locale.G.Get("Calibrate Gamepad"), m.Pos{X: CenterX, Y: ItemBaselineY(int(s.Gamepad), ControlsCount)}, font.Center, fg, bg)
+	}
+	for i, binding := range s.Bindings {
+		item := ControlsLeft + i
+		fg, bg := fgn, bgn
+		if s.Item == ControlsScreenItem(item) {
+			fg, bg = fgs, bgs
+		}
+		font.ByName["Menu"].Draw(screen, locale.G.Get("%s: %s", binding.name, binding.impulse.BindingNames()), m.Pos{X: CenterX, Y: ItemBaselineY(item, ControlsCount)}, font.Center, fg, bg)
+	}
+	fg, bg := fgn, bgn
+	if s.Item == ControlsReset {
+		fg, bg = fgs, bgs
+	}
+	font.ByName["Menu"].Draw(screen, locale.G.Get("Reset to Defaults"), m.Pos{X: CenterX, Y: ItemBaselineY(ControlsReset, ControlsCount)}, font.Center, fg, bg)
+	fg, bg = fgn, bgn
+	if s.Item == ControlsBack {
+		fg, bg = fgs, bgs
+	}
+	font.ByName["Menu"].Draw(screen, locale.G.Get("Back"), m.Pos{X: CenterX, Y: ItemBaselineY(ControlsBack, ControlsCount)}, font.Center, fg, bg)
+}
@@ -21,6 +21,7 @@ import (
 
 	"github.com/hajimehoshi/ebiten/v2"
 
+	"github.com/divVerent/aaaaxy/internal/demo"
 	"github.com/divVerent/aaaaxy/internal/engine"
 	"github.com/divVerent/aaaaxy/internal/exitstatus"
 	"github.com/divVerent/aaaaxy/internal/flag"
@@ -74,8 +75,10 @@ func (c *Controller) Update() error {
 
 	timing.Section("once")
 	if !c.initialized {
-		c.WhiteImage = ebiten.NewImage(1, 1)
-		c.WhiteImage.Fill(color.Gray{255})
+		if c.WhiteImage == nil {
+			c.WhiteImage = ebiten.NewImage(1, 1)
+			c.WhiteImage.Fill(color.Gray{255})
+		}
 
 		err := c.InitGame(loadGame)
 		if err != nil {
@@ -100,7 +103,7 @@ func (c *Controller) Update() error {
 		c.blurFrame = 0
 		c.creditsBlur = true
 		return c.SwitchToScreen(&CreditsScreen{Fancy: true})
-	} else if input.Exit.JustHit && c.Screen == nil && !c.World.TimerStopped {
+	} else if input.Exit.JustHit && c.Screen == nil && !c.World.TimerStopped && !c.World.InspectorEditing() {
 		if c.World.PlayerState.LastCheckpoint() != "" || c.World.PlayerState.Frames() > 0 {
 			c.World.TimerStarted = true
 		}
@@ -368,6 +371,29 @@ func (c *Controller) SwitchToCheckpoint(cp string) error {
 	return nil
 }
 
+// RestoreKeyframe is called by demo playback to jump to a keyframe.
+func (c *Controller) RestoreKeyframe(kf *demo.Keyframe) error {
+	err := c.World.RestoreKeyframe(kf)
+	if err != nil {
+		return fmt.Errorf("could not restore demo keyframe: %w", err)
+	}
+	c.Screen = nil
+	c.blurFrame = 0
+	return nil
+}
+
+// RestartGame makes the next Update start the game from scratch, as if it were the first frame.
+// Used by the tool-assisted mode to rewind to the start.
+func (c *Controller) RestartGame() {
+	c.initialized = false
+	c.Screen = nil
+	c.blurFrame = 0
+	c.creditsBlur = false
+	c.needReloadGame = false
+	c.nextFrame = nil
+	c.nextFrameReady = false
+}
+
 // SwitchToScreen is called by menu screens to go to a different menu screen.
 func (c *Controller) SwitchToScreen(screen MenuScreen) error {
 	c.Screen = screen
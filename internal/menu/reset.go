@@ -15,7 +15,6 @@
 package menu
 
 import (
-	"fmt"
 	"math/rand"
 
 	"github.com/hajimehoshi/ebiten/v2"
@@ -112,19 +111,7 @@ func (s *ResetScreen) Draw(screen *ebiten.Image) {
 	font.ByName["Menu"].Draw(screen, locale.G.Get("Reset and Lose Settings"), m.Pos{X: CenterX, Y: ItemBaselineY(ResetConfig, ResetCount)}, font.Center, fg, bg)
 	var resetText string
 	var dx, dy int
-	var save string
-	switch *saveState {
-	case 0:
-		save = "A"
-	case 1:
-		save = "4"
-	case 2:
-		save = "X"
-	case 3:
-		save = "Y"
-	default:
-		save = fmt.Sprint(*saveState)
-	}
+	save := engine.SaveSlotName(*saveState)
 	if s.ResetFrame >= resetFrames && s.Item == ResetGame {
 		fg, bg = palette.EGA(palette.Red, 255), palette.EGA(palette.Black, 255)
 		resetText = locale.G.Get("Reset and Lose SAVE STATE %s", save)
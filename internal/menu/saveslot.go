@@ -0,0 +1,238 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package menu
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"github.com/divVerent/aaaaxy/internal/engine"
+	"github.com/divVerent/aaaaxy/internal/font"
+	"github.com/divVerent/aaaaxy/internal/input"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	m "github.com/divVerent/aaaaxy/internal/math"
+	"github.com/divVerent/aaaaxy/internal/palette"
+)
+
+// maxSaveSlotNameLength is the maximum length of a save slot name in runes.
+const maxSaveSlotNameLength = 24
+
+type SaveSlotScreenItem int
+
+const (
+	SaveSlotSwitch = iota
+	SaveSlotRename
+	SaveSlotCopy
+	SaveSlotDelete
+	SaveSlotBack
+	SaveSlotCount
+)
+
+type SaveSlotScreen struct {
+	Controller *Controller
+	Item       SaveSlotScreenItem
+	Slot       engine.SaveSlot
+	Text       string
+
+	// Renaming is set while the name is being typed in.
+	Renaming bool
+	NewName  []rune
+
+	// ConfirmDelete is set after the first activation of the delete item.
+	ConfirmDelete bool
+}
+
+func (s *SaveSlotScreen) Init(m *Controller) error {
+	s.Controller = m
+	s.Text = saveStateInfo(s.Controller, s.Controller.World.Level.Clone(), s.Slot.Index)
+	return nil
+}
+
+func (s *SaveSlotScreen) backToList() error {
+	return s.Controller.SwitchToScreen(&SaveStateScreen{})
+}
+
+func (s *SaveSlotScreen) cancelRename() error {
+	s.Renaming = false
+	return s.Controller.MoveSound(nil)
+}
+
+func (s *SaveSlotScreen) confirmRename() error {
+	s.Renaming = false
+	if len(s.NewName) == 0 {
+		return s.Controller.MoveSound(nil)
+	}
+	s.Slot.Name = string(s.NewName)
+	err := engine.RenameSaveSlot(s.Slot.Index, s.Slot.Name)
+	if err != nil {
+		return s.Controller.ActivateSound(fmt.Errorf("could not rename save slot: %w", err))
+	}
+	return s.Controller.ActivateSound(nil)
+}
+
+func (s *SaveSlotScreen) updateRename() error {
+	if clicked := s.Controller.QueryMouseItem(&s.Item, SaveSlotCount); clicked != NotClicked {
+		if s.Item == SaveSlotRename {
+			return s.confirmRename()
+		}
+		return s.cancelRename()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		return s.cancelRename()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsKeyJustPressed(ebiten.KeyNumpadEnter) {
+		return s.confirmRename()
+	}
+	if !input.KeyboardInUse() {
+		// Nobody is typing, so the regular menu bindings apply.
+		if input.Exit.JustHit {
+			return s.cancelRename()
+		}
+		if input.Jump.JustHit || input.Action.JustHit {
+			return s.confirmRename()
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(s.NewName) > 0 {
+		s.NewName = s.NewName[:len(s.NewName)-1]
+	}
+	for _, r := range ebiten.AppendInputChars(nil) {
+		if len(s.NewName) < maxSaveSlotNameLength && r >= ' ' && r != utf8.RuneError {
+			s.NewName = append(s.NewName, r)
+		}
+	}
+	return nil
+}
+
+func (s *SaveSlotScreen) Update() error {
+	if s.Renaming {
+		// While typing, the regular menu bindings are ignored.
+		return s.updateRename()
+	}
+
+	clicked := s.Controller.QueryMouseItem(&s.Item, SaveSlotCount)
+
+	if s.Slot.Index == *saveState {
+		// Update so one can always see the current state.
+		s.Text = saveStateInfo(s.Controller, nil, *saveState)
+	}
+
+	if input.Down.JustHit {
+		s.Item++
+		s.Controller.MoveSound(nil)
+	}
+	if input.Up.JustHit {
+		s.Item--
+		s.Controller.MoveSound(nil)
+	}
+	s.Item = SaveSlotScreenItem(m.Mod(int(s.Item), int(SaveSlotCount)))
+	if s.Item != SaveSlotDelete {
+		s.ConfirmDelete = false
+	}
+	if input.Exit.JustHit {
+		return s.Controller.ActivateSound(s.backToList())
+	}
+	if input.Jump.JustHit || input.Action.JustHit || clicked != NotClicked {
+		switch s.Item {
+		case SaveSlotSwitch:
+			return s.Controller.ActivateSound(s.Controller.SwitchSaveState(s.Slot.Index))
+		case SaveSlotRename:
+			s.Renaming = true
+			s.NewName = []rune(s.Slot.Name)
+			return s.Controller.ActivateSound(nil)
+		case SaveSlotCopy:
+			if s.Slot.Index == *saveState {
+				// Make sure the copy has the latest state.
+				err := s.Controller.World.Save()
+				if err != nil {
+					return s.Controller.ActivateSound(fmt.Errorf("could not save game: %w", err))
+				}
+			}
+			slot, err := engine.CopySaveSlot(s.Slot.Index, locale.G.Get("%s (copy)", s.Slot.Name))
+			if err != nil {
+				return s.Controller.ActivateSound(fmt.Errorf("could not copy save slot: %w", err))
+			}
+			return s.Controller.ActivateSound(s.Controller.SwitchToScreen(&SaveSlotScreen{Slot: slot, Item: SaveSlotRename}))
+		case SaveSlotDelete:
+			if s.Slot.Index == *saveState {
+				// Cannot delete the slot being played.
+				return s.Controller.MoveSound(nil)
+			}
+			if !s.ConfirmDelete {
+				s.ConfirmDelete = true
+				return s.Controller.MoveSound(nil)
+			}
+			err := engine.DeleteSaveSlot(s.Slot.Index)
+			if err != nil {
+				return s.Controller.ActivateSound(fmt.Errorf("could not delete save slot: %w", err))
+			}
+			return s.Controller.ActivateSound(s.backToList())
+		case SaveSlotBack:
+			return s.Controller.ActivateSound(s.backToList())
+		}
+	}
+	return nil
+}
+
+func (s *SaveSlotScreen) Draw(screen *ebiten.Image) {
+	fgs := palette.EGA(palette.Yellow, 255)
+	bgs := palette.EGA(palette.Black, 255)
+	fgn := palette.EGA(palette.LightGrey, 255)
+	bgn := palette.EGA(palette.DarkGrey, 255)
+	font.ByName["MenuBig"].Draw(screen, locale.G.Get("Save State %s", s.Slot.Name), m.Pos{X: CenterX, Y: HeaderY}, font.Center, fgs, bgs)
+	font.ByName["Menu"].Draw(screen, s.Text, m.Pos{X: CenterX, Y: ItemBaselineY(-2, SaveSlotCount)}, font.Center, fgn, bgn)
+	fg, bg := fgn, bgn
+	if s.Item == SaveSlotSwitch {
+		fg, bg = fgs, bgs
+	}
+	switchText := locale.G.Get("Switch to This Save State")
+	if s.Slot.Index == *saveState {
+		switchText = locale.G.Get("Continue This Save State")
+	}
+	font.ByName["Menu"].Draw(screen, switchText, m.Pos{X: CenterX, Y: ItemBaselineY(SaveSlotSwitch, SaveSlotCount)}, font.Center, fg, bg)
+	fg, bg = fgn, bgn
+	if s.Item == SaveSlotRename {
+		fg, bg = fgs, bgs
+	}
+	renameText := locale.G.Get("Rename")
+	if s.Renaming {
+		renameText = locale.G.Get("Name: %s_ (Enter to confirm, Esc to cancel)", string(s.NewName))
+	}
+	font.ByName["Menu"].Draw(screen, renameText, m.Pos{X: CenterX, Y: ItemBaselineY(SaveSlotRename, SaveSlotCount)}, font.Center, fg, bg)
+	fg, bg = fgn, bgn
+	if s.Item == SaveSlotCopy {
+		fg, bg = fgs, bgs
+	}
+	font.ByName["Menu"].Draw(screen, locale.G.Get("Copy to New Save State"), m.Pos{X: CenterX, Y: ItemBaselineY(SaveSlotCopy, SaveSlotCount)}, font.Center, fg, bg)
+	fg, bg = fgn, bgn
+	deleteText := locale.G.Get("Delete")
+	if s.Item == SaveSlotDelete {
+		fg, bg = fgs, bgs
+		if s.Slot.Index == *saveState {
+			deleteText = locale.G.Get("Delete (not possible while in use)")
+		} else if s.ConfirmDelete {
+			fg, bg = palette.EGA(palette.LightRed, 255), palette.EGA(palette.Red, 255)
+			deleteText = locale.G.Get("Delete - REALLY?")
+		}
+	}
+	font.ByName["Menu"].Draw(screen, deleteText, m.Pos{X: CenterX, Y: ItemBaselineY(SaveSlotDelete, SaveSlotCount)}, font.Center, fg, bg)
+	fg, bg = fgn, bgn
+	if s.Item == SaveSlotBack {
+		fg, bg = fgs, bgs
+	}
+	font.ByName["Menu"].Draw(screen, locale.G.Get("Back"), m.Pos{X: CenterX, Y: ItemBaselineY(SaveSlotBack, SaveSlotCount)}, font.Center, fg, bg)
+}
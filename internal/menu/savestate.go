@@ -16,6 +16,7 @@ package menu
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/hajimehoshi/ebiten/v2"
 
@@ -25,33 +26,43 @@ import (
 	"github.com/divVerent/aaaaxy/internal/input"
 	"github.com/divVerent/aaaaxy/internal/level"
 	"github.com/divVerent/aaaaxy/internal/locale"
+	"github.com/divVerent/aaaaxy/internal/log"
 	m "github.com/divVerent/aaaaxy/internal/math"
 	"github.com/divVerent/aaaaxy/internal/palette"
 	"github.com/divVerent/aaaaxy/internal/playerstate"
 	"github.com/divVerent/aaaaxy/internal/vfs"
 )
 
+// saveSlotsPerPage is the number of save slots listed on one page of the menu.
+const saveSlotsPerPage = 4
+
 type SaveStateScreenItem int
 
+// SaveStateExtraItem is an item after the save slots of the current page.
+type SaveStateExtraItem int
+
 const (
-	SaveStateA = iota
-	SaveState4
-	SaveStateX
-	SaveStateY
+	SaveNew SaveStateExtraItem = iota
+	SavePage
 	SaveExit
-	SaveStateCount
+	SaveStateExtraCount
 )
 
 type SaveStateScreen struct {
 	Controller *Controller
 	Item       SaveStateScreenItem
-	Text       [4]string
+	Page       int
+	Slots      []engine.SaveSlot
+
+	// Text is the info text of each slot; only loaded for pages shown so far.
+	Text    []string
+	initLvl *level.Level
 }
 
-func (s *SaveStateScreen) saveStateInfo(initLvl *level.Level, idx int) string {
+func saveStateInfo(c *Controller, initLvl *level.Level, idx int) string {
 	var ps *playerstate.PlayerState
 	if idx == *saveState {
-		ps = &s.Controller.World.PlayerState
+		ps = &c.World.PlayerState
 	} else {
 		saveName := engine.SaveName(idx)
 		state, err := vfs.ReadState(vfs.SavedGames, saveName)
@@ -75,37 +86,107 @@ func (s *SaveStateScreen) saveStateInfo(initLvl *level.Level, idx int) string {
 	return fun.FormatText(ps, format)
 }
 
+// loadSaveSlots returns all save slots, including the current one even if it has not been registered.
+func loadSaveSlots() []engine.SaveSlot {
+	slots, err := engine.SaveSlots()
+	if err != nil {
+		log.Errorf("could not load save slots: %v", err)
+	}
+	for _, slot := range slots {
+		if slot.Index == *saveState {
+			return slots
+		}
+	}
+	var out []engine.SaveSlot
+	cur := engine.SaveSlot{Index: *saveState, Name: engine.SaveSlotName(*saveState)}
+	for _, slot := range slots {
+		if cur.Index >= 0 && slot.Index > cur.Index {
+			out = append(out, cur)
+			cur.Index = -1
+		}
+		out = append(out, slot)
+	}
+	if cur.Index >= 0 {
+		out = append(out, cur)
+	}
+	return out
+}
+
+func (s *SaveStateScreen) pages() int {
+	return (len(s.Slots) + saveSlotsPerPage - 1) / saveSlotsPerPage
+}
+
+// pageSlots returns the index range of the slots on the current page.
+func (s *SaveStateScreen) pageSlots() (int, int) {
+	start := s.Page * saveSlotsPerPage
+	end := start + saveSlotsPerPage
+	if end > len(s.Slots) {
+		end = len(s.Slots)
+	}
+	return start, end
+}
+
+func (s *SaveStateScreen) count() int {
+	start, end := s.pageSlots()
+	return end - start + int(SaveStateExtraCount)
+}
+
+// extraItem returns the item after the slots of the current page.
+func (s *SaveStateScreen) extraItem(i SaveStateExtraItem) SaveStateScreenItem {
+	start, end := s.pageSlots()
+	return SaveStateScreenItem(end - start + int(i))
+}
+
+// loadPage loads the info text of the slots on the current page.
+// Other slots' save games are not read until their page is shown.
+func (s *SaveStateScreen) loadPage() {
+	start, end := s.pageSlots()
+	for i := start; i < end; i++ {
+		if s.Text[i] == "" {
+			s.Text[i] = saveStateInfo(s.Controller, s.initLvl, s.Slots[i].Index)
+		}
+	}
+}
+
+func (s *SaveStateScreen) switchPage(page int) {
+	if s.pages() == 0 {
+		s.Page = 0
+	} else {
+		s.Page = m.Mod(page, s.pages())
+	}
+	s.loadPage()
+	// Keep the page item selected, so one can quickly flip through pages.
+	s.Item = s.extraItem(SavePage)
+}
+
 func (s *SaveStateScreen) Init(m *Controller) error {
 	s.Controller = m
+	s.Slots = loadSaveSlots()
 
-	initLvl := s.Controller.World.Level.Clone()
-
-	s.Text[0] = s.saveStateInfo(initLvl, 0)
-	s.Text[1] = s.saveStateInfo(initLvl, 1)
-	s.Text[2] = s.saveStateInfo(initLvl, 2)
-	s.Text[3] = s.saveStateInfo(initLvl, 3)
-	switch *saveState {
-	case 0:
-		s.Item = SaveStateA
-	case 1:
-		s.Item = SaveState4
-	case 2:
-		s.Item = SaveStateX
-	case 3:
-		s.Item = SaveStateY
-	default:
-		s.Item = SaveExit
-		return nil
+	s.initLvl = s.Controller.World.Level.Clone()
+
+	s.Text = make([]string, len(s.Slots))
+	for i, slot := range s.Slots {
+		if slot.Index == *saveState {
+			s.Page = i / saveSlotsPerPage
+			s.Item = SaveStateScreenItem(i % saveSlotsPerPage)
+		}
 	}
+	s.loadPage()
 	return nil
 }
 
 func (s *SaveStateScreen) Update() error {
-	clicked := s.Controller.QueryMouseItem(&s.Item, SaveStateCount)
+	n := s.count()
+	clicked := s.Controller.QueryMouseItem(&s.Item, n)
+
+	start, end := s.pageSlots()
 
 	// Update so one can always see which save state is current.
-	if *saveState >= 0 && *saveState < 4 {
-		s.Text[*saveState] = s.saveStateInfo(nil, *saveState)
+	for i := start; i < end; i++ {
+		if s.Slots[i].Index == *saveState {
+			s.Text[i] = saveStateInfo(s.Controller, nil, *saveState)
+		}
 	}
 
 	if input.Down.JustHit {
@@ -116,22 +197,35 @@ func (s *SaveStateScreen) Update() error {
 		s.Item--
 		s.Controller.MoveSound(nil)
 	}
-	s.Item = SaveStateScreenItem(m.Mod(int(s.Item), int(SaveStateCount)))
+	s.Item = SaveStateScreenItem(m.Mod(int(s.Item), n))
+	if s.Item == s.extraItem(SavePage) {
+		if input.Left.JustHit || clicked == LeftClicked {
+			s.switchPage(s.Page - 1)
+			return s.Controller.MoveSound(nil)
+		}
+		if input.Right.JustHit || clicked == RightClicked {
+			s.switchPage(s.Page + 1)
+			return s.Controller.MoveSound(nil)
+		}
+	}
 	if input.Exit.JustHit {
 		return s.Controller.ActivateSound(s.Controller.SwitchToScreen(&SettingsScreen{}))
 	}
 	if input.Jump.JustHit || input.Action.JustHit || clicked != NotClicked {
 		switch s.Item {
-		case SaveStateA:
-			return s.Controller.ActivateSound(s.Controller.SwitchSaveState(0))
-		case SaveState4:
-			return s.Controller.ActivateSound(s.Controller.SwitchSaveState(1))
-		case SaveStateX:
-			return s.Controller.ActivateSound(s.Controller.SwitchSaveState(2))
-		case SaveStateY:
-			return s.Controller.ActivateSound(s.Controller.SwitchSaveState(3))
-		case SaveExit:
+		case s.extraItem(SaveNew):
+			slot, err := engine.NewSaveSlot("")
+			if err != nil {
+				return s.Controller.ActivateSound(fmt.Errorf("could not create save slot: %w", err))
+			}
+			return s.Controller.ActivateSound(s.Controller.SwitchToScreen(&SaveSlotScreen{Slot: slot, Item: SaveSlotRename}))
+		case s.extraItem(SavePage):
+			s.switchPage(s.Page + 1)
+			return s.Controller.MoveSound(nil)
+		case s.extraItem(SaveExit):
 			return s.Controller.ActivateSound(s.Controller.SwitchToScreen(&SettingsScreen{}))
+		default:
+			return s.Controller.ActivateSound(s.Controller.SwitchToScreen(&SaveSlotScreen{Slot: s.Slots[start+int(s.Item)]}))
 		}
 	}
 	return nil
@@ -143,29 +237,32 @@ func (s *SaveStateScreen) Draw(screen *ebiten.Image) {
 	fgn := palette.EGA(palette.LightGrey, 255)
 	bgn := palette.EGA(palette.DarkGrey, 255)
 	font.ByName["MenuBig"].Draw(screen, locale.G.Get("Switch Save State"), m.Pos{X: CenterX, Y: HeaderY}, font.Center, fgs, bgs)
-	fg, bg := fgn, bgn
-	if s.Item == SaveStateA {
-		fg, bg = fgs, bgs
-	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("A: %s", s.Text[0]), m.Pos{X: CenterX, Y: ItemBaselineY(SaveStateA, SaveStateCount)}, font.Center, fg, bg)
-	fg, bg = fgn, bgn
-	if s.Item == SaveState4 {
-		fg, bg = fgs, bgs
+	n := s.count()
+	start, end := s.pageSlots()
+	for i := start; i < end; i++ {
+		fg, bg := fgn, bgn
+		if s.Item == SaveStateScreenItem(i-start) {
+			fg, bg = fgs, bgs
+		}
+		text := locale.G.Get("%s: %s", s.Slots[i].Name, s.Text[i])
+		if s.Slots[i].Index == *saveState {
+			text = locale.G.Get("%s: %s (current)", s.Slots[i].Name, s.Text[i])
+		}
+		font.ByName["Menu"].Draw(screen, text, m.Pos{X: CenterX, Y: ItemBaselineY(i-start, n)}, font.Center, fg, bg)
 	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("4: %s", s.Text[1]), m.Pos{X: CenterX, Y: ItemBaselineY(SaveState4, SaveStateCount)}, font.Center, fg, bg)
-	fg, bg = fgn, bgn
-	if s.Item == SaveStateX {
+	fg, bg := fgn, bgn
+	if s.Item == s.extraItem(SaveNew) {
 		fg, bg = fgs, bgs
 	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("X: %s", s.Text[2]), m.Pos{X: CenterX, Y: ItemBaselineY(SaveStateX, SaveStateCount)}, font.Center, fg, bg)
+	font.ByName["Menu"].Draw(screen, locale.G.Get("New Save State"), m.Pos{X: CenterX, Y: ItemBaselineY(int(s.extraItem(SaveNew)), n)}, font.Center, fg, bg)
 	fg, bg = fgn, bgn
-	if s.Item == SaveStateY {
+	if s.Item == s.extraItem(SavePage) {
 		fg, bg = fgs, bgs
 	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("Y: %s", s.Text[3]), m.Pos{X: CenterX, Y: ItemBaselineY(SaveStateY, SaveStateCount)}, font.Center, fg, bg)
+	font.ByName["Menu"].Draw(screen, locale.G.Get("Page: %d/%d", s.Page+1, max(s.pages(), 1)), m.Pos{X: CenterX, Y: ItemBaselineY(int(s.extraItem(SavePage)), n)}, font.Center, fg, bg)
 	fg, bg = fgn, bgn
-	if s.Item == SaveExit {
+	if s.Item == s.extraItem(SaveExit) {
 		fg, bg = fgs, bgs
 	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("Main Menu"), m.Pos{X: CenterX, Y: ItemBaselineY(SaveExit, SaveStateCount)}, font.Center, fg, bg)
+	font.ByName["Menu"].Draw(screen, locale.G.Get("Main Menu"), m.Pos{X: CenterX, Y: ItemBaselineY(int(s.extraItem(SaveExit)), n)}, font.Center, fg, bg)
 }
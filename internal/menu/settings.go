@@ -47,11 +47,11 @@ type SettingsScreenItem int
 
 const (
 	Dynamic1 = iota
-	Dynamic2
 	Graphics
 	Quality
 	Volume
 	Language
+	Controls
 	SaveState
 	Reset
 	Back
@@ -64,7 +64,6 @@ type SettingsScreen struct {
 	CurrentGraphics graphicsSetting
 	CurrentLanguage languageSetting
 	TopItem         SettingsScreenItem
-	EditControls    SettingsScreenItem
 	Fullscreen      SettingsScreenItem
 	Stretch         SettingsScreenItem
 }
@@ -86,12 +85,6 @@ func (s *SettingsScreen) Init(m *Controller) error {
 		s.Fullscreen = SettingsCount
 		s.Stretch = SettingsCount
 	}
-	if input.HaveTouch() {
-		s.TopItem--
-		s.EditControls = s.TopItem
-	} else {
-		s.EditControls = SettingsCount
-	}
 	s.Item = s.TopItem
 	return nil
 }
@@ -340,8 +333,6 @@ func (s *SettingsScreen) Update() error {
 			return s.Controller.ActivateSound(s.Controller.toggleFullscreen())
 		case s.Stretch:
 			return s.Controller.ActivateSound(s.Controller.toggleStretch())
-		case s.EditControls:
-			return s.Controller.ActivateSound(s.Controller.SaveConfigAndSwitchToScreen(&TouchEditScreen{}))
 		case Graphics:
 			return s.Controller.ActivateSound(s.toggleGraphics(0))
 		case Quality:
@@ -350,6 +341,8 @@ func (s *SettingsScreen) Update() error {
 			return s.Controller.ActivateSound(toggleVolume(0))
 		case Language:
 			return s.Controller.ActivateSound(s.CurrentLanguage.toggle(s.Controller, 0))
+		case Controls:
+			return s.Controller.ActivateSound(s.Controller.SaveConfigAndSwitchToScreen(&ControlsScreen{}))
 		case SaveState:
 			return s.Controller.ActivateSound(s.Controller.SaveConfigAndSwitchToScreen(&SaveStateScreen{}))
 		case Reset:
@@ -364,8 +357,6 @@ func (s *SettingsScreen) Update() error {
 			return s.Controller.ActivateSound(s.Controller.toggleFullscreen())
 		case s.Stretch:
 			return s.Controller.ActivateSound(s.Controller.toggleStretch())
-		case s.EditControls:
-			return s.Controller.ActivateSound(s.Controller.SaveConfigAndSwitchToScreen(&TouchEditScreen{}))
 		case Graphics:
 			return s.Controller.ActivateSound(s.toggleGraphics(-1))
 		case Quality:
@@ -382,8 +373,6 @@ func (s *SettingsScreen) Update() error {
 			return s.Controller.ActivateSound(s.Controller.toggleFullscreen())
 		case s.Stretch:
 			return s.Controller.ActivateSound(s.Controller.toggleStretch())
-		case s.EditControls:
-			return s.Controller.ActivateSound(s.Controller.SaveConfigAndSwitchToScreen(&TouchEditScreen{}))
 		case Graphics:
 			return s.Controller.ActivateSound(s.toggleGraphics(+1))
 		case Quality:
@@ -403,13 +392,6 @@ func (s *SettingsScreen) Draw(screen *ebiten.Image) {
 	fgn := palette.EGA(palette.LightGrey, 255)
 	bgn := palette.EGA(palette.DarkGrey, 255)
 	font.ByName["MenuBig"].Draw(screen, locale.G.Get("Settings"), m.Pos{X: CenterX, Y: HeaderY}, font.Center, fgs, bgs)
-	if s.EditControls != SettingsCount {
-		fg, bg := fgn, bgn
-		if s.Item == s.EditControls {
-			fg, bg = fgs, bgs
-		}
-		font.ByName["Menu"].Draw(screen, locale.G.Get("Edit Touch Controls"), m.Pos{X: CenterX, Y: ItemBaselineY(int(s.EditControls), SettingsCount)}, font.Center, fg, bg)
-	}
 	if s.Fullscreen != SettingsCount {
 		fg, bg := fgn, bgn
 		if s.Item == s.Fullscreen {
@@ -453,6 +435,11 @@ func (s *SettingsScreen) Draw(screen *ebiten.Image) {
 	}
 	font.ByName["Menu"].Draw(screen, locale.G.Get("Language: %s", s.CurrentLanguage.name()), m.Pos{X: CenterX, Y: ItemBaselineY(Language, SettingsCount)}, font.Center, fg, bg)
 	fg, bg = fgn, bgn
+	if s.Item == Controls {
+		fg, bg = fgs, bgs
+	}
+	font.ByName["Menu"].Draw(screen, locale.G.Get("Controls"), m.Pos{X: CenterX, Y: ItemBaselineY(Controls, SettingsCount)}, font.Center, fg, bg)
+	fg, bg = fgn, bgn
 	if s.Item == SaveState {
 		fg, bg = fgs, bgs
 	}
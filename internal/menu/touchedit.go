@@ -15,11 +15,14 @@
 package menu
 
 import (
+	"math"
+
 	"github.com/hajimehoshi/ebiten/v2"
 
 	"github.com/divVerent/aaaaxy/internal/font"
 	"github.com/divVerent/aaaaxy/internal/input"
 	"github.com/divVerent/aaaaxy/internal/locale"
+	"github.com/divVerent/aaaaxy/internal/log"
 	m "github.com/divVerent/aaaaxy/internal/math"
 	"github.com/divVerent/aaaaxy/internal/palette"
 )
@@ -27,14 +30,32 @@ import (
 type TouchEditScreenItem int
 
 const (
-	TouchDone = iota
+	TouchLayout = iota
+	TouchOpacity
+	TouchSize
+	TouchExport
+	TouchImport
 	TouchReset
+	TouchDone
 	TouchCount
 )
 
+const (
+	// touchOpacityStep is how much the opacity changes per step.
+	touchOpacityStep = 0.1
+	// touchMinOpacity keeps touch controls visible.
+	touchMinOpacity = 0.2
+	// touchSizeStep is how much the size changes per step.
+	touchSizeStep = 0.25
+	// touchMinSize and touchMaxSize keep the touch controls usable.
+	touchMinSize = 0.5
+	touchMaxSize = 1.5
+)
+
 type TouchEditScreen struct {
 	Controller *Controller
 	Item       TouchEditScreenItem
+	Message    string
 }
 
 func (s *TouchEditScreen) Init(m *Controller) error {
@@ -47,6 +68,87 @@ func touchReset() error {
 	return nil
 }
 
+// touchLayoutName returns the display name of a touch layout.
+func touchLayoutName(name string) string {
+	switch name {
+	case input.TouchLayoutCustom:
+		return locale.G.Get("Custom")
+	case "standard":
+		return locale.G.Get("Standard")
+	case "left_handed":
+		return locale.G.Get("Left-Handed")
+	case "compact":
+		return locale.G.Get("Compact")
+	case "tablet":
+		return locale.G.Get("Tablet")
+	case "large_buttons":
+		return locale.G.Get("Large Buttons")
+	default:
+		return name
+	}
+}
+
+// step changes a value in steps, wrapping around when delta is zero.
+func step(value float64, delta int, stepSize, min, max float64) float64 {
+	if delta == 0 {
+		value += stepSize
+		if value > max+1e-9 {
+			value = min
+		}
+	} else {
+		value += float64(delta) * stepSize
+		value = math.Min(math.Max(value, min), max)
+	}
+	return math.Round(value/stepSize) * stepSize
+}
+
+func (s *TouchEditScreen) change(delta int) error {
+	layout := input.CurrentTouchLayout()
+	switch s.Item {
+	case TouchLayout:
+		i := 0
+		for i < len(input.TouchLayoutNames)-1 && input.TouchLayoutNames[i] != input.TouchLayoutName() {
+			i++
+		}
+		if delta == 0 {
+			delta = 1
+		}
+		s.Message = ""
+		return input.SetTouchLayoutName(input.TouchLayoutNames[m.Mod(i+delta, len(input.TouchLayoutNames))])
+	case TouchOpacity:
+		return input.SetTouchLayoutOpacity(step(layout.Opacity, delta, touchOpacityStep, touchMinOpacity, 1))
+	case TouchSize:
+		return input.SetTouchLayoutScale(step(layout.Scale, delta, touchSizeStep, touchMinSize, touchMaxSize))
+	}
+	if delta != 0 {
+		return nil
+	}
+	switch s.Item {
+	case TouchExport:
+		err := input.ExportTouchLayouts()
+		if err != nil {
+			log.Errorf("could not export touch layouts: %v", err)
+			s.Message = locale.G.Get("Could not export the layouts.")
+			return nil
+		}
+		s.Message = locale.G.Get("Exported the layouts to %s.", input.TouchLayoutFile())
+	case TouchImport:
+		err := input.ImportTouchLayouts()
+		if err != nil {
+			log.Errorf("could not import touch layouts: %v", err)
+			s.Message = locale.G.Get("Could not import the layouts.")
+			return nil
+		}
+		s.Message = locale.G.Get("Imported the layouts from %s.", input.TouchLayoutFile())
+	case TouchReset:
+		s.Message = ""
+		return touchReset()
+	case TouchDone:
+		return s.Controller.SaveConfigAndSwitchToScreen(&ControlsScreen{})
+	}
+	return nil
+}
+
 func (s *TouchEditScreen) Update() error {
 	clicked := s.Controller.QueryMouseItem(&s.Item, TouchCount)
 	if input.Down.JustHit {
@@ -59,15 +161,16 @@ func (s *TouchEditScreen) Update() error {
 	}
 	s.Item = TouchEditScreenItem(m.Mod(int(s.Item), int(TouchCount)))
 	if input.Exit.JustHit {
-		return s.Controller.ActivateSound(s.Controller.SwitchToScreen(&SettingsScreen{}))
+		return s.Controller.ActivateSound(s.Controller.SwitchToScreen(&ControlsScreen{}))
 	}
-	if input.Jump.JustHit || input.Action.JustHit || clicked != NotClicked {
-		switch s.Item {
-		case TouchDone:
-			return s.Controller.ActivateSound(s.Controller.SaveConfigAndSwitchToScreen(&SettingsScreen{}))
-		case TouchReset:
-			return s.Controller.ActivateSound(touchReset())
-		}
+	if input.Jump.JustHit || input.Action.JustHit || clicked == CenterClicked {
+		return s.Controller.ActivateSound(s.change(0))
+	}
+	if input.Left.JustHit || clicked == LeftClicked {
+		return s.Controller.ActivateSound(s.change(-1))
+	}
+	if input.Right.JustHit || clicked == RightClicked {
+		return s.Controller.ActivateSound(s.change(+1))
 	}
 	return nil
 }
@@ -79,14 +182,22 @@ func (s *TouchEditScreen) Draw(screen *ebiten.Image) {
 	fgn := palette.EGA(palette.LightGrey, 255)
 	bgn := palette.EGA(palette.DarkGrey, 255)
 	font.ByName["MenuBig"].Draw(screen, locale.G.Get("Edit Touch Controls"), m.Pos{X: CenterX, Y: HeaderY}, font.Center, fgs, bgs)
-	fg, bg := fgn, bgn
-	if s.Item == TouchDone {
-		fg, bg = fgs, bgs
+	item := func(i TouchEditScreenItem, text string) {
+		fg, bg := fgn, bgn
+		if s.Item == i {
+			fg, bg = fgs, bgs
+		}
+		font.ByName["Menu"].Draw(screen, text, m.Pos{X: CenterX, Y: ItemBaselineY(int(i), TouchCount)}, font.Center, fg, bg)
 	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("Done"), m.Pos{X: CenterX, Y: ItemBaselineY(TouchDone, TouchCount)}, font.Center, fg, bg)
-	fg, bg = fgn, bgn
-	if s.Item == TouchReset {
-		fg, bg = fgs, bgs
+	layout := input.CurrentTouchLayout()
+	item(TouchLayout, locale.G.Get("Layout for %s: %s", input.TouchAspect(), touchLayoutName(input.TouchLayoutName())))
+	item(TouchOpacity, locale.G.Get("Opacity: %d%%", int(math.Round(layout.Opacity*100))))
+	item(TouchSize, locale.G.Get("Size: %d%%", int(math.Round(layout.Scale*100))))
+	item(TouchExport, locale.G.Get("Export Layouts"))
+	item(TouchImport, locale.G.Get("Import Layouts"))
+	item(TouchReset, locale.G.Get("Reset to Defaults"))
+	item(TouchDone, locale.G.Get("Done"))
+	if s.Message != "" {
+		font.ByName["MenuSmall"].Draw(screen, s.Message, m.Pos{X: CenterX, Y: ItemBaselineY(TouchCount, TouchCount)}, font.Center, fgn, bgn)
 	}
-	font.ByName["Menu"].Draw(screen, locale.G.Get("Reset to Defaults"), m.Pos{X: CenterX, Y: ItemBaselineY(TouchReset, TouchCount)}, font.Center, fg, bg)
 }
@@ -134,6 +134,9 @@ func (s *PlayerState) RecordCheckpoint(name string, flipped bool) bool {
 		flip = "FlipX"
 	}
 	updated := false
+	if propmap.StringOr(s.Level.Player.PersistentState, "checkpoint_seen."+name, "") == "" {
+		s.recordSplit(name)
+	}
 	if propmap.StringOr(s.Level.Player.PersistentState, "checkpoint_seen."+name, "") != flip {
 		propmap.Set(s.Level.Player.PersistentState, "checkpoint_seen."+name, flip)
 		updated = true
@@ -221,6 +224,11 @@ func (s *PlayerState) SetWon() {
 	propmap.Set(s.Level.Player.PersistentState, "won", true)
 }
 
+// Edited returns whether the save game has been deliberately edited, e.g. by cmd/savetool.
+func (s *PlayerState) Edited() bool {
+	return propmap.ValueOrP(s.Level.Player.PersistentState, "edited", false, nil)
+}
+
 type SpeedrunCategories int
 
 const (
@@ -333,7 +341,7 @@ func (c SpeedrunCategories) describeCommon() (categories []SpeedrunCategories, t
 			}
 		}
 	}
-	if is, _ := flag.Cheating(); is {
+	if is, _ := flag.Cheating(); is || c.ContainAll(cheatingSpeedrun) {
 		addCategory(cheatingSpeedrun, 0)
 		addCategory(withoutCheatsSpeedrun, impossibleSpeedrun)
 	} else if c.ContainAll(AllCheckpointsSpeedrun) {
@@ -510,5 +518,9 @@ func (s *PlayerState) SpeedrunCategories() SpeedrunCategories {
 		// Probably can't be combined with much.
 		cat &^= NoPushSpeedrun
 	}
+	if s.Edited() {
+		// Edited save games count as cheating forever.
+		cat |= cheatingSpeedrun
+	}
 	return cat
 }
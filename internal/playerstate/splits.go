@@ -0,0 +1,147 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package playerstate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/divVerent/aaaaxy/internal/gametime"
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/locale"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+func (s *PlayerState) recordSplit(name string) {
+	if name == "" {
+		// The start of the game is not a split.
+		return
+	}
+	frames := s.Frames()
+	prev := 0
+	if n := len(s.Level.Splits); n > 0 {
+		prev = s.Level.Splits[n-1].Frames
+	}
+	s.Level.Splits = append(s.Level.Splits, level.Split{
+		Checkpoint: name,
+		Frames:     frames,
+		Segment:    frames - prev,
+	})
+}
+
+// Splits returns all splits of the current run in the order they were reached.
+// The returned slice must not be modified.
+func (s *PlayerState) Splits() []level.Split {
+	return s.Level.Splits
+}
+
+// PersonalBest are the best times achieved so far.
+// It is stored separately from save games so it survives starting a new game.
+type PersonalBest struct {
+	// Segments are the best segment times ever seen per checkpoint.
+	Segments map[string]int `json:",omitempty"`
+	// Splits are the split times of the fastest finished run.
+	Splits map[string]int `json:",omitempty"`
+	// Frames is the total time of the fastest finished run.
+	Frames int `json:",omitempty"`
+}
+
+// LoadPersonalBest loads the personal best from the given state file.
+// A missing file yields an empty personal best.
+func LoadPersonalBest(name string) (*PersonalBest, error) {
+	pb := &PersonalBest{}
+	data, err := vfs.ReadState(vfs.SavedGames, name)
+	if errors.Is(err, os.ErrNotExist) {
+		return pb, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, pb)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode personal best %v: %w", name, err)
+	}
+	return pb, nil
+}
+
+// Save writes the personal best to the given state file.
+func (pb *PersonalBest) Save(name string) error {
+	data, err := json.MarshalIndent(pb, "", "\t")
+	if err != nil {
+		return err
+	}
+	return vfs.WriteState(vfs.SavedGames, name, data)
+}
+
+// Update merges the current run into the personal best and returns whether anything changed.
+func (pb *PersonalBest) Update(s *PlayerState) bool {
+	updated := false
+	splits := s.Splits()
+	for _, split := range splits {
+		best, found := pb.Segments[split.Checkpoint]
+		if found && best <= split.Segment {
+			continue
+		}
+		if pb.Segments == nil {
+			pb.Segments = map[string]int{}
+		}
+		pb.Segments[split.Checkpoint] = split.Segment
+		updated = true
+	}
+	if s.Won() && (pb.Frames == 0 || s.Frames() < pb.Frames) {
+		pb.Frames = s.Frames()
+		pb.Splits = make(map[string]int, len(splits))
+		for _, split := range splits {
+			pb.Splits[split.Checkpoint] = split.Frames
+		}
+		updated = true
+	}
+	return updated
+}
+
+// Clone returns a deep copy of the personal best.
+func (pb *PersonalBest) Clone() *PersonalBest {
+	out := &PersonalBest{
+		Segments: make(map[string]int, len(pb.Segments)),
+		Splits:   make(map[string]int, len(pb.Splits)),
+		Frames:   pb.Frames,
+	}
+	for k, v := range pb.Segments {
+		out.Segments[k] = v
+	}
+	for k, v := range pb.Splits {
+		out.Splits[k] = v
+	}
+	return out
+}
+
+// FormatFrames formats a game time.
+func FormatFrames(frames int) string {
+	hh, mm, ss, ms := gametime.Clock(frames)
+	return locale.G.Get("%d:%02d:%02d.%03d", hh, mm, ss, ms)
+}
+
+// FormatDelta formats a signed time difference.
+func FormatDelta(frames int) string {
+	sign := "+"
+	if frames < 0 {
+		sign = "-"
+		frames = -frames
+	}
+	ss, ms := gametime.Seconds(frames)
+	return locale.G.Get("%s%d.%03d", sign, ss, ms)
+}
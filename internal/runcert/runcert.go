@@ -0,0 +1,151 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package runcert implements run certificates for leaderboard submission.
+//
+// A run certificate bundles the demo of a run with its final save game and
+// the claimed time and speedrun categories. All parts are tied together by a
+// hash chain, so no part can be swapped without the certificate breaking.
+//
+// The chain is plain, unkeyed SHA-256: it proves the integrity of the
+// certificate, i.e. that it was not damaged or edited carelessly, but not its
+// authenticity, as anyone can compute a new chain after editing. The actual
+// proof of a run is replaying its demo and comparing the outcome against the
+// claims, as cmd/verifyrun does.
+package runcert
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/playerstate"
+)
+
+// Claims are what the run claims to have achieved.
+type Claims struct {
+	GameVersion string
+	LevelHash   uint64
+	Frames      int
+	Categories  playerstate.SpeedrunCategories
+	// CategoryNames is only informational, for humans reading the certificate.
+	CategoryNames string
+}
+
+// Certificate is a run certificate.
+type Certificate struct {
+	Claims
+	FinalSaveGame *level.SaveGame
+	Demo          []byte
+	// Chain is the hex encoded last link of the hash chain over demo, final save game and claims.
+	Chain string
+}
+
+// Evaluate computes the claims of a run from its final save game.
+// The given level is not modified.
+func Evaluate(lvl *level.Level, final *level.SaveGame) (*Claims, error) {
+	lvl = lvl.Clone()
+	err := lvl.LoadGame(final)
+	if err != nil {
+		return nil, fmt.Errorf("could not load final save game: %w", err)
+	}
+	ps := playerstate.PlayerState{Level: lvl}
+	ps.Init()
+	cats := ps.SpeedrunCategories()
+	names, _ := cats.Describe()
+	return &Claims{
+		GameVersion:   final.GameVersion,
+		LevelHash:     lvl.Hash,
+		Frames:        ps.Frames(),
+		Categories:    cats,
+		CategoryNames: names,
+	}, nil
+}
+
+// New creates a certificate for the given demo and final save game.
+func New(lvl *level.Level, demo []byte, final *level.SaveGame) (*Certificate, error) {
+	claims, err := Evaluate(lvl, final)
+	if err != nil {
+		return nil, err
+	}
+	c := &Certificate{
+		Claims:        *claims,
+		FinalSaveGame: final,
+		Demo:          demo,
+	}
+	c.Chain, err = c.chain()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// chain computes the hash chain over all parts of the certificate.
+func (c *Certificate) chain() (string, error) {
+	save, err := json.Marshal(c.FinalSaveGame)
+	if err != nil {
+		return "", fmt.Errorf("could not encode final save game: %w", err)
+	}
+	claims, err := json.Marshal(c.Claims)
+	if err != nil {
+		return "", fmt.Errorf("could not encode claims: %w", err)
+	}
+	return chainOf(c.Demo, save, claims), nil
+}
+
+// chainOf returns the hex encoded last link of the hash chain over the given parts.
+func chainOf(parts ...[]byte) string {
+	var link []byte
+	for _, part := range parts {
+		partHash := sha256.Sum256(part)
+		h := sha256.New()
+		h.Write(link)
+		h.Write(partHash[:])
+		link = h.Sum(nil)
+	}
+	return hex.EncodeToString(link)
+}
+
+// Verify checks that the hash chain is intact.
+// It does not check the claims; for that, the demo needs to be replayed.
+func (c *Certificate) Verify() error {
+	chain, err := c.chain()
+	if err != nil {
+		return err
+	}
+	if chain != c.Chain {
+		return fmt.Errorf("hash chain mismatch: got %v, want %v", chain, c.Chain)
+	}
+	return nil
+}
+
+// Write writes the certificate.
+func (c *Certificate) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(c)
+}
+
+// Read reads a certificate.
+func Read(r io.Reader) (*Certificate, error) {
+	c := &Certificate{}
+	err := json.NewDecoder(r).Decode(c)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
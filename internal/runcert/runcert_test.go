@@ -0,0 +1,143 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runcert
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/divVerent/aaaaxy/internal/level"
+	"github.com/divVerent/aaaaxy/internal/playerstate"
+	"github.com/divVerent/aaaaxy/internal/propmap"
+	"github.com/divVerent/aaaaxy/internal/vfs"
+)
+
+func TestMain(m *testing.M) {
+	// The VFS finds the assets relative to the source root.
+	err := os.Chdir("../..")
+	if err != nil {
+		panic(err)
+	}
+	err = vfs.Init()
+	if err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// testLevel is the main level, loaded once as it is not modified by the tests.
+var testLevel *level.Level
+
+// newCertificate creates a certificate for a fresh game of the main level.
+func newCertificate(t *testing.T) *Certificate {
+	t.Helper()
+	if testLevel == nil {
+		lvl, err := level.NewLoader("level").SkipCheckpointLocations(true).Load()
+		if err != nil {
+			t.Fatalf("could not load level: %v", err)
+		}
+		testLevel = lvl
+	}
+	save, err := testLevel.SaveGame()
+	if err != nil {
+		t.Fatalf("could not save game: %v", err)
+	}
+	c, err := New(testLevel, []byte("some demo"), save)
+	if err != nil {
+		t.Fatalf("could not create certificate: %v", err)
+	}
+	return c
+}
+
+func TestRoundTrip(t *testing.T) {
+	c := newCertificate(t)
+	err := c.Verify()
+	if err != nil {
+		t.Fatalf("new certificate does not verify: %v", err)
+	}
+	var buf bytes.Buffer
+	err = c.Write(&buf)
+	if err != nil {
+		t.Fatalf("could not write certificate: %v", err)
+	}
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("could not read certificate: %v", err)
+	}
+	err = got.Verify()
+	if err != nil {
+		t.Errorf("certificate does not verify after reading it back: %v", err)
+	}
+	if got.Claims != c.Claims || got.Chain != c.Chain {
+		t.Errorf("certificate changed after reading it back: got %+v %v, want %+v %v", got.Claims, got.Chain, c.Claims, c.Chain)
+	}
+}
+
+func TestTampered(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		tamper func(c *Certificate)
+	}{
+		{"demo", func(c *Certificate) {
+			c.Demo[0] ^= 1
+		}},
+		{"save game", func(c *Certificate) {
+			c.FinalSaveGame.State[1234] = propmap.New()
+		}},
+		{"category", func(c *Certificate) {
+			c.Categories |= playerstate.AllSecretsSpeedrun
+		}},
+		{"frames", func(c *Certificate) {
+			c.Frames--
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newCertificate(t)
+			tc.tamper(c)
+			err := c.Verify()
+			if err == nil {
+				t.Errorf("tampered certificate verifies")
+			}
+		})
+	}
+}
+
+func TestReorderedChain(t *testing.T) {
+	c := newCertificate(t)
+	save, err := json.Marshal(c.FinalSaveGame)
+	if err != nil {
+		t.Fatalf("could not encode final save game: %v", err)
+	}
+	claims, err := json.Marshal(c.Claims)
+	if err != nil {
+		t.Fatalf("could not encode claims: %v", err)
+	}
+	if got := chainOf(c.Demo, save, claims); got != c.Chain {
+		t.Fatalf("chain of the certificate parts: got %v, want %v", got, c.Chain)
+	}
+	for _, parts := range [][][]byte{
+		{save, c.Demo, claims},
+		{c.Demo, claims, save},
+		{claims, save, c.Demo},
+	} {
+		c.Chain = chainOf(parts...)
+		err := c.Verify()
+		if err == nil {
+			t.Errorf("certificate with reordered chain verifies")
+		}
+	}
+}
@@ -0,0 +1,427 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package script
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	identToken tokenKind = iota
+	intToken
+	stringToken
+	opToken
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// operators lists all operators, longest first so that lexing is greedy.
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "(", ")", ",", "=", "<", ">", "+", "-", "*", "/", "%", "!"}
+
+func isIdentRune(r byte, first bool) bool {
+	if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+		return true
+	}
+	return !first && r >= '0' && r <= '9'
+}
+
+// lex splits a line into tokens, dropping comments.
+func lex(line string) ([]token, error) {
+	var out []token
+	i := 0
+	for i < len(line) {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '#':
+			return out, nil
+		case isIdentRune(c, true):
+			j := i + 1
+			for j < len(line) && isIdentRune(line[j], false) {
+				j++
+			}
+			out = append(out, token{identToken, line[i:j]})
+			i = j
+		case c >= '0' && c <= '9':
+			j := i + 1
+			for j < len(line) && line[j] >= '0' && line[j] <= '9' {
+				j++
+			}
+			out = append(out, token{intToken, line[i:j]})
+			i = j
+		case c == '"':
+			var sb strings.Builder
+			j := i + 1
+			for {
+				if j >= len(line) {
+					return nil, fmt.Errorf("unterminated string")
+				}
+				if line[j] == '"' {
+					break
+				}
+				if line[j] == '\\' && j+1 < len(line) {
+					j++
+				}
+				sb.WriteByte(line[j])
+				j++
+			}
+			out = append(out, token{stringToken, sb.String()})
+			i = j + 1
+		default:
+			found := false
+			for _, op := range operators {
+				if strings.HasPrefix(line[i:], op) {
+					out = append(out, token{opToken, op})
+					i += len(op)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("unexpected character %q", c)
+			}
+		}
+	}
+	return out, nil
+}
+
+// parser parses one program.
+type parser struct {
+	lines  [][]token
+	lineNo int
+}
+
+// exprParser parses the expression in a single line.
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *exprParser) acceptOp(op string) bool {
+	t, ok := p.peek()
+	if ok && t.kind == opToken && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// binaryPrecedence lists the binary operators by increasing precedence.
+var binaryPrecedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *exprParser) parseBinary(level int) (expr, error) {
+	if level >= len(binaryPrecedence) {
+		return p.parseUnary()
+	}
+	x, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.peek()
+		if !ok || t.kind != opToken {
+			return x, nil
+		}
+		found := false
+		for _, op := range binaryPrecedence[level] {
+			if t.text == op {
+				found = true
+			}
+		}
+		if !found {
+			return x, nil
+		}
+		p.pos++
+		y, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		x = &binaryExpr{op: t.text, x: x, y: y}
+	}
+}
+
+func (p *exprParser) parseUnary() (expr, error) {
+	if p.acceptOp("!") || p.acceptOp("-") {
+		op := p.tokens[p.pos-1].text
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{op: op, x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (expr, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of line")
+	}
+	p.pos++
+	switch t.kind {
+	case intToken:
+		n, err := strconv.ParseInt(t.text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse integer %q: %w", t.text, err)
+		}
+		return &literalExpr{value: Int(n)}, nil
+	case stringToken:
+		return &literalExpr{value: String(t.text)}, nil
+	case identToken:
+		switch t.text {
+		case "true":
+			return &literalExpr{value: Bool(true)}, nil
+		case "false":
+			return &literalExpr{value: Bool(false)}, nil
+		}
+		if !p.acceptOp("(") {
+			return &variableExpr{name: t.text}, nil
+		}
+		c := &callExpr{name: t.text}
+		if p.acceptOp(")") {
+			return c, nil
+		}
+		for {
+			arg, err := p.parseBinary(0)
+			if err != nil {
+				return nil, err
+			}
+			c.args = append(c.args, arg)
+			if p.acceptOp(")") {
+				return c, nil
+			}
+			if !p.acceptOp(",") {
+				return nil, fmt.Errorf("expected , or ) in call to %v", t.text)
+			}
+		}
+	case opToken:
+		if t.text == "(" {
+			x, err := p.parseBinary(0)
+			if err != nil {
+				return nil, err
+			}
+			if !p.acceptOp(")") {
+				return nil, fmt.Errorf("expected )")
+			}
+			return x, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q", t.text)
+}
+
+// parseExpr parses tokens that must form exactly one expression.
+func parseExpr(tokens []token) (expr, error) {
+	p := &exprParser{tokens: tokens}
+	x, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := p.peek(); ok {
+		return nil, fmt.Errorf("unexpected %q after expression", t.text)
+	}
+	return x, nil
+}
+
+// parseBlock parses statements until one of the given keywords starts a line.
+// Returns the keyword found; the line with it is not consumed.
+func (p *parser) parseBlock(terminators ...string) ([]stmt, string, error) {
+	var out []stmt
+	for p.lineNo < len(p.lines) {
+		tokens := p.lines[p.lineNo]
+		if len(tokens) == 0 {
+			p.lineNo++
+			continue
+		}
+		line := p.lineNo + 1
+		head := tokens[0]
+		if head.kind == identToken {
+			for _, term := range terminators {
+				if head.text == term {
+					return out, term, nil
+				}
+			}
+		}
+		p.lineNo++
+		var s stmt
+		var err error
+		switch {
+		case head.kind == identToken && head.text == "if":
+			// Errors from nested blocks already carry a line number.
+			s, err = p.parseIf(line, tokens[1:])
+			if err != nil {
+				return nil, "", err
+			}
+		case head.kind == identToken && head.text == "while":
+			s, err = p.parseWhile(line, tokens[1:])
+			if err != nil {
+				return nil, "", err
+			}
+		case head.kind == identToken && head.text == "return":
+			if len(tokens) != 1 {
+				return nil, "", fmt.Errorf("line %d: return takes no value", line)
+			}
+			s = &returnStmt{line: line}
+		case head.kind == identToken && len(tokens) >= 2 && tokens[1].kind == opToken && tokens[1].text == "=":
+			var value expr
+			value, err = parseExpr(tokens[2:])
+			s = &assignStmt{line: line, name: head.text, value: value}
+		case head.kind == identToken && (head.text == "on" || head.text == "end" || head.text == "else" || head.text == "elif"):
+			return nil, "", fmt.Errorf("line %d: unexpected %v", line, head.text)
+		default:
+			var value expr
+			value, err = parseExpr(tokens)
+			s = &exprStmt{line: line, value: value}
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("line %d: %w", line, err)
+		}
+		out = append(out, s)
+	}
+	if len(terminators) != 0 {
+		return nil, "", fmt.Errorf("unexpected end of script, want %v", strings.Join(terminators, " or "))
+	}
+	return out, "", nil
+}
+
+// expectKeyword consumes a line that must consist of just the given keyword.
+func (p *parser) expectKeyword(keyword string) error {
+	tokens := p.lines[p.lineNo]
+	if len(tokens) != 1 {
+		return fmt.Errorf("line %d: %v takes no arguments", p.lineNo+1, keyword)
+	}
+	p.lineNo++
+	return nil
+}
+
+func (p *parser) parseIf(line int, condTokens []token) (stmt, error) {
+	cond, err := parseExpr(condTokens)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", line, err)
+	}
+	s := &ifStmt{line: line, cond: cond}
+	var term string
+	s.then, term, err = p.parseBlock("elif", "else", "end")
+	if err != nil {
+		return nil, err
+	}
+	switch term {
+	case "elif":
+		elifLine := p.lineNo + 1
+		tokens := p.lines[p.lineNo]
+		p.lineNo++
+		// The nested if consumes the final end.
+		elif, err := p.parseIf(elifLine, tokens[1:])
+		if err != nil {
+			return nil, err
+		}
+		s.els = []stmt{elif}
+		return s, nil
+	case "else":
+		err = p.expectKeyword("else")
+		if err != nil {
+			return nil, err
+		}
+		s.els, _, err = p.parseBlock("end")
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, p.expectKeyword("end")
+}
+
+func (p *parser) parseWhile(line int, condTokens []token) (stmt, error) {
+	cond, err := parseExpr(condTokens)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", line, err)
+	}
+	s := &whileStmt{line: line, cond: cond}
+	s.body, _, err = p.parseBlock("end")
+	if err != nil {
+		return nil, err
+	}
+	return s, p.expectKeyword("end")
+}
+
+// Parse compiles a script.
+//
+// A script consists of event handlers:
+//
+//	on <event>
+//	  <statements>
+//	end
+//
+// Statements are one per line: "name = expr", "if expr" ... "elif expr" ...
+// "else" ... "end", "while expr" ... "end", "return" and function calls.
+// Expressions use integers, "strings", true, false, variables, function calls
+// and the operators || && == != < <= > >= + - * / % ! with the usual precedence.
+// Comments start with #.
+func Parse(src string) (*Program, error) {
+	p := &parser{}
+	for i, line := range strings.Split(src, "\n") {
+		tokens, err := lex(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		p.lines = append(p.lines, tokens)
+	}
+	prog := &Program{handlers: map[string][]stmt{}}
+	for p.lineNo < len(p.lines) {
+		tokens := p.lines[p.lineNo]
+		if len(tokens) == 0 {
+			p.lineNo++
+			continue
+		}
+		line := p.lineNo + 1
+		if len(tokens) != 2 || tokens[0].kind != identToken || tokens[0].text != "on" || tokens[1].kind != identToken {
+			return nil, fmt.Errorf("line %d: expected on <event>", line)
+		}
+		event := tokens[1].text
+		if _, found := prog.handlers[event]; found {
+			return nil, fmt.Errorf("line %d: duplicate handler for %v", line, event)
+		}
+		p.lineNo++
+		body, _, err := p.parseBlock("end")
+		if err != nil {
+			return nil, err
+		}
+		err = p.expectKeyword("end")
+		if err != nil {
+			return nil, err
+		}
+		prog.handlers[event] = body
+	}
+	return prog, nil
+}
@@ -0,0 +1,356 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package script implements a small sandboxed language for level logic.
+//
+// Scripts can only compute with integers and strings and call the functions
+// their Host provides. There is no access to time, randomness or anything
+// else outside the Host, so scripts are deterministic and demos replay.
+package script
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// maxSteps is the number of statements and calls a script may run per event.
+	// This is not a flag as changing it could change what demos do.
+	maxSteps = 10000
+	// maxStringLength is the maximum length of a string built by a script, in bytes.
+	maxStringLength = 4096
+)
+
+// Host provides the functions scripts can call.
+type Host interface {
+	// Call runs the named function.
+	Call(name string, args []Value) (Value, error)
+}
+
+// Program is a parsed script.
+type Program struct {
+	handlers map[string][]stmt
+}
+
+// Handles returns whether the script has a handler for the given event.
+func (p *Program) Handles(event string) bool {
+	_, found := p.handlers[event]
+	return found
+}
+
+// Instance is a program with its variables.
+type Instance struct {
+	prog  *Program
+	host  Host
+	vars  map[string]Value
+	steps int
+	// depth counts the nested Run calls, e.g. when a handler triggers its own entity.
+	depth int
+}
+
+// NewInstance creates a new instance of the program with no variables set.
+func (p *Program) NewInstance(host Host) *Instance {
+	return &Instance{
+		prog: p,
+		host: host,
+		vars: map[string]Value{},
+	}
+}
+
+// errReturn unwinds a handler on return.
+var errReturn = errors.New("return")
+
+// Run runs the handler for the given event, if any.
+// The args are set as variables first. Variables persist between events.
+// Handlers run from within another handler share its step limit.
+func (i *Instance) Run(event string, args map[string]Value) error {
+	body, found := i.prog.handlers[event]
+	if !found {
+		return nil
+	}
+	for k, v := range args {
+		i.vars[k] = v
+	}
+	if i.depth == 0 {
+		i.steps = 0
+	}
+	i.depth++
+	defer func() {
+		i.depth--
+	}()
+	err := i.runBlock(body)
+	if err == errReturn {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("in handler %v: %w", event, err)
+	}
+	return nil
+}
+
+func (i *Instance) step() error {
+	i.steps++
+	if i.steps > maxSteps {
+		return fmt.Errorf("exceeded %d steps", maxSteps)
+	}
+	return nil
+}
+
+func (i *Instance) runBlock(body []stmt) error {
+	for _, s := range body {
+		err := s.run(i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type stmt interface {
+	run(i *Instance) error
+}
+
+type assignStmt struct {
+	line  int
+	name  string
+	value expr
+}
+
+func (s *assignStmt) run(i *Instance) error {
+	if err := i.step(); err != nil {
+		return fmt.Errorf("line %d: %w", s.line, err)
+	}
+	v, err := s.value.eval(i)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", s.line, err)
+	}
+	i.vars[s.name] = v
+	return nil
+}
+
+type exprStmt struct {
+	line  int
+	value expr
+}
+
+func (s *exprStmt) run(i *Instance) error {
+	if err := i.step(); err != nil {
+		return fmt.Errorf("line %d: %w", s.line, err)
+	}
+	_, err := s.value.eval(i)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", s.line, err)
+	}
+	return nil
+}
+
+type ifStmt struct {
+	line      int
+	cond      expr
+	then, els []stmt
+}
+
+func (s *ifStmt) run(i *Instance) error {
+	if err := i.step(); err != nil {
+		return fmt.Errorf("line %d: %w", s.line, err)
+	}
+	cond, err := s.cond.eval(i)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", s.line, err)
+	}
+	if cond.Truthy() {
+		return i.runBlock(s.then)
+	}
+	return i.runBlock(s.els)
+}
+
+type whileStmt struct {
+	line int
+	cond expr
+	body []stmt
+}
+
+func (s *whileStmt) run(i *Instance) error {
+	for {
+		if err := i.step(); err != nil {
+			return fmt.Errorf("line %d: %w", s.line, err)
+		}
+		cond, err := s.cond.eval(i)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", s.line, err)
+		}
+		if !cond.Truthy() {
+			return nil
+		}
+		err = i.runBlock(s.body)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+type returnStmt struct {
+	line int
+}
+
+func (s *returnStmt) run(i *Instance) error {
+	return errReturn
+}
+
+type expr interface {
+	eval(i *Instance) (Value, error)
+}
+
+type literalExpr struct {
+	value Value
+}
+
+func (e *literalExpr) eval(i *Instance) (Value, error) {
+	return e.value, nil
+}
+
+type variableExpr struct {
+	name string
+}
+
+func (e *variableExpr) eval(i *Instance) (Value, error) {
+	v, found := i.vars[e.name]
+	if !found {
+		return Value{}, fmt.Errorf("undefined variable %v", e.name)
+	}
+	return v, nil
+}
+
+type callExpr struct {
+	name string
+	args []expr
+}
+
+func (e *callExpr) eval(i *Instance) (Value, error) {
+	if err := i.step(); err != nil {
+		return Value{}, err
+	}
+	args := make([]Value, len(e.args))
+	for j, arg := range e.args {
+		v, err := arg.eval(i)
+		if err != nil {
+			return Value{}, err
+		}
+		args[j] = v
+	}
+	v, err := i.host.Call(e.name, args)
+	if err != nil {
+		return Value{}, fmt.Errorf("%v: %w", e.name, err)
+	}
+	return v, nil
+}
+
+type unaryExpr struct {
+	op string
+	x  expr
+}
+
+func (e *unaryExpr) eval(i *Instance) (Value, error) {
+	x, err := e.x.eval(i)
+	if err != nil {
+		return Value{}, err
+	}
+	switch e.op {
+	case "!":
+		return Bool(!x.Truthy()), nil
+	case "-":
+		n, err := x.Int()
+		if err != nil {
+			return Value{}, err
+		}
+		return Int(-n), nil
+	}
+	return Value{}, fmt.Errorf("unknown operator %v", e.op)
+}
+
+type binaryExpr struct {
+	op   string
+	x, y expr
+}
+
+func (e *binaryExpr) eval(i *Instance) (Value, error) {
+	x, err := e.x.eval(i)
+	if err != nil {
+		return Value{}, err
+	}
+	// Short circuit evaluation.
+	switch e.op {
+	case "&&":
+		if !x.Truthy() {
+			return Bool(false), nil
+		}
+	case "||":
+		if x.Truthy() {
+			return Bool(true), nil
+		}
+	}
+	y, err := e.y.eval(i)
+	if err != nil {
+		return Value{}, err
+	}
+	switch e.op {
+	case "&&", "||":
+		return Bool(y.Truthy()), nil
+	case "==":
+		return Bool(x.equal(y)), nil
+	case "!=":
+		return Bool(!x.equal(y)), nil
+	case "+":
+		if x.IsString() || y.IsString() {
+			xs, ys := x.String(), y.String()
+			if len(xs)+len(ys) > maxStringLength {
+				return Value{}, fmt.Errorf("string longer than %d bytes", maxStringLength)
+			}
+			return String(xs + ys), nil
+		}
+	}
+	a, err := x.Int()
+	if err != nil {
+		return Value{}, fmt.Errorf("operator %v: %w", e.op, err)
+	}
+	b, err := y.Int()
+	if err != nil {
+		return Value{}, fmt.Errorf("operator %v: %w", e.op, err)
+	}
+	switch e.op {
+	case "<":
+		return Bool(a < b), nil
+	case "<=":
+		return Bool(a <= b), nil
+	case ">":
+		return Bool(a > b), nil
+	case ">=":
+		return Bool(a >= b), nil
+	case "+":
+		return Int(a + b), nil
+	case "-":
+		return Int(a - b), nil
+	case "*":
+		return Int(a * b), nil
+	case "/", "%":
+		if b == 0 {
+			return Value{}, errors.New("division by zero")
+		}
+		if e.op == "/" {
+			return Int(a / b), nil
+		}
+		return Int(a % b), nil
+	}
+	return Value{}, fmt.Errorf("unknown operator %v", e.op)
+}
@@ -0,0 +1,162 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package script
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testHost records printed values and lets scripts run handlers recursively.
+type testHost struct {
+	inst    *Instance
+	printed []string
+}
+
+func (h *testHost) Call(name string, args []Value) (Value, error) {
+	switch name {
+	case "print":
+		strs := make([]string, len(args))
+		for i, arg := range args {
+			strs[i] = arg.String()
+		}
+		h.printed = append(h.printed, strings.Join(strs, " "))
+		return Value{}, nil
+	case "trigger":
+		return Value{}, h.inst.Run(args[0].String(), nil)
+	case "fail":
+		return Value{}, errors.New("failed")
+	}
+	return Value{}, errors.New("unknown function")
+}
+
+func run(t *testing.T, src, event string) ([]string, error) {
+	t.Helper()
+	prog, err := Parse(src)
+	if err != nil {
+		t.Fatalf("could not parse %q: %v", src, err)
+	}
+	h := &testHost{}
+	h.inst = prog.NewInstance(h)
+	err = h.inst.Run(event, map[string]Value{"arg": Int(42)})
+	return h.printed, err
+}
+
+func TestRun(t *testing.T) {
+	for _, c := range []struct {
+		src  string
+		want string
+	}{
+		{"on e\nprint(1 + 2 * 3, (1 + 2) * 3)\nend", "7 9"},
+		{"on e\nprint(7 / 2, 7 % 2, -7 / 2, 1 - -1)\nend", "3 1 -3 2"},
+		{"on e\nprint(3000000000 * 3)\nend", "9000000000"},
+		{"on e\nprint(\"a\" + 1, 1 + \"b\", \"say \\\"hi\\\"\")\nend", "a1 1b say \"hi\""},
+		{"on e\nprint(1 < 2 && 2 <= 2, 1 > 2 || 2 >= 3, !0, 1 == \"1\", \"x\" != \"y\")\nend", "1 0 1 0 1"},
+		{"on e\nprint(0 && fail(), 1 || fail())\nend", "0 1"},
+		{"on e\nprint(arg)\nend", "42"},
+		{"# comment\non e\n  x = 1 # set x\n  print(x)\nend", "1"},
+		{"on e\nif arg == 1\nprint(\"one\")\nelif arg == 42\nprint(\"answer\")\nelse\nprint(\"other\")\nend\nend", "answer"},
+		{"on e\nif false\nprint(1)\nelse\nprint(2)\nend\nend", "2"},
+		{"on e\nn = 0\nwhile n < 5\nn = n + 1\nend\nprint(n)\nend", "5"},
+		{"on e\nprint(1)\nreturn\nprint(2)\nend", "1"},
+		{"on e\ntrigger(\"f\")\nprint(\"e\")\nend\non f\nprint(\"f\")\nend", "f\ne"},
+		{"on other\nprint(1)\nend", ""},
+	} {
+		printed, err := run(t, c.src, "e")
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", c.src, err)
+			continue
+		}
+		if got := strings.Join(printed, "\n"); got != c.want {
+			t.Errorf("%q: got %q, want %q", c.src, got, c.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, c := range []struct {
+		src  string
+		want string
+	}{
+		{"print(1)", "line 1: expected on <event>"},
+		{"on e\nprint(1)", "unexpected end of script, want end"},
+		{"on e\nend\non e\nend", "line 3: duplicate handler for e"},
+		{"on e\nprint(\"x)\nend", "line 2: unterminated string"},
+		{"on e\nx = 1 $ 2\nend", "line 2: unexpected character '$'"},
+		{"on e\nx = (1 + 2\nend", "line 2:"},
+		{"on e\nreturn 1\nend", "line 2: return takes no value"},
+		{"on e\nif 1\nelse 2\nend\nend", "line 3: else takes no arguments"},
+		{"on e\non f\nend", "line 2: unexpected on"},
+		{"on e\nx = 99999999999999999999\nend", "line 2: could not parse integer"},
+	} {
+		_, err := Parse(c.src)
+		if err == nil {
+			t.Errorf("%q: parsed without error", c.src)
+			continue
+		}
+		if !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%q: got error %q, want %q", c.src, err, c.want)
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	for _, c := range []struct {
+		src  string
+		want string
+	}{
+		{"on e\nprint(x)\nend", "in handler e: line 2: undefined variable x"},
+		{"on e\nx = 1 / 0\nend", "line 2: division by zero"},
+		{"on e\nx = 1 % 0\nend", "line 2: division by zero"},
+		{"on e\nx = \"a\" * 2\nend", "line 2: operator *: expected an integer"},
+		{"on e\nx = -\"a\"\nend", "line 2: expected an integer"},
+		{"on e\nfail()\nend", "line 2: fail: failed"},
+		{"on e\nnope()\nend", "line 2: nope: unknown function"},
+		{"on e\nwhile true\nend\nend", "exceeded 10000 steps"},
+		{"on e\ns = \"x\"\nwhile true\ns = s + s\nend\nend", "string longer than 4096 bytes"},
+	} {
+		_, err := run(t, c.src, "e")
+		if err == nil {
+			t.Errorf("%q: ran without error", c.src)
+			continue
+		}
+		if !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%q: got error %q, want %q", c.src, err, c.want)
+		}
+	}
+}
+
+func TestStepLimitAcrossReentry(t *testing.T) {
+	// Each handler run alone stays within the limit, but they trigger each other endlessly.
+	_, err := run(t, "on e\ntrigger(\"e\")\nend", "e")
+	if err == nil || !strings.Contains(err.Error(), "exceeded 10000 steps") {
+		t.Errorf("got error %v, want step limit", err)
+	}
+}
+
+func TestStepLimitResets(t *testing.T) {
+	prog, err := Parse("on e\nn = 0\nwhile n < 3000\nn = n + 1\nend\nend")
+	if err != nil {
+		t.Fatalf("could not parse: %v", err)
+	}
+	inst := prog.NewInstance(&testHost{})
+	for i := 0; i < 10; i++ {
+		err := inst.Run("e", nil)
+		if err != nil {
+			t.Fatalf("run %d: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package script
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Value is a script value: either an integer or a string.
+// Booleans are the integers 0 and 1.
+type Value struct {
+	str   string
+	num   int64
+	isStr bool
+}
+
+// Int returns an integer value.
+func Int(n int64) Value {
+	return Value{num: n}
+}
+
+// String returns a string value.
+func String(s string) Value {
+	return Value{str: s, isStr: true}
+}
+
+// Bool returns a boolean value.
+func Bool(b bool) Value {
+	if b {
+		return Int(1)
+	}
+	return Int(0)
+}
+
+// IsString returns whether the value is a string.
+func (v Value) IsString() bool {
+	return v.isStr
+}
+
+// Truthy returns whether the value counts as true in conditions.
+func (v Value) Truthy() bool {
+	if v.isStr {
+		return v.str != ""
+	}
+	return v.num != 0
+}
+
+// Int returns the integer value, or an error for strings.
+func (v Value) Int() (int64, error) {
+	if v.isStr {
+		return 0, fmt.Errorf("expected an integer, got string %q", v.str)
+	}
+	return v.num, nil
+}
+
+// String returns the value as text.
+func (v Value) String() string {
+	if v.isStr {
+		return v.str
+	}
+	return strconv.FormatInt(v.num, 10)
+}
+
+// equal compares two values; an integer never equals a string.
+func (v Value) equal(other Value) bool {
+	return v == other
+}
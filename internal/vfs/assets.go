@@ -19,6 +19,7 @@ import (
 	"io"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/divVerent/aaaaxy/internal/log"
 )
@@ -48,6 +49,16 @@ func Load(purpose, name string) (ReadSeekCloser, error) {
 	return load(vfsPath)
 }
 
+// ModTime returns the modification time of a file.
+// Returns the zero time if the file comes from an archive or the executable.
+func ModTime(purpose, name string) (time.Time, error) {
+	if strings.ContainsRune(name, '/') {
+		log.Fatalf("noncanonical path: %v %v", purpose, name)
+	}
+	vfsPath := fmt.Sprintf("/%s/%s", purpose, name)
+	return modTime(vfsPath)
+}
+
 // ReadDir lists all files in a directory. Returns their VFS paths!
 func ReadDir(purpose string) ([]string, error) {
 	vfsPath := fmt.Sprintf("/%s/", purpose)
@@ -23,6 +23,7 @@ import (
 	"path"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/divVerent/aaaaxy/internal/exitstatus"
 	"github.com/divVerent/aaaaxy/internal/flag"
@@ -46,7 +47,11 @@ func (f fsRoot) String() string {
 }
 
 var (
+	// assetDirs are the directories of the game's own assets.
 	assetDirs []fsRoot
+
+	// searchDirs are the directories files are looked up in, i.e. assetDirs followed by those of the selected level pack.
+	searchDirs []fsRoot
 )
 
 func dumpAssetsFrom(dir fsRoot) error {
@@ -115,6 +120,7 @@ func initAssets() error {
 	}
 
 	log.Infof("asset search path: %v", assetDirs)
+	searchDirs = assetDirs
 
 	if *dumpEmbeddedAssets != "" {
 		err := dumpAssets()
@@ -125,13 +131,17 @@ func initAssets() error {
 		return exitstatus.ErrRegularTermination
 	}
 
+	err := initLevelPacks()
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
 // load loads a file from the VFS.
 func load(vfsPath string) (ReadSeekCloser, error) {
 	var err error
-	for _, dir := range assetDirs {
+	for _, dir := range searchDirs {
 		if !strings.HasPrefix(vfsPath, dir.toPrefix) {
 			continue
 		}
@@ -154,10 +164,28 @@ func load(vfsPath string) (ReadSeekCloser, error) {
 	return nil, fmt.Errorf("could not open %v: %w", vfsPath, err)
 }
 
+// modTime returns the modification time of a file in the VFS.
+func modTime(vfsPath string) (time.Time, error) {
+	var err error
+	for _, dir := range searchDirs {
+		if !strings.HasPrefix(vfsPath, dir.toPrefix) {
+			continue
+		}
+		relPath := strings.TrimPrefix(vfsPath, dir.toPrefix)
+		var info fs.FileInfo
+		info, err = fs.Stat(dir.filesys, path.Join(dir.root, relPath))
+		if err != nil {
+			continue
+		}
+		return info.ModTime(), nil
+	}
+	return time.Time{}, fmt.Errorf("could not stat %v: %w", vfsPath, err)
+}
+
 // readDir lists all files in a directory. Returns their VFS names, NOT full paths!
 func readDir(vfsPath string) ([]string, error) {
 	var results []string
-	for _, dir := range assetDirs {
+	for _, dir := range searchDirs {
 		if !strings.HasPrefix(vfsPath, dir.toPrefix) {
 			continue
 		}
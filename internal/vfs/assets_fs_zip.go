@@ -22,65 +22,14 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"io/fs"
 
 	"github.com/divVerent/aaaaxy/internal/flag"
-	"github.com/divVerent/aaaaxy/internal/log"
 )
 
 var (
 	pinAssetsToRAM = flag.Bool("pin_assets_to_ram", false, "if enabled, keep all asset data in RAM in compressed form rather than loading from the file system as needed")
 )
 
-// Make it seekable.
-type seekingFS struct {
-	fs.FS
-}
-
-type closableBytesReader struct {
-	*bytes.Reader
-	f fs.File
-}
-
-func (c closableBytesReader) Close() error {
-	return nil
-}
-
-func (c closableBytesReader) Stat() (fs.FileInfo, error) {
-	return c.f.Stat()
-}
-
-func makeSeekable(name string, f fs.File) (fs.File, error) {
-	if _, ok := f.(ReadSeekCloser); ok {
-		return f, nil
-	}
-	info, err := f.Stat()
-	if err != nil {
-		log.Errorf("failed to stat %v: %v", name, err)
-		return f, nil
-	}
-	if info.IsDir() {
-		return f, nil
-	}
-	c, closable := f.(io.Closer)
-	if closable {
-		defer c.Close()
-	}
-	data, err := io.ReadAll(f)
-	if err != nil {
-		log.Errorf("failed to read %v: %v", name, err)
-	}
-	return closableBytesReader{bytes.NewReader(data), f}, nil
-}
-
-func (s seekingFS) Open(name string) (fs.File, error) {
-	f, err := s.FS.Open(name)
-	if err != nil {
-		return nil, err
-	}
-	return makeSeekable(name, f)
-}
-
 // initAssetsFS opens the zip file systems.
 func initAssetsFS() ([]fsRoot, error) {
 	zipf, err := openAssetsZip()
@@ -0,0 +1,255 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vfs
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/divVerent/aaaaxy/internal/log"
+)
+
+// LevelPack is a user installed set of levels.
+//
+// Level packs are directories or zip files in the levelpacks directory next
+// to the save games. Their content is laid out like the assets directory,
+// i.e. maps/*.tmx, tiles/*.tsx, sprites/*.png and generated/*.cp.json. An
+// optional pack.json at the root provides the metadata.
+//
+// Each level pack is only visible while one of its levels is selected by
+// SetLevelPack, so packs cannot see each other's files. It is searched after
+// all embedded assets, so it can only add files and never replace any.
+type LevelPack struct {
+	// Name is the file name of the pack without extension.
+	Name string `json:"-"`
+	// Title, Author and Version come from pack.json.
+	Title   string
+	Author  string
+	Version string
+	// Maps lists the level names the pack provides.
+	Maps []string `json:"-"`
+}
+
+// levelPackDir is the name of the level pack directory in the saved games directory.
+const levelPackDir = "levelpacks"
+
+// levelPackPurposes are the asset directories a level pack may provide.
+var levelPackPurposes = []string{"maps", "tiles", "sprites", "generated"}
+
+var (
+	levelPacks []LevelPack
+
+	// levelPackDirs are the directories of each level pack, by pack name.
+	levelPackDirs = map[string][]fsRoot{}
+
+	// levelPack is the name of the level pack currently visible.
+	levelPack string
+)
+
+// LevelPacks returns all installed level packs.
+func LevelPacks() []LevelPack {
+	return levelPacks
+}
+
+// SetLevelPack makes the files of the named level pack visible, hiding those of all other packs.
+// An empty name selects no level pack.
+func SetLevelPack(name string) {
+	if name == levelPack {
+		return
+	}
+	levelPack = name
+	searchDirs = append(assetDirs[:len(assetDirs):len(assetDirs)], levelPackDirs[name]...)
+	log.Infof("asset search path: %v", searchDirs)
+}
+
+// SelectLevel makes the level pack providing the given level visible, if any.
+func SelectLevel(level string) {
+	for _, pack := range levelPacks {
+		if slices.Contains(pack.Maps, level) {
+			SetLevelPack(pack.Name)
+			return
+		}
+	}
+	SetLevelPack("")
+}
+
+// WithLevelPack runs f while the named level pack is visible.
+func WithLevelPack(name string, f func() error) error {
+	prev := levelPack
+	SetLevelPack(name)
+	defer SetLevelPack(prev)
+	return f()
+}
+
+// LevelPackName returns the name of the level pack currently visible.
+func LevelPackName() string {
+	return levelPack
+}
+
+// IsFromLevelPack returns whether the given file is provided by the current level pack.
+func IsFromLevelPack(purpose, name string) bool {
+	vfsPath := fmt.Sprintf("/%s/%s", purpose, name)
+	for _, dir := range searchDirs {
+		if !strings.HasPrefix(vfsPath, dir.toPrefix) {
+			continue
+		}
+		relPath := strings.TrimPrefix(vfsPath, dir.toPrefix)
+		if _, err := fs.Stat(dir.filesys, path.Join(dir.root, relPath)); err != nil {
+			continue
+		}
+		return strings.HasPrefix(dir.name, "pack:")
+	}
+	return false
+}
+
+// hasLevel returns whether a level of the given name is already known.
+func hasLevel(level string) bool {
+	if f, err := load("/maps/" + level + ".tmx"); err == nil {
+		f.Close()
+		return true
+	}
+	for _, pack := range levelPacks {
+		if slices.Contains(pack.Maps, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// levelPackRoot finds the directory inside the pack that contains the maps.
+// This allows zip files containing a single top level directory.
+func levelPackRoot(filesys fs.FS) (string, error) {
+	if info, err := fs.Stat(filesys, "maps"); err == nil && info.IsDir() {
+		return ".", nil
+	}
+	content, err := fs.ReadDir(filesys, ".")
+	if err != nil {
+		return "", err
+	}
+	if len(content) == 1 && content[0].IsDir() {
+		root := content[0].Name()
+		if info, err := fs.Stat(filesys, path.Join(root, "maps")); err == nil && info.IsDir() {
+			return root, nil
+		}
+	}
+	return "", errors.New("no maps directory found")
+}
+
+func loadLevelPack(name string, filesys fs.FS) (*LevelPack, []fsRoot, error) {
+	root, err := levelPackRoot(filesys)
+	if err != nil {
+		return nil, nil, err
+	}
+	pack := &LevelPack{
+		Name:  name,
+		Title: name,
+	}
+	info, err := fs.ReadFile(filesys, path.Join(root, "pack.json"))
+	if err == nil {
+		err = json.Unmarshal(info, pack)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not parse pack.json: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, fmt.Errorf("could not read pack.json: %w", err)
+	}
+	maps, err := fs.ReadDir(filesys, path.Join(root, "maps"))
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, m := range maps {
+		level, isTMX := strings.CutSuffix(m.Name(), ".tmx")
+		if m.IsDir() || !isTMX {
+			continue
+		}
+		if hasLevel(level) {
+			// Level names must be unique, as they select the level pack.
+			log.Warningf("level %v from level pack %v is shadowed by an already existing level - ignoring it", level, name)
+			continue
+		}
+		pack.Maps = append(pack.Maps, level)
+	}
+	sort.Strings(pack.Maps)
+	var dirs []fsRoot
+	for _, purpose := range levelPackPurposes {
+		dirs = append(dirs, fsRoot{
+			name:     "pack:" + name,
+			filesys:  seekingFS{filesys},
+			root:     path.Join(root, purpose),
+			toPrefix: "/" + purpose + "/",
+		})
+	}
+	return pack, dirs, nil
+}
+
+// initLevelPacks adds all installed level packs to the VFS.
+func initLevelPacks() error {
+	dir, err := levelPackPath()
+	if err != nil {
+		log.Infof("level packs cannot be found: %v", err)
+		return nil
+	}
+	content, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not scan level packs in %v: %w", dir, err)
+	}
+	for _, info := range content {
+		var name string
+		var filesys fs.FS
+		p := filepath.Join(dir, info.Name())
+		if info.IsDir() {
+			name = info.Name()
+			filesys = os.DirFS(p)
+		} else if n, isZip := strings.CutSuffix(info.Name(), ".zip"); isZip {
+			name = n
+			filesys, err = zip.OpenReader(p)
+			if err != nil {
+				log.Errorf("could not open level pack %v: %v", p, err)
+				continue
+			}
+		} else {
+			continue
+		}
+		if _, found := levelPackDirs[name]; found {
+			log.Warningf("level pack %v has the same name as another level pack - ignoring it", p)
+			continue
+		}
+		pack, dirs, err := loadLevelPack(name, filesys)
+		if err != nil {
+			log.Errorf("could not load level pack %v: %v", p, err)
+			continue
+		}
+		if len(pack.Maps) == 0 {
+			log.Warningf("level pack %v provides no levels - ignoring it", p)
+			continue
+		}
+		log.Infof("loaded level pack %v (%v) with levels %v", pack.Name, pack.Title, pack.Maps)
+		levelPacks = append(levelPacks, *pack)
+		levelPackDirs[pack.Name] = dirs
+	}
+	return nil
+}
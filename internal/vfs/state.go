@@ -15,6 +15,8 @@
 package vfs
 
 import (
+	"os"
+
 	"github.com/divVerent/aaaaxy/internal/flag"
 	"github.com/divVerent/aaaaxy/internal/log"
 )
@@ -58,6 +60,10 @@ func ReadState(kind StateKind, name string) ([]byte, error) {
 		buf, found := readonlyBuffer[key]
 		if found {
 			log.Infof("readonly: forcing read of %v from memory", key)
+			if buf == nil {
+				// Removed.
+				return nil, os.ErrNotExist
+			}
 			return append([]byte(nil), buf...), nil
 		}
 	}
@@ -77,3 +83,17 @@ func WriteState(kind StateKind, name string, data []byte) error {
 	}
 	return writeState(kind, name, data)
 }
+
+// RemoveState deletes the given state file. A missing file is not an error.
+func RemoveState(kind StateKind, name string) error {
+	if crashOnWrite != nil {
+		log.Fatalf("attempted to remove data despite %s", *crashOnWrite)
+	}
+	if *readonly {
+		key := readonlyKey{kind: kind, name: name}
+		log.Infof("readonly: forcing removal of %v from memory", key)
+		readonlyBuffer[key] = nil
+		return nil
+	}
+	return removeState(kind, name)
+}
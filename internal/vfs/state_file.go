@@ -69,6 +69,11 @@ func pathForWrite(kind StateKind, name string) (string, error) {
 	return pathForWriteRaw(kind, name)
 }
 
+// levelPackPath returns the directory holding level packs.
+func levelPackPath() (string, error) {
+	return pathForWrite(SavedGames, levelPackDir)
+}
+
 func initState() error {
 	path, err := pathForWrite(Config, "*")
 	if err != nil {
@@ -137,3 +142,19 @@ func writeState(kind StateKind, name string, data []byte) error {
 	}
 	return os.WriteFile(path, data, 0666)
 }
+
+// removeState deletes the given state file.
+func removeState(kind StateKind, name string) error {
+	paths, err := pathForRead(kind, name)
+	if err != nil {
+		return nil
+	}
+	var lastErr error
+	for _, path := range paths {
+		err := os.Remove(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
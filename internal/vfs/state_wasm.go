@@ -32,6 +32,11 @@ func initState() error {
 	return nil
 }
 
+// levelPackPath returns the directory holding level packs.
+func levelPackPath() (string, error) {
+	return "", errors.New("level packs are not supported in the browser")
+}
+
 func protectJS(f func()) (err error) {
 	ok := false
 	defer func() {
@@ -86,3 +91,11 @@ func writeState(kind StateKind, name string, data []byte) error {
 		js.Global().Get("localStorage").Call("setItem", js.ValueOf(path), js.ValueOf(string(data)))
 	})
 }
+
+// removeState deletes the given state file.
+func removeState(kind StateKind, name string) error {
+	path := fmt.Sprintf("%d/%s", kind, name)
+	return protectJS(func() {
+		js.Global().Get("localStorage").Call("removeItem", js.ValueOf(path))
+	})
+}
@@ -21,6 +21,7 @@ while read -r type xml; do
 			AppearBlock)          color=00aa00 ;;
 			Checkpoint)           color=008000 ;;
 			CheckpointTarget)     color=008000 ;;
+			CounterTarget)        color=000000 ;;
 			CoverSprite)          color=ffffff ;;
 			CreditsTarget)        color=ff00ff ;;
 			DelayTarget)          color=000000 ;;
@@ -38,9 +39,11 @@ while read -r type xml; do
 			Player)               color=008000 ;;
 			PrintToConsoleTarget) color=000000 ;;
 			QuestionBlock)        color=000000 ;;
+			RandomTarget)         color=000000 ;;
 			RespawnPlayer)        color=ff0000 ;;
 			Riser)                color=000080 ;;
 			RiserFsck)            color=ffff80 ;;
+			ScriptEntity)         color=000000 ;;
 			SequenceCollector)    color=00ff00 ;;
 			SequenceTarget)       color=00ff00 ;;
 			SetState)             color=ff0000 ;;
@@ -57,6 +60,7 @@ while read -r type xml; do
 			SwitchMusic)          color=00ff00 ;;
 			SwitchMusicTarget)    color=00ff00 ;;
 			Text)                 color=ffffff ;;
+			TimerTarget)          color=000000 ;;
 			TnihSign)             color=ffff00 ;;
 			VVVVVV)               color=00ff00 ;;
 			WarpZone)             color=ff0000 ;;